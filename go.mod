@@ -14,6 +14,7 @@ require (
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible
 	github.com/go-chi/chi v3.3.2+incompatible
 	github.com/go-enry/go-enry/v2 v2.8.2
+	github.com/go-git/go-git/v5 v5.4.2
 	github.com/golang/mock v1.4.4
 	github.com/google/go-github/v30 v30.1.0
 	github.com/google/go-github/v32 v32.1.0
@@ -22,6 +23,7 @@ require (
 	github.com/libgit2/git2go/v31 v31.7.9
 	github.com/reiver/go-porterstemmer v1.0.1
 	github.com/rs/zerolog v1.26.1
+	github.com/sergi/go-diff v1.1.0
 	github.com/stretchr/testify v1.7.1
 	github.com/suhaibmujahid/go-bitbucket-server v0.1.0
 	github.com/surge/glog v0.0.0-20141108051140-2578deb2b95c // indirect
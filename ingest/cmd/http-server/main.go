@@ -55,6 +55,9 @@ func main() {
 
 	var (
 		commitsDB       = mongosrc.NewCommitDataSource(ctx, db)
+		bugLinksDB      = mongosrc.NewBugLinkDataSource(ctx, db)
+		releasesDB      = mongosrc.NewReleaseDataSource(ctx, db)
+		checkpointsDB   = mongosrc.NewCheckpointDataSource(ctx, db)
 		montorDB        = mongosrc.NewMontorDataSource(db)
 		reposDB         = mongosrc.NewRepositoryDataSource(db, montorDB)
 		jobsDB          = mongosrc.NewJobDataSource(db)
@@ -64,6 +67,7 @@ func main() {
 		verificationDB  = mongosrc.NewVerificationDataSource(ctx, db)
 		visitDB         = mongosrc.NewVisitDataSource(db)
 		feedbackDB      = mongosrc.NewFeedbackDataSource(db)
+		jobQueue        = mongosrc.NewJobQueue(ctx, db)
 	)
 
 	auth := jwtauth.NewAuthenticator(serviceName, cfg.Keys.PrivateKey, nil, nil)
@@ -76,17 +80,25 @@ func main() {
 
 	authCheck := jwtauth.NewAuthCheck(jwtauth.NewLocalKeySource(cfg.Keys.PublicKeys))
 
-	manager := manage.NewManager(ctx, manage.ManagerServices{
+	manager, err := manage.NewManager(ctx, manage.ManagerServices{
 		Provider:     providersDB,
 		Commit:       commitsDB,
+		BugLink:      bugLinksDB,
+		Release:      releasesDB,
+		Checkpoint:   checkpointsDB,
 		Repo:         reposDB,
 		Job:          jobsDB,
 		PullRequest:  pullsDB,
 		Organization: organizationsDB,
 		Verification: verificationDB,
 		Monitor:      montorDB,
+		Queue:        jobQueue,
 		Repofuel:     rfc,
-	}, authCheck)
+	}, authCheck, &cfg.Manager)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed in creating the jobs manager")
+	}
+
 	if err := manager.Recover(ctx); err != nil {
 		log.Fatal().Err(err).Msg("problem in recovering the stuck repositories")
 	}
@@ -98,6 +110,8 @@ func main() {
 		RepofuelClient: rfc,
 		FeedbackDB:     feedbackDB,
 		CommitDB:       commitsDB,
+		BugLinkDB:      bugLinksDB,
+		ReleaseDB:      releasesDB,
 		RepositoryDB:   reposDB,
 		PullRequestDB:  pullsDB,
 		JobDB:          jobsDB,
@@ -1,9 +1,18 @@
 package graph
 
 import (
+	"context"
+	"errors"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/repofuel/repofuel/ingest/graph/model"
+	"github.com/repofuel/repofuel/ingest/internal/accesscontrol"
 	"github.com/repofuel/repofuel/ingest/internal/entity"
+	"github.com/repofuel/repofuel/ingest/pkg/branchfilter"
 	"github.com/repofuel/repofuel/ingest/pkg/classify"
 	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+	"github.com/repofuel/repofuel/ingest/pkg/identity"
+	"github.com/repofuel/repofuel/ingest/pkg/subsystem"
 )
 
 func tagsToStrings(org []classify.Tag) []string {
@@ -14,9 +23,13 @@ func tagsToStrings(org []classify.Tag) []string {
 	return tags
 }
 
-func hashToBranches(org map[string]identifier.Hash) []*entity.Branch {
+func hashToBranches(org map[string]identifier.Hash, filter *branchfilter.Filter) []*entity.Branch {
 	branches := make([]*entity.Branch, 0, len(org))
 	for name, h := range org {
+		if !filter.Match(name) {
+			continue
+		}
+
 		b := &entity.Branch{
 			Name: name,
 			SHA:  h.Hex(),
@@ -25,3 +38,66 @@ func hashToBranches(org map[string]identifier.Hash) []*entity.Branch {
 	}
 	return branches
 }
+
+func developerAliasesFromInput(input []*model.DeveloperAliasInput) []*identity.Alias {
+	aliases := make([]*identity.Alias, len(input))
+	for i, a := range input {
+		aliases[i] = &identity.Alias{
+			Email:  a.Email,
+			Emails: a.Emails,
+			Logins: a.Logins,
+		}
+		if a.Name != nil {
+			aliases[i].Name = *a.Name
+		}
+	}
+	return aliases
+}
+
+func subsystemConfigFromInput(input *model.SubsystemConfigInput) *subsystem.Config {
+	cfg := &subsystem.Config{
+		Rules: make([]*subsystem.Rule, len(input.Rules)),
+	}
+	if input.Source != nil {
+		cfg.Source = subsystem.Source(*input.Source)
+	}
+	for i, r := range input.Rules {
+		cfg.Rules[i] = &subsystem.Rule{
+			Pattern: r.Pattern,
+			Name:    r.Name,
+		}
+		if r.Regexp != nil {
+			cfg.Rules[i].Regexp = *r.Regexp
+		}
+	}
+	return cfg
+}
+
+// parentCommit returns the closest commit in the resolved parents of the field.
+func parentCommit(ctx context.Context) *entity.Commit {
+	for fc := graphql.GetFieldContext(ctx); fc != nil; fc = fc.Parent {
+		if c, ok := fc.Result.(*entity.Commit); ok {
+			return c
+		}
+	}
+	return nil
+}
+
+// administeredRepository returns the repository if the viewer can administer it.
+func (r *Resolver) administeredRepository(ctx context.Context, id string) (*entity.Repository, error) {
+	repoID, err := NodeIdToRepoId(id)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := r.RepositoryDB.FindByID(ctx, repoID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !accesscontrol.UserPermissions(context.WithValue(ctx, accesscontrol.RepositoryCtxKey, repo)).Admin {
+		return nil, errors.New("only the repository admins can change the repository")
+	}
+
+	return repo, nil
+}
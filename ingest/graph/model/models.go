@@ -15,24 +15,26 @@ import (
 )
 
 type (
-	ProgressEvent     = manage.ProgressObservable
-	Progress          = manage.Progress
-	Tag               = classify.Tag
-	Stage             = status.Stage
-	Signature         = engine.Signature
-	ChangeMeasures    = metrics.ChangeMeasures
-	FileMeasures      = metrics.FileMeasures
-	RepositorySource  = common.Repository
-	Owner             = common.Account
-	PullRequestSource = common.PullRequest
-	JobInvoker        = invoke.Action
-	JobLogEntry       = entity.Update
-	Issue             = common.Issue
-	Role              = permission.Role
-	CommitFile        = entity.File
-	DeltaType         = engine.DeltaType
-	Insight           = insights.Reason
-	UserProviderInfo  = common.User
+	ProgressEvent      = manage.ProgressObservable
+	Progress           = manage.Progress
+	Tag                = classify.Tag
+	Stage              = status.Stage
+	Signature          = engine.Signature
+	ChangeMeasures     = metrics.ChangeMeasures
+	FileMeasures       = metrics.FileMeasures
+	FunctionMeasures   = metrics.FunctionMeasures
+	ComplexityMeasures = metrics.ComplexityMeasures
+	RepositorySource   = common.Repository
+	Owner              = common.Account
+	PullRequestSource  = common.PullRequest
+	JobInvoker         = invoke.Action
+	JobLogEntry        = entity.Update
+	Issue              = common.Issue
+	Role               = permission.Role
+	CommitFile         = entity.File
+	DeltaType          = engine.DeltaType
+	Insight            = insights.Reason
+	UserProviderInfo   = common.User
 )
 
 type User struct {
@@ -49,5 +51,5 @@ type User struct {
 func (_ *User) IsRepositoryOwner() {}
 func (_ *User) IsNode()            {}
 
-//deprecated
+// deprecated
 type Activity struct{}
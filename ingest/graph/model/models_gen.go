@@ -8,7 +8,9 @@ import (
 	"strconv"
 
 	"github.com/repofuel/repofuel/ingest/internal/entity"
+	"github.com/repofuel/repofuel/ingest/pkg/branchfilter"
 	"github.com/repofuel/repofuel/ingest/pkg/classify"
+	"github.com/repofuel/repofuel/ingest/pkg/szz"
 )
 
 type Node interface {
@@ -54,10 +56,29 @@ type DeleteRepositoryPayload struct {
 	Repository *entity.Repository `json:"repository"`
 }
 
+type DeveloperAliasInput struct {
+	Name   *string  `json:"name"`
+	Email  string   `json:"email"`
+	Emails []string `json:"emails"`
+	Logins []string `json:"logins"`
+}
+
+type DropReasonCountConnection struct {
+	Nodes []*entity.DropReasonCount `json:"nodes"`
+}
+
 type MonitorRepositoryPayload struct {
 	Repository *entity.Repository `json:"repository"`
 }
 
+type PauseRepositoryPayload struct {
+	Repository *entity.Repository `json:"repository"`
+}
+
+type ResumeRepositoryPayload struct {
+	Repository *entity.Repository `json:"repository"`
+}
+
 type SendCommitFeedbackInput struct {
 	CommitID string `json:"commitID"`
 	Message  string `json:"message"`
@@ -67,13 +88,35 @@ type StopRepositoryMonitoringPayload struct {
 	Repository *entity.Repository `json:"repository"`
 }
 
+type SubsystemConfigInput struct {
+	Rules  []*SubsystemRuleInput `json:"rules"`
+	Source *string               `json:"source"`
+}
+
+type SubsystemRuleInput struct {
+	Pattern string `json:"pattern"`
+	Regexp  *bool  `json:"regexp"`
+	Name    string `json:"name"`
+}
+
 type TagsCountConnection struct {
 	Nodes []*entity.TagCount `json:"nodes"`
 }
 
 type UpdateRepositoryInput struct {
-	ID           string             `json:"id"`
-	ChecksConfig *ChecksConfigInput `json:"checksConfig"`
+	ID               string                 `json:"id"`
+	ChecksConfig     *ChecksConfigInput     `json:"checksConfig"`
+	DeveloperAliases []*DeveloperAliasInput `json:"developerAliases"`
+	// Changing the subsystems recomputes the subsystem metrics of the analyzed commits.
+	SubsystemConfig *SubsystemConfigInput `json:"subsystemConfig"`
+	// The path patterns apply to the commits that are analyzed after changing them.
+	PathConfig *classify.PathConfig `json:"pathConfig"`
+	// The commits that are only in the excluded branches are removed by a refresh of the repository.
+	BranchConfig *branchfilter.Config `json:"branchConfig"`
+	// The SZZ config applies to the fixes that are analyzed after changing it.
+	SzzConfig *szz.Config `json:"szzConfig"`
+	// The metrics config applies to the commits that are analyzed after changing it.
+	MetricsConfig *entity.MetricsConfig `json:"metricsConfig"`
 }
 
 type UpdateRepositoryPayload struct {
@@ -37,6 +37,8 @@ type Resolver struct {
 	RepofuelClient *repofuel.Client
 	FeedbackDB     entity.FeedbackDataSource
 	CommitDB       entity.CommitDataSource
+	BugLinkDB      entity.BugLinkDataSource
+	ReleaseDB      entity.ReleaseDataSource
 	RepositoryDB   entity.RepositoryDataSource
 	PullRequestDB  entity.PullRequestDataSource
 	JobDB          entity.JobDataSource
@@ -16,8 +16,14 @@ import (
 	"github.com/repofuel/repofuel/ingest/graph/model"
 	"github.com/repofuel/repofuel/ingest/internal/accesscontrol"
 	"github.com/repofuel/repofuel/ingest/internal/entity"
+	"github.com/repofuel/repofuel/ingest/pkg/branchfilter"
 	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+	"github.com/repofuel/repofuel/ingest/pkg/invoke"
+	"github.com/repofuel/repofuel/ingest/pkg/jobinfo"
+	"github.com/repofuel/repofuel/ingest/pkg/jobqueue"
 	"github.com/repofuel/repofuel/ingest/pkg/manage"
+	"github.com/repofuel/repofuel/ingest/pkg/subsystem"
+	"github.com/repofuel/repofuel/ingest/pkg/szz"
 	"github.com/repofuel/repofuel/pkg/common"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -139,6 +145,18 @@ func (r *activityResolver) VisitCount(ctx context.Context, obj *model.Activity,
 	}, nil
 }
 
+func (r *bugLinkResolver) Fix(ctx context.Context, obj *entity.BugLink) (*entity.Commit, error) {
+	return r.CommitDB.FindByID(ctx, obj.FixID())
+}
+
+func (r *bugLinkResolver) Inducing(ctx context.Context, obj *entity.BugLink) (*entity.Commit, error) {
+	return r.CommitDB.FindByID(ctx, obj.InducingID())
+}
+
+func (r *bugLinkResolver) Szz(ctx context.Context, obj *entity.BugLink) (string, error) {
+	return string(obj.SZZ), nil
+}
+
 func (r *commitResolver) Hash(ctx context.Context, obj *entity.Commit) (string, error) {
 	return obj.ID.CommitHash.Hex(), nil
 }
@@ -165,6 +183,24 @@ func (r *commitResolver) Fixes(ctx context.Context, obj *entity.Commit, first *i
 	})
 }
 
+func (r *commitResolver) BugLinks(ctx context.Context, obj *entity.Commit, first *int, after *string, last *int, before *string, direction *entity.OrderDirection) (entity.BugLinkConnection, error) {
+	return r.BugLinkDB.FixBugLinkConnection(obj.ID.RepoID, obj.ID.CommitHash, direction, &entity.PaginationInput{
+		First:  first,
+		After:  after,
+		Last:   last,
+		Before: before,
+	}), nil
+}
+
+func (r *commitResolver) InducedBugLinks(ctx context.Context, obj *entity.Commit, first *int, after *string, last *int, before *string, direction *entity.OrderDirection) (entity.BugLinkConnection, error) {
+	return r.BugLinkDB.InducingBugLinkConnection(obj.ID.RepoID, obj.ID.CommitHash, direction, &entity.PaginationInput{
+		First:  first,
+		After:  after,
+		Last:   last,
+		Before: before,
+	}), nil
+}
+
 func (r *commitResolver) Repository(ctx context.Context, obj *entity.Commit) (*entity.Repository, error) {
 	return r.RepositoryDB.FindByID(ctx, obj.ID.RepoID)
 }
@@ -192,6 +228,14 @@ func (r *commitFileResolver) Fixing(ctx context.Context, obj *entity.File) ([]*e
 	return itr.Slice(ctx)
 }
 
+func (r *commitFileResolver) Functions(ctx context.Context, obj *entity.File) ([]*entity.FunctionChange, error) {
+	return obj.FunctionChanges, nil
+}
+
+func (r *dropReasonCountResolver) Reason(ctx context.Context, obj *entity.DropReasonCount) (string, error) {
+	return string(obj.Reason), nil
+}
+
 func (r *feedbackResolver) Sender(ctx context.Context, obj *entity.Feedback) (*model.User, error) {
 	//todo: Fetch more information (from the accounts service) if more fields are required from the GraphQL API
 	return &model.User{
@@ -203,16 +247,142 @@ func (r *feedbackResolver) Target(ctx context.Context, obj *entity.Feedback) (*e
 	return r.CommitDB.FindByID(ctx, &obj.CommitID)
 }
 
-func (r *mutationResolver) UpdateRepository(ctx context.Context, input model.UpdateRepositoryInput) (*model.UpdateRepositoryPayload, error) {
-	repoID, err := identifier.RepositoryIDFromNodeID(input.ID)
+func (r *functionChangeResolver) Fixing(ctx context.Context, obj *entity.FunctionChange) ([]*entity.Commit, error) {
+	if len(obj.Fixing) == 0 {
+		return nil, nil
+	}
+
+	c := parentCommit(ctx)
+	if c == nil {
+		return nil, errors.New("the function is not resolved from a commit")
+	}
+
+	itr, err := r.CommitDB.FindCommitsByHash(ctx, c.ID.RepoID, obj.Fixing...)
 	if err != nil {
 		return nil, err
 	}
+	return itr.Slice(ctx)
+}
 
-	repo, err := r.RepositoryDB.FindAndUpdateChecksConfig(ctx, repoID, (*entity.ChecksConfig)(input.ChecksConfig))
+func (r *jobQueueResolver) Name(ctx context.Context, obj *manage.QueueStats) (string, error) {
+	return obj.ID.String(), nil
+}
+
+func (r *jobQueueResolver) Priority(ctx context.Context, obj *manage.QueueStats) (int, error) {
+	return int(obj.ID.Priority()), nil
+}
+
+func (r *jobQueueResolver) OldestWait(ctx context.Context, obj *manage.QueueStats) (float64, error) {
+	return obj.OldestWait.Seconds(), nil
+}
+
+func (r *jobQueueResolver) AverageWait(ctx context.Context, obj *manage.QueueStats) (float64, error) {
+	return obj.AverageWait.Seconds(), nil
+}
+
+func (r *mutationResolver) UpdateRepository(ctx context.Context, input model.UpdateRepositoryInput) (*model.UpdateRepositoryPayload, error) {
+	old, err := r.administeredRepository(ctx, input.ID)
 	if err != nil {
 		return nil, err
 	}
+	repoID := old.ID
+
+	var repo *entity.Repository
+	if input.ChecksConfig != nil || (input.DeveloperAliases == nil && input.SubsystemConfig == nil && input.PathConfig == nil && input.BranchConfig == nil && input.SzzConfig == nil && input.MetricsConfig == nil) {
+		repo, err = r.RepositoryDB.FindAndUpdateChecksConfig(ctx, repoID, (*entity.ChecksConfig)(input.ChecksConfig))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if input.DeveloperAliases != nil {
+		repo, err = r.RepositoryDB.FindAndUpdateDeveloperAliases(ctx, repoID, developerAliasesFromInput(input.DeveloperAliases))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if input.PathConfig != nil {
+		if err := input.PathConfig.Validate(); err != nil {
+			return nil, err
+		}
+
+		repo, err = r.RepositoryDB.FindAndUpdatePathConfig(ctx, repoID, input.PathConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if input.BranchConfig != nil {
+		if err := input.BranchConfig.Validate(); err != nil {
+			return nil, err
+		}
+
+		repo, err = r.RepositoryDB.FindAndUpdateBranchConfig(ctx, repoID, input.BranchConfig)
+		if err != nil {
+			return nil, err
+		}
+
+		// the branches are ingested again to prune the excluded ones, the
+		// branches of the config file take precedence over the stored ones
+		if !manage.IsSameStored(old.EffectiveBranchConfig(), repo.EffectiveBranchConfig()) {
+			err = r.Manager.ProcessRepository(&jobinfo.JobInfo{
+				Action: invoke.ActionRepositoryRefreshing,
+				RepoID: repo.ID,
+				Cache: jobinfo.Store{
+					jobinfo.RepoEntity: repo,
+				},
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if input.SzzConfig != nil {
+		if err := input.SzzConfig.Validate(); err != nil {
+			return nil, err
+		}
+
+		repo, err = r.RepositoryDB.FindAndUpdateSZZConfig(ctx, repoID, input.SzzConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if input.MetricsConfig != nil {
+		repo, err = r.RepositoryDB.FindAndUpdateMetricsConfig(ctx, repoID, input.MetricsConfig)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if input.SubsystemConfig != nil {
+		cfg := subsystemConfigFromInput(input.SubsystemConfig)
+		if err := cfg.Validate(); err != nil {
+			return nil, err
+		}
+
+		repo, err = r.RepositoryDB.FindAndUpdateSubsystemConfig(ctx, repoID, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		// the subsystems of the config file take precedence over the stored ones,
+		// so the commits are analyzed again only if the effective ones change
+		if !manage.IsSameStored(old.EffectiveSubsystemConfig(), repo.EffectiveSubsystemConfig()) {
+			err = r.Manager.ProcessRepository(&jobinfo.JobInfo{
+				Action: invoke.ActionSubsystemsUpdate,
+				RepoID: repo.ID,
+				Cache: jobinfo.Store{
+					jobinfo.RepoEntity: repo,
+				},
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
 
 	return &model.UpdateRepositoryPayload{
 		Repository: repo,
@@ -348,6 +518,45 @@ func (r *mutationResolver) DeleteRepository(ctx context.Context, id string) (*mo
 	}, nil
 }
 
+func (r *mutationResolver) PauseRepository(ctx context.Context, id string) (*model.PauseRepositoryPayload, error) {
+	repo, err := r.administeredRepository(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.Manager.PauseRepository(ctx, repo.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err = r.RepositoryDB.FindByID(ctx, repo.ID)
+
+	return &model.PauseRepositoryPayload{
+		Repository: repo,
+	}, err
+}
+
+func (r *mutationResolver) ResumeRepository(ctx context.Context, id string) (*model.ResumeRepositoryPayload, error) {
+	repo, err := r.administeredRepository(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.Manager.ResumeRepository(ctx, repo.ID)
+	if err == jobqueue.ErrNotPaused {
+		return nil, errors.New("the repository is not paused")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err = r.RepositoryDB.FindByID(ctx, repo.ID)
+
+	return &model.ResumeRepositoryPayload{
+		Repository: repo,
+	}, err
+}
+
 func (r *mutationResolver) DeleteCommitTag(ctx context.Context, input model.DeleteCommitTagInput) (*model.DeleteCommitTagPayload, error) {
 	//todo: check the authorization
 	return nil, errors.New("'deleteCommitTag' mutation is disabled")
@@ -551,6 +760,23 @@ func (r *queryResolver) Feedback(ctx context.Context, first *int, after *string,
 	}), nil
 }
 
+func (r *queryResolver) JobQueues(ctx context.Context) ([]*manage.QueueStats, error) {
+	viewer := permission.ViewerCtx(ctx)
+	if viewer == nil || viewer.Role != permission.RoleSiteAdmin {
+		return nil, errors.New("unauthorized")
+	}
+
+	return r.Manager.QueueStats(ctx)
+}
+
+func (r *releaseResolver) Commit(ctx context.Context, obj *entity.Release) (*entity.Commit, error) {
+	return r.CommitDB.FindByID(ctx, obj.CommitID())
+}
+
+func (r *releaseResolver) Statistics(ctx context.Context, obj *entity.Release) (*entity.ReleaseStatistics, error) {
+	return r.CommitDB.ReleaseStatistics(ctx, obj.Repo, obj.Tag)
+}
+
 func (r *repositoryResolver) DatabaseID(ctx context.Context, obj *entity.Repository) (string, error) {
 	return obj.ID.Hex(), nil
 }
@@ -563,6 +789,15 @@ func (r *repositoryResolver) Commit(ctx context.Context, obj *entity.Repository,
 	return r.CommitDB.FindByID(ctx, identifier.NewCommitID(obj.ID, identifier.NewHash(hash)))
 }
 
+func (r *repositoryResolver) FileBugLinks(ctx context.Context, obj *entity.Repository, path string, first *int, after *string, last *int, before *string, direction *entity.OrderDirection) (entity.BugLinkConnection, error) {
+	return r.BugLinkDB.PathBugLinkConnection(obj.ID, path, direction, &entity.PaginationInput{
+		First:  first,
+		After:  after,
+		Last:   last,
+		Before: before,
+	}), nil
+}
+
 func (r *repositoryResolver) PullRequest(ctx context.Context, obj *entity.Repository, number int) (*entity.PullRequest, error) {
 	return r.PullRequestDB.FindByNumber(ctx, obj.ID, number)
 }
@@ -633,7 +868,13 @@ func (r *repositoryResolver) Jobs(ctx context.Context, obj *entity.Repository, f
 }
 
 func (r *repositoryResolver) Branches(ctx context.Context, obj *entity.Repository) ([]*entity.Branch, error) {
-	return hashToBranches(obj.Branches), nil
+	// the branches are filtered until the next job prunes the excluded ones
+	filter, err := branchfilter.New(obj.EffectiveBranchConfig(), obj.Source.DefaultBranch)
+	if err != nil {
+		return nil, err
+	}
+
+	return hashToBranches(obj.Branches, filter), nil
 }
 
 func (r *repositoryResolver) DeveloperEmails(ctx context.Context, obj *entity.Repository) ([]string, error) {
@@ -644,6 +885,19 @@ func (r *repositoryResolver) DeveloperNames(ctx context.Context, obj *entity.Rep
 	return r.CommitDB.DeveloperNames(ctx, obj.ID)
 }
 
+func (r *repositoryResolver) Releases(ctx context.Context, obj *entity.Repository, first *int, after *string, last *int, before *string, direction *entity.OrderDirection) (entity.ReleaseConnection, error) {
+	return r.ReleaseDB.RepositoryReleaseConnection(obj.ID, direction, &entity.PaginationInput{
+		First:  first,
+		After:  after,
+		Last:   last,
+		Before: before,
+	}), nil
+}
+
+func (r *repositoryResolver) Release(ctx context.Context, obj *entity.Repository, tag string) (*entity.Release, error) {
+	return r.ReleaseDB.FindByTag(ctx, obj.ID, tag)
+}
+
 func (r *repositoryResolver) PredictionStatus(ctx context.Context, obj *entity.Repository) (*int, error) {
 	v := int(obj.Quality)
 	return &v, nil
@@ -707,6 +961,17 @@ func (r *repositoryResolver) TagsCount(ctx context.Context, obj *entity.Reposito
 	}, nil
 }
 
+func (r *repositoryResolver) DroppedBugsCount(ctx context.Context, obj *entity.Repository) (*model.DropReasonCountConnection, error) {
+	nodes, err := r.CommitDB.DroppedBugsCount(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.DropReasonCountConnection{
+		Nodes: nodes,
+	}, nil
+}
+
 func (r *repositoryResolver) AvgEntropyOverTime(ctx context.Context, obj *entity.Repository) (*model.AvgOverTimeConnection, error) {
 	nodes, err := r.CommitDB.AvgEntropyOverTime(ctx, obj.ID)
 	if err != nil {
@@ -738,6 +1003,10 @@ func (r *repositorySourceResolver) URL(ctx context.Context, obj *common.Reposito
 	return obj.HTMLURL, nil
 }
 
+func (r *sZZConfigResolver) Variant(ctx context.Context, obj *szz.Config) (string, error) {
+	return string(obj.Variant), nil
+}
+
 func (r *subscriptionResolver) ChangeProgress(ctx context.Context, ids []string) (<-chan *manage.ProgressObservable, error) {
 	obs := make(chan *manage.ProgressObservable)
 
@@ -755,6 +1024,13 @@ func (r *subscriptionResolver) ChangeProgress(ctx context.Context, ids []string)
 	return obs, nil
 }
 
+func (r *subsystemConfigResolver) Source(ctx context.Context, obj *subsystem.Config) (string, error) {
+	if obj.Source == "" {
+		return string(subsystem.Directory), nil
+	}
+	return string(obj.Source), nil
+}
+
 func (r *userResolver) Repositories(ctx context.Context, obj *model.User, first *int, after *string, last *int, before *string, direction *entity.OrderDirection, ownerAffiliations []entity.RepositoryAffiliation) (entity.RepositoryConnection, error) {
 	page := &entity.PaginationInput{
 		First:  first,
@@ -772,18 +1048,41 @@ func (r *userResolver) Repositories(ctx context.Context, obj *model.User, first
 	return r.RepositoryDB.FindUserReposConnection(ctx, affiliations, direction, page)
 }
 
+func (r *sZZConfigInputResolver) Variant(ctx context.Context, obj *szz.Config, data *string) error {
+	if data != nil {
+		obj.Variant = szz.Variant(*data)
+	}
+	return nil
+}
+
 // Activity returns generated.ActivityResolver implementation.
 func (r *Resolver) Activity() generated.ActivityResolver { return &activityResolver{r} }
 
+// BugLink returns generated.BugLinkResolver implementation.
+func (r *Resolver) BugLink() generated.BugLinkResolver { return &bugLinkResolver{r} }
+
 // Commit returns generated.CommitResolver implementation.
 func (r *Resolver) Commit() generated.CommitResolver { return &commitResolver{r} }
 
 // CommitFile returns generated.CommitFileResolver implementation.
 func (r *Resolver) CommitFile() generated.CommitFileResolver { return &commitFileResolver{r} }
 
+// DropReasonCount returns generated.DropReasonCountResolver implementation.
+func (r *Resolver) DropReasonCount() generated.DropReasonCountResolver {
+	return &dropReasonCountResolver{r}
+}
+
 // Feedback returns generated.FeedbackResolver implementation.
 func (r *Resolver) Feedback() generated.FeedbackResolver { return &feedbackResolver{r} }
 
+// FunctionChange returns generated.FunctionChangeResolver implementation.
+func (r *Resolver) FunctionChange() generated.FunctionChangeResolver {
+	return &functionChangeResolver{r}
+}
+
+// JobQueue returns generated.JobQueueResolver implementation.
+func (r *Resolver) JobQueue() generated.JobQueueResolver { return &jobQueueResolver{r} }
+
 // Mutation returns generated.MutationResolver implementation.
 func (r *Resolver) Mutation() generated.MutationResolver { return &mutationResolver{r} }
 
@@ -801,6 +1100,9 @@ func (r *Resolver) PullRequestSource() generated.PullRequestSourceResolver {
 // Query returns generated.QueryResolver implementation.
 func (r *Resolver) Query() generated.QueryResolver { return &queryResolver{r} }
 
+// Release returns generated.ReleaseResolver implementation.
+func (r *Resolver) Release() generated.ReleaseResolver { return &releaseResolver{r} }
+
 // Repository returns generated.RepositoryResolver implementation.
 func (r *Resolver) Repository() generated.RepositoryResolver { return &repositoryResolver{r} }
 
@@ -809,22 +1111,43 @@ func (r *Resolver) RepositorySource() generated.RepositorySourceResolver {
 	return &repositorySourceResolver{r}
 }
 
+// SZZConfig returns generated.SZZConfigResolver implementation.
+func (r *Resolver) SZZConfig() generated.SZZConfigResolver { return &sZZConfigResolver{r} }
+
 // Subscription returns generated.SubscriptionResolver implementation.
 func (r *Resolver) Subscription() generated.SubscriptionResolver { return &subscriptionResolver{r} }
 
+// SubsystemConfig returns generated.SubsystemConfigResolver implementation.
+func (r *Resolver) SubsystemConfig() generated.SubsystemConfigResolver {
+	return &subsystemConfigResolver{r}
+}
+
 // User returns generated.UserResolver implementation.
 func (r *Resolver) User() generated.UserResolver { return &userResolver{r} }
 
+// SZZConfigInput returns generated.SZZConfigInputResolver implementation.
+func (r *Resolver) SZZConfigInput() generated.SZZConfigInputResolver {
+	return &sZZConfigInputResolver{r}
+}
+
 type activityResolver struct{ *Resolver }
+type bugLinkResolver struct{ *Resolver }
 type commitResolver struct{ *Resolver }
 type commitFileResolver struct{ *Resolver }
+type dropReasonCountResolver struct{ *Resolver }
 type feedbackResolver struct{ *Resolver }
+type functionChangeResolver struct{ *Resolver }
+type jobQueueResolver struct{ *Resolver }
 type mutationResolver struct{ *Resolver }
 type organizationResolver struct{ *Resolver }
 type pullRequestResolver struct{ *Resolver }
 type pullRequestSourceResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
+type releaseResolver struct{ *Resolver }
 type repositoryResolver struct{ *Resolver }
 type repositorySourceResolver struct{ *Resolver }
+type sZZConfigResolver struct{ *Resolver }
 type subscriptionResolver struct{ *Resolver }
+type subsystemConfigResolver struct{ *Resolver }
 type userResolver struct{ *Resolver }
+type sZZConfigInputResolver struct{ *Resolver }
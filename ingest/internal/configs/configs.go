@@ -9,6 +9,7 @@ import (
 
 	"github.com/joho/godotenv"
 	"github.com/repofuel/repofuel/accounts/pkg/keys"
+	"github.com/repofuel/repofuel/ingest/pkg/manage"
 	"github.com/repofuel/repofuel/pkg/mongocon"
 	"github.com/repofuel/repofuel/pkg/repofuel"
 	"github.com/repofuel/repofuel/pkg/utilconfig"
@@ -19,6 +20,7 @@ type Configs struct {
 	Keys     keys.ServiceKeys
 	Repofuel repofuel.Options
 	DB       mongocon.DatabaseOptions
+	Manager  manage.Options
 	//deprecated
 	Providers struct {
 		//deprecated
@@ -0,0 +1,49 @@
+package entity
+
+import (
+	"context"
+	"time"
+
+	"github.com/repofuel/repofuel/ingest/pkg/engine"
+	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+	"github.com/repofuel/repofuel/ingest/pkg/szz"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type BugLinkDataSource interface {
+	InsertMany(context.Context, []*BugLink) error
+	FixBugLinkConnection(identifier.RepositoryID, identifier.Hash, *OrderDirection, *PaginationInput) BugLinkConnection
+	InducingBugLinkConnection(identifier.RepositoryID, identifier.Hash, *OrderDirection, *PaginationInput) BugLinkConnection
+	PathBugLinkConnection(identifier.RepositoryID, string, *OrderDirection, *PaginationInput) BugLinkConnection
+	DeleteFixLinks(context.Context, identifier.RepositoryID, identifier.Hash) error
+	DeleteRepoLinks(context.Context, identifier.RepositoryID) error
+}
+
+// BugLink links a chunk of lines deleted by a fix commit to the commit that
+// introduced them.
+type BugLink struct {
+	ID       primitive.ObjectID      `bson:"_id,omitempty"`
+	Repo     identifier.RepositoryID `bson:"repo"`
+	Fix      identifier.Hash         `bson:"fix"`
+	Inducing identifier.Hash         `bson:"inducing"`
+	// Path is the path of the file in the parent of the fix commit, where the
+	// deleted lines are numbered.
+	Path    string           `bson:"path"`
+	Deleted engine.ChunkAddr `bson:"deleted"`
+	// Functions are the keys of the functions that enclose the deleted lines,
+	// the lines out of the functions are not linked to any function.
+	Functions []string    `bson:"functions,omitempty"`
+	SZZ       szz.Variant `bson:"szz"`
+	// Confidence is one divided by the number of commits that are blamed for
+	// the same chunk, it is 1 when the inducing commit is the only origin.
+	Confidence float64   `bson:"confidence"`
+	CreatedAt  time.Time `bson:"created_at"`
+}
+
+func (l *BugLink) FixID() *identifier.CommitID {
+	return identifier.NewCommitID(l.Repo, l.Fix)
+}
+
+func (l *BugLink) InducingID() *identifier.CommitID {
+	return identifier.NewCommitID(l.Repo, l.Inducing)
+}
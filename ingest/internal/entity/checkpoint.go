@@ -0,0 +1,18 @@
+package entity
+
+import (
+	"context"
+
+	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+)
+
+// CheckpointDataSource keeps the commits that are analyzed by the interrupted
+// analyses, so they are not analyzed again when the analyses are resumed.
+type CheckpointDataSource interface {
+	// AnalyzedCommits returns the checkpointed commits of the repository.
+	AnalyzedCommits(context.Context, identifier.RepositoryID) (identifier.HashSet, error)
+	// SaveAnalyzedCommits adds the commits to the checkpoint of the repository.
+	SaveAnalyzedCommits(context.Context, identifier.RepositoryID, identifier.JobID, []identifier.Hash) error
+	// DeleteRepoCheckpoint is called when the analysis is completed.
+	DeleteRepoCheckpoint(context.Context, identifier.RepositoryID) error
+}
@@ -14,9 +14,12 @@ import (
 	"time"
 
 	"github.com/repofuel/repofuel/ingest/pkg/classify"
+	"github.com/repofuel/repofuel/ingest/pkg/commitmsg"
 	"github.com/repofuel/repofuel/ingest/pkg/engine"
 	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+	"github.com/repofuel/repofuel/ingest/pkg/identity"
 	"github.com/repofuel/repofuel/ingest/pkg/insights"
+	"github.com/repofuel/repofuel/ingest/pkg/szz"
 	"github.com/repofuel/repofuel/pkg/common"
 	"github.com/repofuel/repofuel/pkg/metrics"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -30,7 +33,9 @@ type ChangeMeasures = metrics.ChangeMeasures
 
 type CommitDataSource interface {
 	//fixme: should update, should not delete the analysis
-	InsertOrReplace(context.Context, *Commit) error
+	// InsertOrReplace stores the commit, and reports whether it replaced an
+	// already stored one.
+	InsertOrReplace(context.Context, *Commit) (bool, error)
 	MarkBuggy(context.Context, identifier.RepositoryID, identifier.Hash, identifier.HashSet) error
 	FindRepoCommits(context.Context, identifier.RepositoryID, ...*options.FindOptions) (CommitIter, error)
 	FindPullRequestCommits(context.Context, identifier.RepositoryID, identifier.PullRequestID, ...*options.FindOptions) (CommitIter, error)
@@ -42,6 +47,10 @@ type CommitDataSource interface {
 	DeleteRepoCommits(context.Context, identifier.RepositoryID) error
 	DeleteCommitTag(ctx context.Context, commitID *identifier.CommitID, tag classify.Tag) error
 	SaveCommitAnalysis(ctx context.Context, analyses ...*CommitAnalysisHolder) error
+	SaveSubsystems(ctx context.Context, holders ...*CommitSubsystemsHolder) error
+	// SaveReleases assigns the commits to their first releases by the tags, the
+	// commits that are missing from the map are unassigned.
+	SaveReleases(ctx context.Context, repoID identifier.RepositoryID, releases map[identifier.Hash]string) error
 	RemoveBranch(ctx context.Context, repoID identifier.RepositoryID, branch string) error
 	ReTagBranch(ctx context.Context, repoID identifier.RepositoryID, branch string, commitIDs identifier.HashSet) error
 	ReTagPullRequest(ctx context.Context, repoID identifier.RepositoryID, pull identifier.PullRequestID, commitIDs identifier.HashSet) error
@@ -63,6 +72,7 @@ type CommitStatisticsDataSource interface {
 	BugInducingCount(ctx context.Context, repoID identifier.RepositoryID) (int64, error)
 	ContributorsCount(ctx context.Context, repoID identifier.RepositoryID) (int, error)
 	BugFixingCount(ctx context.Context, repoID identifier.RepositoryID) (int, error)
+	DroppedBugsCount(ctx context.Context, repoID identifier.RepositoryID) ([]*DropReasonCount, error)
 
 	BuggyCommitsOverTime(ctx context.Context, repoID identifier.RepositoryID) ([]*CountOverTime, error)
 	CommitsOverTime(ctx context.Context, repoID identifier.RepositoryID) ([]*CountOverTime, error)
@@ -77,6 +87,7 @@ type CommitStatisticsDataSource interface {
 	PredictedCountOverTime(ctx context.Context, since time.Time, frequency Frequency) ([]*CountOverTime, error)
 
 	FileAggregatedMetrics(ctx context.Context, id identifier.RepositoryID) (FileMeasuresIter, error)
+	ReleaseStatistics(ctx context.Context, repoID identifier.RepositoryID, tag string) (*ReleaseStatistics, error)
 }
 
 type CommitFilters struct {
@@ -84,6 +95,8 @@ type CommitFilters struct {
 	DeveloperName *string  `json:"developerName"`
 	MinRisk       *float32 `json:"minRisk"`
 	MaxRisk       *float32 `json:"maxRisk"`
+	Release       *string  `json:"release"`
+	Buggy         *bool    `json:"buggy"`
 }
 
 type DeveloperExp struct {
@@ -128,11 +141,18 @@ func (err *CommitError) Error() string {
 type Commit struct {
 	ID           *identifier.CommitID       `json:"id"                       bson:"_id,omitempty"`
 	Author       *engine.Signature          `json:"author"                   bson:"author"`
+	Committer    *engine.Signature          `json:"committer,omitempty"      bson:"committer,omitempty"`
+	CoAuthors    []commitmsg.CoAuthor       `json:"co_authors,omitempty"     bson:"co_authors,omitempty"`
+	Identity     *identity.Identity         `json:"identity,omitempty"       bson:"identity,omitempty"`
 	Message      string                     `json:"message"                  bson:"message"`
 	Files        []*File                    `json:"files"                    bson:"files,omitempty"`
 	Fixes        []string                   `json:"fixes,omitempty"          bson:"fixes,omitempty"`
 	Fix          bool                       `json:"fix"                      bson:"fix"`
+	SZZ          szz.Variant                `json:"szz,omitempty"            bson:"szz,omitempty"`
+	DroppedBugs  []*szz.DroppedCandidate    `json:"dropped_bugs,omitempty"   bson:"dropped_bugs,omitempty"`
 	Metrics      *metrics.ChangeMeasures    `json:"metrics,omitempty"        bson:"metrics,omitempty"`
+	RawLines     *metrics.LineMeasures      `json:"raw_lines,omitempty"      bson:"raw_lines,omitempty"`
+	CodeLines    *metrics.LineMeasures      `json:"code_lines,omitempty"     bson:"code_lines,omitempty"`
 	Job          identifier.JobID           `json:"job"                      bson:"job"`
 	Analysis     *CommitAnalysis            `json:"analysis"                 bson:"analysis,omitempty"`
 	Tags         []classify.Tag             `json:"tags,omitempty"           bson:"tags,omitempty"`
@@ -141,6 +161,7 @@ type Commit struct {
 	Issues       []common.Issue             `json:"issues,omitempty"         bson:"issues,omitempty"`
 	Branches     []string                   `json:"branches,omitempty"       bson:"branches,omitempty"`
 	PullRequests []identifier.PullRequestID `json:"pulls,omitempty"          bson:"pulls,omitempty"`
+	Release      string                     `json:"release,omitempty"        bson:"release,omitempty"`
 	CreatedAt    time.Time                  `json:"created_at"               bson:"created_at,omitempty"`
 }
 
@@ -150,6 +171,21 @@ type CommitAnalysisHolder struct {
 	FileInsights [][]insights.Reason
 }
 
+// CommitSubsystemsHolder holds the recomputed subsystems of the code files of a
+// commit by the index of the file, and the recomputed subsystem metrics.
+type CommitSubsystemsHolder struct {
+	ID         *identifier.CommitID
+	Subsystems map[int]string
+	// Metrics is nil for the commits without metrics, such as the merges.
+	Metrics *SubsystemMeasures
+}
+
+// SubsystemMeasures are the commit metrics that depend on the subsystems.
+type SubsystemMeasures struct {
+	NS   float64
+	SEXP float64
+}
+
 type CommitAnalysis struct {
 	BugPotential float32           `bson:"bug_potential"`
 	Indicators   BugIndicators     `bson:"indicators,omitempty"`
@@ -187,6 +223,15 @@ func (c *Commit) HasBranch(branch string) bool {
 	return false
 }
 
+func (c *Commit) HasTag(tag classify.Tag) bool {
+	for i := range c.Tags {
+		if c.Tags[i] == tag {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Commit) Hash() identifier.Hash {
 	return c.ID.CommitHash
 }
@@ -248,6 +293,12 @@ func (e OrderDirection) MarshalGQL(w io.Writer) {
 	fmt.Fprint(w, strconv.Quote(e.String()))
 }
 
+// DropReasonCount is the number of the bug-inducing candidates that are dropped for a reason.
+type DropReasonCount struct {
+	Reason szz.DropReason `bson:"_id"`
+	Count  int            `bson:"count"`
+}
+
 type TagCount struct {
 	Tag   classify.Tag `bson:"_id"`
 	Count int          `bson:"count"`
@@ -262,12 +313,30 @@ type EngineFileIter interface {
 type File struct {
 	*engine.FileInfo `bson:",inline"`
 
-	Type          classify.FileType     `json:"type"              bson:"type"`
-	Language      string                `json:"language"          bson:"language,omitempty"`
-	Fixing        []identifier.Hash     `json:"fixing"            bson:"fixing,omitempty"`
-	Metrics       *metrics.FileMeasures `json:"metrics,omitempty" bson:"metrics,omitempty"`
-	SameDeveloper bool                  `json:"same_developer"    bson:"same_developer"`
-	Insights      []insights.Reason     `json:"insights"          bson:"insights"`
+	Type          classify.FileType           `json:"type"                 bson:"type"`
+	Language      string                      `json:"language"             bson:"language,omitempty"`
+	Fixing        []identifier.Hash           `json:"fixing"               bson:"fixing,omitempty"`
+	Metrics       *metrics.FileMeasures       `json:"metrics,omitempty"    bson:"metrics,omitempty"`
+	RawLines      *metrics.LineMeasures       `json:"raw_lines,omitempty"  bson:"raw_lines,omitempty"`
+	CodeLines     *metrics.LineMeasures       `json:"code_lines,omitempty" bson:"code_lines,omitempty"`
+	Complexity    *metrics.ComplexityMeasures `json:"complexity,omitempty" bson:"complexity,omitempty"`
+	SameDeveloper bool                        `json:"same_developer"       bson:"same_developer"`
+	Insights      []insights.Reason           `json:"insights"             bson:"insights"`
+	// FunctionChanges are stored apart from the names of the changed functions
+	// in the file info, which are loaded with the history of the repository.
+	FunctionChanges []*FunctionChange `json:"functions,omitempty" bson:"function_changes,omitempty"`
+}
+
+// FunctionChange is a function that is changed in a file, it is located in the
+// new content of the file unless the function is deleted.
+type FunctionChange struct {
+	Name      string                    `json:"name"                bson:"name"`
+	Signature string                    `json:"signature,omitempty" bson:"signature,omitempty"`
+	Start     int                       `json:"start"               bson:"start"`
+	End       int                       `json:"end"                 bson:"end"`
+	Deleted   bool                      `json:"deleted"             bson:"deleted,omitempty"`
+	Fixing    []identifier.Hash         `json:"fixing"              bson:"fixing,omitempty"`
+	Metrics   *metrics.FunctionMeasures `json:"metrics,omitempty"   bson:"metrics,omitempty"`
 }
 
 type CommitFiles struct {
@@ -73,6 +73,48 @@ func nodeToJobCursor(n *Job) *string {
 	return &s
 }
 
+func nodeToBugLinkCursor(n *BugLink) *string {
+	s := base64.StdEncoding.EncodeToString(n.ID[:])
+	return &s
+}
+
+const releaseCursorLen = 8 + len(ReleaseCursor{}.ID)
+
+// ReleaseCursor locates a release in the releases of a repository that are
+// ordered by their dates.
+type ReleaseCursor struct {
+	RepoID identifier.RepositoryID `bson:"repo"`
+	Date   time.Time               `bson:"date"`
+	ID     primitive.ObjectID      `bson:"_id"`
+}
+
+func nodeToReleaseCursor(n *Release) *string {
+	buf := make([]byte, releaseCursorLen)
+	// the dates are stored in milliseconds
+	binary.LittleEndian.PutUint64(buf, uint64(n.Date.UnixNano()/int64(time.Millisecond)))
+	copy(buf[8:], n.ID[:])
+
+	s := base64.StdEncoding.EncodeToString(buf)
+	return &s
+}
+
+func (c *ReleaseCursor) UnmarshalBase64(data []byte) error {
+	buf, err := base64.StdEncoding.DecodeString(string(data))
+	if err != nil {
+		return err
+	}
+
+	if len(buf) != releaseCursorLen {
+		return errors.New("invalid length")
+	}
+
+	ms := int64(binary.LittleEndian.Uint64(buf))
+	c.Date = time.Unix(0, ms*int64(time.Millisecond))
+	copy(c.ID[:], buf[8:])
+
+	return nil
+}
+
 func objectIDToBase64(dst []byte, src *primitive.ObjectID) {
 	base64.StdEncoding.Encode(dst, src[:])
 }
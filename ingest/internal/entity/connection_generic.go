@@ -6,8 +6,8 @@ import (
 	"github.com/cheekybits/genny/generic"
 )
 
-//go:generate genny -in=connection_generic.go -out=connection_gnerated.go                   gen "Item=Commit,PullRequest,Repository,Job,Feedback,Organization"
-//go:generate genny -in=mongosrc/connection_generic.go -out=mongosrc/connection_gnerated.go gen "Item=Commit,PullRequest,Repository,Job,Feedback,Organization"
+//go:generate genny -in=connection_generic.go -out=connection_gnerated.go                   gen "Item=Commit,PullRequest,Repository,Job,Feedback,Organization,BugLink,Release"
+//go:generate genny -in=mongosrc/connection_generic.go -out=mongosrc/connection_gnerated.go gen "Item=Commit,PullRequest,Repository,Job,Feedback,Organization,BugLink,Release"
 
 type Item generic.Type
 
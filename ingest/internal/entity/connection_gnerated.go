@@ -251,3 +251,85 @@ func PageInfoFromOrganizationEdges(edges []*OrganizationEdge, hasNext bool, opts
 
 	return &page
 }
+
+type BugLinkConnection interface {
+	TotalCount(context.Context) (int64, error)
+	Edges(context.Context) ([]*BugLinkEdge, error)
+	PageInfo(context.Context) (*PageInfo, error)
+	Nodes(context.Context) ([]*BugLink, error)
+}
+
+type BugLinkEdge struct {
+	Node BugLink
+}
+
+func (e *BugLinkEdge) Cursor() *string {
+	return nodeToBugLinkCursor(&e.Node)
+}
+
+func PageInfoFromBugLinkEdges(edges []*BugLinkEdge, hasNext bool, opts *PaginationInput) *PageInfo {
+	if len(edges) == 0 {
+		return &PageInfo{
+			HasNextPage:     opts.Last != nil && opts.Before != nil,
+			HasPreviousPage: opts.First != nil && opts.After != nil,
+		}
+	}
+
+	var page PageInfo
+
+	if opts.Last != nil {
+		page.HasPreviousPage = len(edges) == *opts.Last && hasNext
+		page.HasNextPage = opts.Before != nil
+	} else {
+		page.HasPreviousPage = opts.After != nil
+		page.HasNextPage = len(edges) == *opts.First && hasNext
+	}
+
+	if len(edges) > 0 {
+		page.StartEdge = edges[0]
+		page.EndEdge = edges[len(edges)-1]
+	}
+
+	return &page
+}
+
+type ReleaseConnection interface {
+	TotalCount(context.Context) (int64, error)
+	Edges(context.Context) ([]*ReleaseEdge, error)
+	PageInfo(context.Context) (*PageInfo, error)
+	Nodes(context.Context) ([]*Release, error)
+}
+
+type ReleaseEdge struct {
+	Node Release
+}
+
+func (e *ReleaseEdge) Cursor() *string {
+	return nodeToReleaseCursor(&e.Node)
+}
+
+func PageInfoFromReleaseEdges(edges []*ReleaseEdge, hasNext bool, opts *PaginationInput) *PageInfo {
+	if len(edges) == 0 {
+		return &PageInfo{
+			HasNextPage:     opts.Last != nil && opts.Before != nil,
+			HasPreviousPage: opts.First != nil && opts.After != nil,
+		}
+	}
+
+	var page PageInfo
+
+	if opts.Last != nil {
+		page.HasPreviousPage = len(edges) == *opts.Last && hasNext
+		page.HasNextPage = opts.Before != nil
+	} else {
+		page.HasPreviousPage = opts.After != nil
+		page.HasNextPage = len(edges) == *opts.First && hasNext
+	}
+
+	if len(edges) > 0 {
+		page.StartEdge = edges[0]
+		page.EndEdge = edges[len(edges)-1]
+	}
+
+	return &page
+}
@@ -6,8 +6,8 @@ import (
 	"github.com/cheekybits/genny/generic"
 )
 
-//go:generate genny -in=./mongosrc/iter_generic.go -out=./mongosrc/iter_gnerated.go gen "item=entity.Commit,entity.Repository,entity.Job,entity.Organization,entity.PullRequest,entity.DeveloperExp,entity.File,metrics.ChangeMeasures,metrics.FileMeasures,entity.Feedback"
-//go:generate genny -in=iter_generic.go -out=iter_gnerated.go gen "item=Commit,Repository,Job,Organization,PullRequest,DeveloperExp,File,ChangeMeasures,FileMeasures,Feedback"
+//go:generate genny -in=./mongosrc/iter_generic.go -out=./mongosrc/iter_gnerated.go gen "item=entity.Commit,entity.Repository,entity.Job,entity.Organization,entity.PullRequest,entity.DeveloperExp,entity.File,metrics.ChangeMeasures,metrics.FileMeasures,entity.Feedback,entity.BugLink,entity.Release"
+//go:generate genny -in=iter_generic.go -out=iter_gnerated.go gen "item=Commit,Repository,Job,Organization,PullRequest,DeveloperExp,File,ChangeMeasures,FileMeasures,Feedback,BugLink,Release"
 
 type item generic.Type
 
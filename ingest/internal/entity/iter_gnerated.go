@@ -55,3 +55,13 @@ type FeedbackIter interface {
 	ForEach(context.Context, func(*Feedback) error) error
 	Slice(context.Context) ([]*Feedback, error)
 }
+
+type BugLinkIter interface {
+	ForEach(context.Context, func(*BugLink) error) error
+	Slice(context.Context) ([]*BugLink, error)
+}
+
+type ReleaseIter interface {
+	ForEach(context.Context, func(*Release) error) error
+	Slice(context.Context) ([]*Release, error)
+}
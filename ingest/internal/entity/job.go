@@ -17,6 +17,9 @@ type JobDataSource interface {
 	SaveStatus(context.Context, identifier.JobID, status.Stage) error
 	CreateJob(context.Context, identifier.RepositoryID, invoke.Action, map[string]interface{}) (identifier.JobID, error)
 	ReportError(context.Context, identifier.JobID, error) error
+	ReportWarnings(context.Context, identifier.JobID, ...string) error
+	// ReportAttempt logs a failed attempt of a task that will be retried.
+	ReportAttempt(ctx context.Context, id identifier.JobID, task string, attempt int, report error) error
 
 	RepositoryJobConnection(identifier.RepositoryID, *OrderDirection, *PaginationInput) JobConnection
 
@@ -35,6 +38,9 @@ type Job struct {
 	Repository identifier.RepositoryID `json:"repo_id"            bson:"repo_id"`
 	StatusLog  []Update                `json:"log"                bson:"log,omitempty"`
 	Error      string                  `json:"error"              bson:"error,omitempty"`
+	// Warnings are the problems that did not fail the job, such as an invalid
+	// config file.
+	Warnings []string `json:"warnings,omitempty"  bson:"warnings,omitempty"`
 }
 
 //deprecated
@@ -79,6 +85,11 @@ func (j *Job) CreatedAt() time.Time {
 type Update struct {
 	Status    status.Stage `json:"status"       bson:"status"`
 	StartedAt time.Time    `json:"started_at"   bson:"started_at"`
+	// Task, Attempt, and Error describe the failed attempt of a task that is
+	// retried, they are set for the Retrying updates only.
+	Task    string `json:"task,omitempty"      bson:"task,omitempty"`
+	Attempt int    `json:"attempt,omitempty"   bson:"attempt,omitempty"`
+	Error   string `json:"error,omitempty"     bson:"error,omitempty"`
 }
 
 func (j *Update) StatusText() string {
@@ -0,0 +1,99 @@
+package mongosrc
+
+import (
+	"context"
+	"time"
+
+	"github.com/repofuel/repofuel/ingest/internal/entity"
+	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const bugLinksCollection = "bug_links"
+
+type bugLinkDataSource struct {
+	collection *mongo.Collection
+}
+
+func NewBugLinkDataSource(ctx context.Context, db *mongo.Database) *bugLinkDataSource {
+	c := db.Collection(bugLinksCollection)
+
+	_, err := c.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "repo", Value: 1}, {Key: "fix", Value: 1}}},
+		{Keys: bson.D{{Key: "repo", Value: 1}, {Key: "inducing", Value: 1}}},
+		{Keys: bson.D{{Key: "repo", Value: 1}, {Key: "path", Value: 1}}},
+	})
+	if err != nil {
+		log.Ctx(ctx).Fatal().Err(err).Msg("create indexes on bug links collection")
+	}
+
+	return &bugLinkDataSource{
+		collection: c,
+	}
+}
+
+func (db *bugLinkDataSource) InsertMany(ctx context.Context, links []*entity.BugLink) error {
+	if len(links) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	docs := make([]interface{}, len(links))
+	for i, l := range links {
+		l.CreatedAt = now
+		docs[i] = l
+	}
+
+	_, err := db.collection.InsertMany(ctx, docs)
+	return err
+}
+
+func (db *bugLinkDataSource) FixBugLinkConnection(repoID identifier.RepositoryID, fix identifier.Hash, direction *entity.OrderDirection, pageCfg *entity.PaginationInput) entity.BugLinkConnection {
+	return db.connection(bson.M{
+		"repo": repoID,
+		"fix":  fix,
+	}, direction, pageCfg)
+}
+
+func (db *bugLinkDataSource) InducingBugLinkConnection(repoID identifier.RepositoryID, inducing identifier.Hash, direction *entity.OrderDirection, pageCfg *entity.PaginationInput) entity.BugLinkConnection {
+	return db.connection(bson.M{
+		"repo":     repoID,
+		"inducing": inducing,
+	}, direction, pageCfg)
+}
+
+func (db *bugLinkDataSource) PathBugLinkConnection(repoID identifier.RepositoryID, path string, direction *entity.OrderDirection, pageCfg *entity.PaginationInput) entity.BugLinkConnection {
+	return db.connection(bson.M{
+		"repo": repoID,
+		"path": path,
+	}, direction, pageCfg)
+}
+
+func (db *bugLinkDataSource) DeleteFixLinks(ctx context.Context, repoID identifier.RepositoryID, fix identifier.Hash) error {
+	_, err := db.collection.DeleteMany(ctx, bson.M{
+		"repo": repoID,
+		"fix":  fix,
+	})
+
+	return err
+}
+
+func (db *bugLinkDataSource) DeleteRepoLinks(ctx context.Context, repoID identifier.RepositoryID) error {
+	_, err := db.collection.DeleteMany(ctx, bson.M{
+		"repo": repoID,
+	})
+
+	return err
+}
+
+func (db *bugLinkDataSource) connection(filter bson.M, direction *entity.OrderDirection, pageCfg *entity.PaginationInput) entity.BugLinkConnection {
+	orderCfg := &orderDirectionConfig{
+		Direction: getOrderDirection(direction, entity.OrderDirectionDesc),
+		DescIndex: defaultDescIndex,
+		AscIndex:  defaultAscIndex,
+	}
+
+	return newBugLinkConnection(db.collection, filter, pageCfg, orderCfg, defaultCursorParser)
+}
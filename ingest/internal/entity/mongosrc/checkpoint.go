@@ -0,0 +1,87 @@
+package mongosrc
+
+import (
+	"context"
+	"time"
+
+	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const checkpointsCollection = "checkpoints"
+
+// checkpointDataSource keeps every saved batch of the analyzed commits in a
+// document, so the checkpoints of the large repositories do not exceed the
+// limit of the document size.
+type checkpointDataSource struct {
+	collection *mongo.Collection
+}
+
+type checkpointBatch struct {
+	Repo      identifier.RepositoryID `bson:"repo"`
+	Job       identifier.JobID        `bson:"job"`
+	Commits   []identifier.Hash       `bson:"commits"`
+	CreatedAt time.Time               `bson:"created_at"`
+}
+
+func NewCheckpointDataSource(ctx context.Context, db *mongo.Database) *checkpointDataSource {
+	c := db.Collection(checkpointsCollection)
+
+	_, err := c.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "repo", Value: 1}},
+	})
+	if err != nil {
+		log.Ctx(ctx).Fatal().Err(err).Msg("create indexes on checkpoints collection")
+	}
+
+	return &checkpointDataSource{
+		collection: c,
+	}
+}
+
+var checkpointCommitsOpts = options.Find().SetProjection(bson.M{"commits": 1})
+
+func (db *checkpointDataSource) AnalyzedCommits(ctx context.Context, repoID identifier.RepositoryID) (identifier.HashSet, error) {
+	cur, err := db.collection.Find(ctx, bson.M{
+		"repo": repoID,
+	}, checkpointCommitsOpts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	commits := identifier.NewHashSet()
+	for cur.Next(ctx) {
+		var batch checkpointBatch
+		err = cur.Decode(&batch)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, h := range batch.Commits {
+			commits.Add(h)
+		}
+	}
+
+	return commits, cur.Err()
+}
+
+func (db *checkpointDataSource) SaveAnalyzedCommits(ctx context.Context, repoID identifier.RepositoryID, jobID identifier.JobID, commits []identifier.Hash) error {
+	_, err := db.collection.InsertOne(ctx, &checkpointBatch{
+		Repo:      repoID,
+		Job:       jobID,
+		Commits:   commits,
+		CreatedAt: time.Now(),
+	})
+	return err
+}
+
+func (db *checkpointDataSource) DeleteRepoCheckpoint(ctx context.Context, repoID identifier.RepositoryID) error {
+	_, err := db.collection.DeleteMany(ctx, bson.M{
+		"repo": repoID,
+	})
+	return err
+}
@@ -92,11 +92,12 @@ func (db *commitDataSource) SelectedCommitConnection(ctx context.Context, repoID
 }
 
 func NewCommitDataSource(ctx context.Context, db *mongo.Database) *commitDataSource {
-	_, err := db.Collection(commitsCollection).Indexes().CreateOne(ctx, mongo.IndexModel{
-		Keys: ascCommitIndex,
+	_, err := db.Collection(commitsCollection).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: ascCommitIndex},
+		{Keys: bson.D{{Key: "_id.r", Value: 1}, {Key: "release", Value: 1}}},
 	})
 	if err != nil {
-		log.Ctx(ctx).Fatal().Err(err).Msg("create index on commit collection")
+		log.Ctx(ctx).Fatal().Err(err).Msg("create indexes on commit collection")
 	}
 
 	return &commitDataSource{
@@ -106,15 +107,20 @@ func NewCommitDataSource(ctx context.Context, db *mongo.Database) *commitDataSou
 
 var insertOrReplaceOpts = options.Replace().SetUpsert(true)
 
-func (db *commitDataSource) InsertOrReplace(ctx context.Context, c *entity.Commit) error {
+func (db *commitDataSource) InsertOrReplace(ctx context.Context, c *entity.Commit) (bool, error) {
 	c.CreatedAt = time.Now()
 	r, err := db.collection.ReplaceOne(ctx, bson.M{
 		"_id": c.ID,
 	}, c, insertOrReplaceOpts)
-	if r != nil && r.MatchedCount > 0 {
+	if err != nil {
+		return false, err
+	}
+
+	if r.MatchedCount > 0 {
 		log.Ctx(ctx).Debug().Msg("commit already exists and has been replaced")
+		return true, nil
 	}
-	return err
+	return false, nil
 }
 
 func (db *commitDataSource) countOverTime(ctx context.Context, filter interface{}) ([]*entity.CountOverTime, error) {
@@ -267,44 +273,55 @@ var descCommitIndex = bson.D{
 	{Key: "_id.h", Value: -1},
 }
 
+// developerEmail and developerName are the resolved identity of the author,
+// the commits that are analyzed before resolving the identities fall back to
+// the author signature. The bot commits are not counted for the developers.
+var (
+	developerEmail = bson.M{"$ifNull": bson.A{"$identity.email", "$author.email"}}
+	developerName  = bson.M{"$ifNull": bson.A{"$identity.name", "$author.name"}}
+)
+
 func (db *commitDataSource) DeveloperEmails(ctx context.Context, repoID identifier.RepositoryID) ([]string, error) {
-	filter := bson.M{"_id.r": repoID}
+	return db.distinctDevelopers(ctx, repoID, developerEmail)
+}
+
+func (db *commitDataSource) DeveloperNames(ctx context.Context, repoID identifier.RepositoryID) ([]string, error) {
+	return db.distinctDevelopers(ctx, repoID, developerName)
+}
+
+func (db *commitDataSource) distinctDevelopers(ctx context.Context, repoID identifier.RepositoryID, field interface{}) ([]string, error) {
+	pipe := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"_id.r": repoID, "tags": bson.M{"$ne": classify.Bot}}}},
+		{{Key: "$group", Value: bson.M{"_id": field}}},
+	}
 
-	res, err := db.collection.Distinct(ctx, "author.email", filter)
+	cur, err := db.collection.Aggregate(ctx, pipe)
 	if err != nil {
 		return nil, err
 	}
 
-	emails := make([]string, len(res))
-	for i := range res {
-		emails[i] = res[i].(string)
+	var res []struct {
+		Value string `bson:"_id"`
 	}
-
-	return emails, nil
-}
-
-func (db *commitDataSource) DeveloperNames(ctx context.Context, repoID identifier.RepositoryID) ([]string, error) {
-	filter := bson.M{"_id.r": repoID}
-
-	res, err := db.collection.Distinct(ctx, "author.name", filter)
+	err = cur.All(ctx, &res)
 	if err != nil {
 		return nil, err
 	}
 
-	names := make([]string, len(res))
+	values := make([]string, len(res))
 	for i := range res {
-		names[i] = res[i].(string)
+		values[i] = res[i].Value
 	}
 
-	return names, nil
+	return values, nil
 }
 
 func (db *commitDataSource) DevelopersAggregatedMetrics(ctx context.Context, repoID identifier.RepositoryID) (entity.ChangeMeasuresIter, error) {
-	filter := bson.M{"_id.r": repoID}
+	filter := bson.M{"_id.r": repoID, "tags": bson.M{"$ne": classify.Bot}}
 	pipe := mongo.Pipeline{
 		{{Key: "$match", Value: filter}},
 		{{Key: "$group", Value: bson.M{
-			"_id":     "$author.email",
+			"_id":     developerEmail,
 			"ns":      bson.M{"$avg": "$metrics.ns"},
 			"nd":      bson.M{"$avg": "$metrics.nd"},
 			"nf":      bson.M{"$avg": "$metrics.nf"},
@@ -319,6 +336,10 @@ func (db *commitDataSource) DevelopersAggregatedMetrics(ctx context.Context, rep
 			"exp":     bson.M{"$max": "$metrics.exp"},
 			"rexp":    bson.M{"$max": "$metrics.rexp"},
 			"sexp":    bson.M{"$max": "$metrics.sexp"},
+			"cc":      bson.M{"$avg": "$metrics.cc"},
+			"dcc":     bson.M{"$avg": "$metrics.dcc"},
+			"nest":    bson.M{"$avg": "$metrics.nest"},
+			"nfn":     bson.M{"$avg": "$metrics.nfn"},
 		}}},
 	}
 
@@ -366,6 +387,14 @@ func (db *commitDataSource) RepositoryCommitConnection(ctx context.Context, repo
 		filter["author.name"] = filters.DeveloperName
 	}
 
+	if filters.Release != nil {
+		filter["release"] = filters.Release
+	}
+
+	if filters.Buggy != nil {
+		filter["fixes.0"] = bson.M{"$exists": *filters.Buggy}
+	}
+
 	orderCfg := orderDirectionConfig{
 		Direction: getOrderDirection(direction, entity.OrderDirectionDesc),
 		DescIndex: descCommitIndex,
@@ -494,6 +523,96 @@ func (db *commitDataSource) SaveCommitAnalysis(ctx context.Context, analyses ...
 	return err
 }
 
+func (db *commitDataSource) SaveSubsystems(ctx context.Context, holders ...*entity.CommitSubsystemsHolder) error {
+	if len(holders) == 0 {
+		// nothing to save
+		return nil
+	}
+
+	models := make([]mongo.WriteModel, len(holders))
+
+	for i, h := range holders {
+		fieldValues := make(bson.M, len(h.Subsystems)+2)
+		for ii, s := range h.Subsystems {
+			fieldValues[fmt.Sprintf("files.%d.subsystem", ii)] = s
+		}
+		if h.Metrics != nil {
+			fieldValues["metrics.ns"] = h.Metrics.NS
+			fieldValues["metrics.sexp"] = h.Metrics.SEXP
+		}
+
+		models[i] = mongo.NewUpdateOneModel().SetFilter(bson.M{
+			"_id": h.ID,
+		}).SetUpdate(bson.M{
+			"$set": fieldValues,
+		})
+	}
+
+	_, err := db.collection.BulkWrite(ctx, models)
+
+	return err
+}
+
+// saveReleasesBatchSize limits the number of hashes in a single update.
+const saveReleasesBatchSize = 1000
+
+func (db *commitDataSource) SaveReleases(ctx context.Context, repoID identifier.RepositoryID, releases map[identifier.Hash]string) error {
+	hashes := make(map[string][]identifier.Hash)
+	for hash, tag := range releases {
+		hashes[tag] = append(hashes[tag], hash)
+	}
+
+	stored, err := db.collection.Distinct(ctx, "release", bson.M{
+		"_id.r":   repoID,
+		"release": bson.M{"$exists": true},
+	})
+	if err != nil {
+		return err
+	}
+
+	// only the commits with changed releases are updated
+	var models []mongo.WriteModel
+	for _, v := range stored {
+		tag, ok := v.(string)
+		if !ok {
+			continue
+		}
+
+		nin := hashes[tag]
+		if nin == nil {
+			nin = []identifier.Hash{}
+		}
+		models = append(models, mongo.NewUpdateManyModel().SetFilter(bson.M{
+			"_id.r":   repoID,
+			"release": tag,
+			"_id.h":   bson.M{"$nin": nin},
+		}).SetUpdate(bson.M{"$unset": bson.M{"release": ""}}))
+	}
+
+	for tag, list := range hashes {
+		for len(list) > 0 {
+			n := len(list)
+			if n > saveReleasesBatchSize {
+				n = saveReleasesBatchSize
+			}
+
+			models = append(models, mongo.NewUpdateManyModel().SetFilter(bson.M{
+				"_id.r":   repoID,
+				"_id.h":   bson.M{"$in": list[:n]},
+				"release": bson.M{"$ne": tag},
+			}).SetUpdate(bson.M{"$set": bson.M{"release": tag}}))
+			list = list[n:]
+		}
+	}
+
+	if len(models) == 0 {
+		return nil
+	}
+
+	_, err = db.collection.BulkWrite(ctx, models)
+	return err
+}
+
 func (db *commitDataSource) FindRepoCommits(ctx context.Context, repoID identifier.RepositoryID, opts ...*options.FindOptions) (entity.CommitIter, error) {
 	return db.find(ctx, bson.M{
 		"_id.r": repoID,
@@ -516,10 +635,7 @@ func (db *commitDataSource) FindByID(ctx context.Context, commitID *identifier.C
 }
 
 func (db *commitDataSource) ContributorsCount(ctx context.Context, repoID identifier.RepositoryID) (int, error) {
-	val, err := db.collection.Distinct(ctx, "author.email", bson.M{
-		"_id.r": repoID,
-	})
-
+	val, err := db.DeveloperEmails(ctx, repoID)
 	return len(val), err
 }
 
@@ -530,6 +646,38 @@ func (db *commitDataSource) BugInducingCount(ctx context.Context, repoID identif
 	})
 }
 
+func (db *commitDataSource) ReleaseStatistics(ctx context.Context, repoID identifier.RepositoryID, tag string) (*entity.ReleaseStatistics, error) {
+	cur, err := db.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"_id.r":   repoID,
+			"release": tag,
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":           nil,
+			"commits_count": bson.M{"$sum": 1},
+			"buggy_count": bson.M{"$sum": bson.M{
+				"$cond": bson.A{bson.M{"$gt": bson.A{bson.M{"$size": bson.M{"$ifNull": bson.A{"$fixes", bson.A{}}}}, 0}}, 1, 0},
+			}},
+			"avg_risk": bson.M{"$avg": "$analysis.bug_potential"},
+			"max_risk": bson.M{"$max": "$analysis.bug_potential"},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var res []*entity.ReleaseStatistics
+	err = cur.All(ctx, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res) == 0 {
+		return &entity.ReleaseStatistics{}, nil
+	}
+	return res[0], nil
+}
+
 func (db *commitDataSource) BugFixingCount(ctx context.Context, repoID identifier.RepositoryID) (int, error) {
 	res, err := db.collection.Distinct(ctx, "fixes", bson.M{
 		"_id.r": repoID,
@@ -539,6 +687,31 @@ func (db *commitDataSource) BugFixingCount(ctx context.Context, repoID identifie
 	return len(res), err
 }
 
+func (db *commitDataSource) DroppedBugsCount(ctx context.Context, repoID identifier.RepositoryID) ([]*entity.DropReasonCount, error) {
+	cur, err := db.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"_id.r":          repoID,
+			"dropped_bugs.0": bson.M{"$exists": true},
+		}}},
+		{{Key: "$unwind", Value: "$dropped_bugs"}},
+		{{Key: "$group", Value: bson.M{
+			"_id": "$dropped_bugs.reason",
+			"count": bson.M{
+				"$sum": 1,
+			},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var res []*entity.DropReasonCount
+	err = cur.All(ctx, &res)
+
+	return res, err
+}
+
 func (db *commitDataSource) FindJobCommits(ctx context.Context, repoID identifier.RepositoryID, job identifier.JobID) (entity.CommitIter, error) {
 	return db.find(ctx, bson.M{
 		"_id.r": repoID,
@@ -659,9 +832,68 @@ func (db *commitDataSource) RemoveBranch(ctx context.Context, repoID identifier.
 	return err
 }
 
+const pruneBatchSize = 1000
+
+// Prune deletes the commits that are not in any branch or pull request, with
+// the bug links where they are the fixes or the inducing commits.
 func (db *commitDataSource) Prune(ctx context.Context, repoID identifier.RepositoryID) error {
-	_, err := db.collection.DeleteMany(ctx, bson.M{
+	cur, err := db.collection.Find(ctx, bson.M{
+		"_id.r":      repoID,
+		"pulls.0":    bson.M{"$exists": false},
+		"branches.0": bson.M{"$exists": false},
+	}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+
+	var hashes []identifier.Hash
+	for cur.Next(ctx) {
+		var doc struct {
+			ID identifier.CommitID `bson:"_id"`
+		}
+		err = cur.Decode(&doc)
+		if err != nil {
+			return err
+		}
+
+		hashes = append(hashes, doc.ID.CommitHash)
+		if len(hashes) == pruneBatchSize {
+			err = db.pruneCommits(ctx, repoID, hashes)
+			if err != nil {
+				return err
+			}
+			hashes = hashes[:0]
+		}
+	}
+	if err = cur.Err(); err != nil {
+		return err
+	}
+
+	return db.pruneCommits(ctx, repoID, hashes)
+}
+
+// pruneCommits deletes the bug links of the commits then the commits, so the
+// links are not left behind if the commits deletion fails.
+func (db *commitDataSource) pruneCommits(ctx context.Context, repoID identifier.RepositoryID, hashes []identifier.Hash) error {
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	_, err := db.collection.Database().Collection(bugLinksCollection).DeleteMany(ctx, bson.M{
+		"repo": repoID,
+		"$or": bson.A{
+			bson.M{"fix": bson.M{"$in": hashes}},
+			bson.M{"inducing": bson.M{"$in": hashes}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.collection.DeleteMany(ctx, bson.M{
 		"_id.r":      repoID,
+		"_id.h":      bson.M{"$in": hashes},
 		"pulls.0":    bson.M{"$exists": false},
 		"branches.0": bson.M{"$exists": false},
 	})
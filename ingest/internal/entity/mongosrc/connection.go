@@ -95,6 +95,24 @@ func commitCursorParser(repoID identifier.RepositoryID) func(cursor *string) (in
 	}
 }
 
+func releaseCursorParser(repoID identifier.RepositoryID) func(cursor *string) (interface{}, error) {
+	return func(cursor *string) (interface{}, error) {
+		if cursor == nil {
+			return nil, nil
+		}
+
+		var c entity.ReleaseCursor
+		err := c.UnmarshalBase64([]byte(*cursor))
+		if err != nil {
+			return nil, err
+		}
+
+		c.RepoID = repoID
+
+		return c, nil
+	}
+}
+
 func base64ToObjectID(dst *primitive.ObjectID, src []byte) error {
 	if base64.StdEncoding.DecodedLen(len(src)) != len(dst) {
 		return errors.New("unexpected base64 length")
@@ -841,3 +841,279 @@ func backwardOrganizationEdges(ctx context.Context, cur *mongo.Cursor, opts *ent
 
 	return edges, nil
 }
+
+type BugLinkConnection struct {
+	collection   *mongo.Collection
+	filter       bson.M
+	pgInput      *entity.PaginationInput
+	orderCfg     *orderDirectionConfig
+	cursorParser FuncCursorParser
+
+	edges   []*entity.BugLinkEdge
+	hasNext bool
+	once    sync.Once
+}
+
+func newBugLinkConnection(collection *mongo.Collection, filter bson.M, pgInput *entity.PaginationInput, orderCfg *orderDirectionConfig, cursorParser FuncCursorParser) *BugLinkConnection {
+	return &BugLinkConnection{
+		collection:   collection,
+		filter:       filter,
+		pgInput:      pgInput,
+		orderCfg:     orderCfg,
+		cursorParser: cursorParser,
+	}
+}
+
+func (c *BugLinkConnection) TotalCount(ctx context.Context) (int64, error) {
+	return c.collection.CountDocuments(ctx, c.filter)
+}
+
+func (c *BugLinkConnection) Edges(ctx context.Context) ([]*entity.BugLinkEdge, error) {
+	var err error
+
+	c.once.Do(func() {
+		c.edges, c.hasNext, err = findBugLinkEdges(ctx, c.collection, c.filter, c.pgInput, c.orderCfg, c.cursorParser)
+	})
+
+	return c.edges, err
+}
+
+func (c *BugLinkConnection) PageInfo(ctx context.Context) (*entity.PageInfo, error) {
+	edges, err := c.Edges(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.PageInfoFromBugLinkEdges(edges, c.hasNext, c.pgInput), nil
+}
+
+func (c *BugLinkConnection) Nodes(ctx context.Context) ([]*entity.BugLink, error) {
+	edges, err := c.Edges(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]*entity.BugLink, len(edges))
+	for i := range edges {
+		nodes[i] = &edges[i].Node
+	}
+	return nodes, nil
+}
+
+func findBugLinkEdges(ctx context.Context, collection *mongo.Collection, filter bson.M, pgInput *entity.PaginationInput, orderCfg *orderDirectionConfig, cursorParser FuncCursorParser) ([]*entity.BugLinkEdge, bool, error) {
+	err := pgInput.Validate("BugLinks", 100)
+	if err != nil {
+		return nil, false, err
+	}
+
+	mongoOpts := options.Find()
+
+	filter = copyBsonM(filter) //fixme: should have a better solution
+	err = applyPaginationOptions(mongoOpts, filter, pgInput, orderCfg, cursorParser)
+	if err != nil {
+		return nil, false, err
+	}
+
+	//todo: apply projection
+
+	cur, err := collection.Find(ctx, filter, mongoOpts)
+	if err != nil {
+		if err, ok := err.(mongo.CommandError); ok && err.Code == 51175 {
+			// no results
+			return make([]*entity.BugLinkEdge, 0), false, nil
+		}
+		return nil, false, err
+	}
+	defer cur.Close(ctx)
+
+	edges, err := getSortedBugLinkEdges(ctx, cur, pgInput)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return edges, cur.Next(ctx), err
+}
+
+func getSortedBugLinkEdges(ctx context.Context, cur *mongo.Cursor, opts *entity.PaginationInput) ([]*entity.BugLinkEdge, error) {
+	if opts.Last != nil {
+		return backwardBugLinkEdges(ctx, cur, opts)
+	}
+
+	return forwardBugLinkEdges(ctx, cur, opts)
+}
+
+func forwardBugLinkEdges(ctx context.Context, cur *mongo.Cursor, opts *entity.PaginationInput) ([]*entity.BugLinkEdge, error) {
+	var limit = *opts.First
+	var edges = make([]*entity.BugLinkEdge, limit)
+	var index = 0
+
+	for index < limit && cur.Next(ctx) {
+		var edge entity.BugLinkEdge
+		err := cur.Decode(&edge.Node)
+		if err != nil {
+			return nil, err
+		}
+		edges[index] = &edge
+		index++
+	}
+	edges = edges[:index]
+
+	return edges, nil
+}
+
+func backwardBugLinkEdges(ctx context.Context, cur *mongo.Cursor, opts *entity.PaginationInput) ([]*entity.BugLinkEdge, error) {
+	var limit = *opts.Last
+	var edges = make([]*entity.BugLinkEdge, limit)
+	var index = limit - 1
+
+	for index >= 0 && cur.Next(ctx) {
+		var c entity.BugLinkEdge
+		err := cur.Decode(&c.Node)
+		if err != nil {
+			return nil, err
+		}
+		edges[index] = &c
+		index--
+	}
+	edges = edges[index+1:]
+
+	return edges, nil
+}
+
+type ReleaseConnection struct {
+	collection   *mongo.Collection
+	filter       bson.M
+	pgInput      *entity.PaginationInput
+	orderCfg     *orderDirectionConfig
+	cursorParser FuncCursorParser
+
+	edges   []*entity.ReleaseEdge
+	hasNext bool
+	once    sync.Once
+}
+
+func newReleaseConnection(collection *mongo.Collection, filter bson.M, pgInput *entity.PaginationInput, orderCfg *orderDirectionConfig, cursorParser FuncCursorParser) *ReleaseConnection {
+	return &ReleaseConnection{
+		collection:   collection,
+		filter:       filter,
+		pgInput:      pgInput,
+		orderCfg:     orderCfg,
+		cursorParser: cursorParser,
+	}
+}
+
+func (c *ReleaseConnection) TotalCount(ctx context.Context) (int64, error) {
+	return c.collection.CountDocuments(ctx, c.filter)
+}
+
+func (c *ReleaseConnection) Edges(ctx context.Context) ([]*entity.ReleaseEdge, error) {
+	var err error
+
+	c.once.Do(func() {
+		c.edges, c.hasNext, err = findReleaseEdges(ctx, c.collection, c.filter, c.pgInput, c.orderCfg, c.cursorParser)
+	})
+
+	return c.edges, err
+}
+
+func (c *ReleaseConnection) PageInfo(ctx context.Context) (*entity.PageInfo, error) {
+	edges, err := c.Edges(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.PageInfoFromReleaseEdges(edges, c.hasNext, c.pgInput), nil
+}
+
+func (c *ReleaseConnection) Nodes(ctx context.Context) ([]*entity.Release, error) {
+	edges, err := c.Edges(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]*entity.Release, len(edges))
+	for i := range edges {
+		nodes[i] = &edges[i].Node
+	}
+	return nodes, nil
+}
+
+func findReleaseEdges(ctx context.Context, collection *mongo.Collection, filter bson.M, pgInput *entity.PaginationInput, orderCfg *orderDirectionConfig, cursorParser FuncCursorParser) ([]*entity.ReleaseEdge, bool, error) {
+	err := pgInput.Validate("Releases", 100)
+	if err != nil {
+		return nil, false, err
+	}
+
+	mongoOpts := options.Find()
+
+	filter = copyBsonM(filter) //fixme: should have a better solution
+	err = applyPaginationOptions(mongoOpts, filter, pgInput, orderCfg, cursorParser)
+	if err != nil {
+		return nil, false, err
+	}
+
+	//todo: apply projection
+
+	cur, err := collection.Find(ctx, filter, mongoOpts)
+	if err != nil {
+		if err, ok := err.(mongo.CommandError); ok && err.Code == 51175 {
+			// no results
+			return make([]*entity.ReleaseEdge, 0), false, nil
+		}
+		return nil, false, err
+	}
+	defer cur.Close(ctx)
+
+	edges, err := getSortedReleaseEdges(ctx, cur, pgInput)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return edges, cur.Next(ctx), err
+}
+
+func getSortedReleaseEdges(ctx context.Context, cur *mongo.Cursor, opts *entity.PaginationInput) ([]*entity.ReleaseEdge, error) {
+	if opts.Last != nil {
+		return backwardReleaseEdges(ctx, cur, opts)
+	}
+
+	return forwardReleaseEdges(ctx, cur, opts)
+}
+
+func forwardReleaseEdges(ctx context.Context, cur *mongo.Cursor, opts *entity.PaginationInput) ([]*entity.ReleaseEdge, error) {
+	var limit = *opts.First
+	var edges = make([]*entity.ReleaseEdge, limit)
+	var index = 0
+
+	for index < limit && cur.Next(ctx) {
+		var edge entity.ReleaseEdge
+		err := cur.Decode(&edge.Node)
+		if err != nil {
+			return nil, err
+		}
+		edges[index] = &edge
+		index++
+	}
+	edges = edges[:index]
+
+	return edges, nil
+}
+
+func backwardReleaseEdges(ctx context.Context, cur *mongo.Cursor, opts *entity.PaginationInput) ([]*entity.ReleaseEdge, error) {
+	var limit = *opts.Last
+	var edges = make([]*entity.ReleaseEdge, limit)
+	var index = limit - 1
+
+	for index >= 0 && cur.Next(ctx) {
+		var c entity.ReleaseEdge
+		err := cur.Decode(&c.Node)
+		if err != nil {
+			return nil, err
+		}
+		edges[index] = &c
+		index--
+	}
+	edges = edges[index+1:]
+
+	return edges, nil
+}
@@ -351,3 +351,71 @@ func (iter *entityFeedbackIter) Slice(ctx context.Context) ([]*entity.Feedback,
 
 	return s, err
 }
+
+type entityBugLinkIter struct {
+	cur *mongo.Cursor
+}
+
+func newEntityBugLinkIter(cur *mongo.Cursor) *entityBugLinkIter {
+	return &entityBugLinkIter{cur: cur}
+}
+
+func (iter *entityBugLinkIter) ForEach(ctx context.Context, fun func(*entity.BugLink) error) error {
+	defer iter.cur.Close(ctx)
+	for iter.cur.Next(ctx) {
+		var doc entity.BugLink
+		if err := iter.cur.Decode(&doc); err != nil {
+			return err
+		}
+
+		if err := fun(&doc); err != nil {
+			return err
+		}
+	}
+	return iter.cur.Err()
+}
+
+func (iter *entityBugLinkIter) Slice(ctx context.Context) ([]*entity.BugLink, error) {
+	s := make([]*entity.BugLink, 0, iter.cur.RemainingBatchLength())
+
+	err := iter.ForEach(ctx, func(i *entity.BugLink) error {
+		s = append(s, i)
+		return nil
+	})
+
+	return s, err
+}
+
+type entityReleaseIter struct {
+	cur *mongo.Cursor
+}
+
+func newEntityReleaseIter(cur *mongo.Cursor) *entityReleaseIter {
+	return &entityReleaseIter{cur: cur}
+}
+
+func (iter *entityReleaseIter) ForEach(ctx context.Context, fun func(*entity.Release) error) error {
+	defer iter.cur.Close(ctx)
+	for iter.cur.Next(ctx) {
+		var doc entity.Release
+		if err := iter.cur.Decode(&doc); err != nil {
+			return err
+		}
+
+		if err := fun(&doc); err != nil {
+			return err
+		}
+	}
+	return iter.cur.Err()
+}
+
+func (iter *entityReleaseIter) Slice(ctx context.Context) ([]*entity.Release, error) {
+	s := make([]*entity.Release, 0, iter.cur.RemainingBatchLength())
+
+	err := iter.ForEach(ctx, func(i *entity.Release) error {
+		s = append(s, i)
+		return nil
+	})
+
+	return s, err
+}
@@ -139,6 +139,28 @@ func (db *jobDataSource) ReportError(ctx context.Context, id identifier.JobID, r
 	return err
 }
 
+func (db *jobDataSource) ReportAttempt(ctx context.Context, id identifier.JobID, task string, attempt int, report error) error {
+	_, err := db.collection.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$push": bson.M{"log": &entity.Update{
+			Status:    status.Retrying,
+			StartedAt: time.Now(),
+			Task:      task,
+			Attempt:   attempt,
+			Error:     report.Error(),
+		}}})
+
+	return err
+}
+
+func (db *jobDataSource) ReportWarnings(ctx context.Context, id identifier.JobID, warnings ...string) error {
+	_, err := db.collection.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$push": bson.M{"warnings": bson.M{"$each": warnings}}})
+
+	return err
+}
+
 func (db *jobDataSource) find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (entity.JobIter, error) {
 	cur, err := db.collection.Find(ctx, filter, opts...)
 	if err != nil {
@@ -0,0 +1,377 @@
+package mongosrc
+
+import (
+	"context"
+	"time"
+
+	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+	"github.com/repofuel/repofuel/ingest/pkg/jobqueue"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	jobQueueCollection  = "job_queue"
+	jobLeasesCollection = "job_leases"
+)
+
+// jobQueue keeps the queued jobs in a collection, and the leases of the
+// repositories in another collection where the repository is the document ID,
+// so a repository cannot be leased twice. The leases are expired by the clocks
+// of the nodes, so the TTL should be much longer than the clock skew. A paused
+// repository has a lease without an owner that does not expire.
+type jobQueue struct {
+	entries *mongo.Collection
+	leases  *mongo.Collection
+}
+
+func NewJobQueue(ctx context.Context, db *mongo.Database) *jobQueue {
+	entries := db.Collection(jobQueueCollection)
+
+	_, err := entries.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "queue", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "repo", Value: 1}, {Key: "_id", Value: 1}}},
+	})
+	if err != nil {
+		log.Ctx(ctx).Fatal().Err(err).Msg("create indexes on job queue collection")
+	}
+
+	return &jobQueue{
+		entries: entries,
+		leases:  db.Collection(jobLeasesCollection),
+	}
+}
+
+// pausedExpires is the expiry of the paused leases, they are deleted when the
+// repositories are resumed.
+var pausedExpires = time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+var startEntryOpts = options.FindOneAndUpdate().
+	SetSort(bson.M{"_id": 1}).
+	SetReturnDocument(options.After)
+
+func (db *jobQueue) Push(ctx context.Context, e *jobqueue.Entry) error {
+	_, err := db.entries.InsertOne(ctx, e)
+	return err
+}
+
+func (db *jobQueue) Lease(ctx context.Context, owner string, ttl time.Duration, filter *jobqueue.Filter) (*jobqueue.Entry, error) {
+	now := time.Now()
+	cur, err := db.leases.Find(ctx, bson.M{
+		"expires": bson.M{"$gt": now},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var leases []struct {
+		Repo   identifier.RepositoryID   `bson:"_id"`
+		Org    identifier.OrganizationID `bson:"org"`
+		Paused bool                      `bson:"paused"`
+	}
+	err = cur.All(ctx, &leases)
+	if err != nil {
+		return nil, err
+	}
+
+	leasedRepos := make([]identifier.RepositoryID, len(leases))
+	leasedOrgs := make(map[identifier.OrganizationID]int)
+	for i, l := range leases {
+		leasedRepos[i] = l.Repo
+		if !l.Paused {
+			leasedOrgs[l.Org]++
+		}
+	}
+
+	// the first job of every organization in every priority, among the first
+	// jobs of the repositories that are in the filtered queues
+	cur, err = db.entries.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"repo": bson.M{"$nin": leasedRepos},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      "$repo",
+			"job":      bson.M{"$first": "$_id"},
+			"org":      bson.M{"$first": "$org"},
+			"queue":    bson.M{"$first": "$queue"},
+			"priority": bson.M{"$first": "$priority"},
+		}}},
+		{{Key: "$match", Value: bson.M{
+			"queue": bson.M{"$in": filter.Queues},
+		}}},
+		{{Key: "$sort", Value: bson.M{"job": 1}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      bson.M{"org": "$org", "priority": "$priority"},
+			"job":      bson.M{"$first": "$job"},
+			"repo":     bson.M{"$first": "$_id"},
+			"org":      bson.M{"$first": "$org"},
+			"priority": bson.M{"$first": "$priority"},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []struct {
+		Job      identifier.JobID          `bson:"job"`
+		Repo     identifier.RepositoryID   `bson:"repo"`
+		Org      identifier.OrganizationID `bson:"org"`
+		Priority uint8                     `bson:"priority"`
+	}
+	err = cur.All(ctx, &groups)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]*jobqueue.Entry, len(groups))
+	for i, g := range groups {
+		candidates[i] = &jobqueue.Entry{Job: g.Job, Repo: g.Repo, Org: g.Org, Priority: g.Priority}
+	}
+
+	for _, c := range jobqueue.SortCandidates(candidates, leasedOrgs, filter) {
+		ok, err := db.acquire(ctx, c.Repo, c.Org, owner, now.Add(ttl))
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			// leased by another node in the meantime
+			continue
+		}
+
+		e, err := db.start(ctx, c.Repo)
+		if err == mongo.ErrNoDocuments {
+			// the jobs are removed in the meantime
+			err = db.release(ctx, c.Repo, owner)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return e, err
+	}
+
+	return nil, jobqueue.ErrEmpty
+}
+
+func (db *jobQueue) Next(ctx context.Context, owner string, ttl time.Duration, done *jobqueue.Entry) (*jobqueue.Entry, error) {
+	_, err := db.entries.DeleteOne(ctx, bson.M{
+		"_id": done.Job,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	lost, err := db.Extend(ctx, owner, ttl, done.Repo)
+	if err != nil {
+		return nil, err
+	}
+	if len(lost) > 0 {
+		return nil, jobqueue.ErrLeaseLost
+	}
+
+	e, err := db.start(ctx, done.Repo)
+	if err == mongo.ErrNoDocuments {
+		err = db.release(ctx, done.Repo, owner)
+		if err != nil {
+			return nil, err
+		}
+		return nil, jobqueue.ErrEmpty
+	}
+
+	return e, err
+}
+
+func (db *jobQueue) Extend(ctx context.Context, owner string, ttl time.Duration, repos ...identifier.RepositoryID) ([]identifier.RepositoryID, error) {
+	now := time.Now()
+
+	var lost []identifier.RepositoryID
+	for _, repo := range repos {
+		r, err := db.leases.UpdateOne(ctx, bson.M{
+			"_id":     repo,
+			"owner":   owner,
+			"expires": bson.M{"$gt": now},
+		}, bson.M{
+			"$set": bson.M{"expires": now.Add(ttl)},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if r.MatchedCount == 0 {
+			lost = append(lost, repo)
+		}
+	}
+
+	return lost, nil
+}
+
+func (db *jobQueue) Remove(ctx context.Context, repo identifier.RepositoryID) error {
+	_, err := db.entries.DeleteMany(ctx, bson.M{
+		"repo": repo,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = db.leases.DeleteOne(ctx, bson.M{
+		"_id": repo,
+	})
+	return err
+}
+
+func (db *jobQueue) Pause(ctx context.Context, repo identifier.RepositoryID) error {
+	_, err := db.leases.ReplaceOne(ctx, bson.M{
+		"_id": repo,
+	}, bson.M{
+		"owner":   "",
+		"paused":  true,
+		"expires": pausedExpires,
+	}, options.Replace().SetUpsert(true))
+	if err != nil {
+		return err
+	}
+
+	_, err = db.entries.UpdateMany(ctx, bson.M{
+		"repo": repo,
+	}, bson.M{
+		"$unset": bson.M{"started_at": ""},
+	})
+	return err
+}
+
+func (db *jobQueue) Resume(ctx context.Context, repo identifier.RepositoryID) error {
+	r, err := db.leases.DeleteOne(ctx, bson.M{
+		"_id":    repo,
+		"paused": true,
+	})
+	if err != nil {
+		return err
+	}
+
+	if r.DeletedCount == 0 {
+		return jobqueue.ErrNotPaused
+	}
+	return nil
+}
+
+func (db *jobQueue) Paused(ctx context.Context, repo identifier.RepositoryID) (bool, error) {
+	n, err := db.leases.CountDocuments(ctx, bson.M{
+		"_id":    repo,
+		"paused": true,
+	}, options.Count().SetLimit(1))
+
+	return n > 0, err
+}
+
+func (db *jobQueue) Stats(ctx context.Context) ([]*jobqueue.Stats, error) {
+	now := time.Now()
+	// the started jobs of the expired leases wait to be leased again
+	started := bson.M{"$and": bson.A{
+		bson.M{"$eq": bson.A{bson.M{"$type": "$started_at"}, "date"}},
+		bson.M{"$gt": bson.A{bson.M{"$max": "$lease.expires"}, now}},
+	}}
+	// the IDs are ObjectIDs that are created when the jobs are queued
+	queuedAt := bson.M{"$toDate": "$_id"}
+
+	cur, err := db.entries.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$lookup", Value: bson.M{
+			"from":         jobLeasesCollection,
+			"localField":   "repo",
+			"foreignField": "_id",
+			"as":           "lease",
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     "$queue",
+			"running": bson.M{"$sum": bson.M{"$cond": bson.A{started, 1, 0}}},
+			"waiting": bson.M{"$sum": bson.M{"$cond": bson.A{started, 0, 1}}},
+			"oldest":  bson.M{"$min": bson.M{"$cond": bson.A{started, nil, queuedAt}}},
+			"avg":     bson.M{"$avg": bson.M{"$cond": bson.A{started, nil, bson.M{"$toLong": queuedAt}}}},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []struct {
+		Queue   uint8     `bson:"_id"`
+		Running int       `bson:"running"`
+		Waiting int       `bson:"waiting"`
+		Oldest  time.Time `bson:"oldest"`
+		Avg     float64   `bson:"avg"`
+	}
+	err = cur.All(ctx, &docs)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]*jobqueue.Stats, len(docs))
+	for i, doc := range docs {
+		stats[i] = &jobqueue.Stats{
+			Queue:   doc.Queue,
+			Running: doc.Running,
+			Waiting: doc.Waiting,
+		}
+
+		if doc.Waiting > 0 {
+			stats[i].OldestWait = now.Sub(doc.Oldest)
+			stats[i].AverageWait = now.Sub(time.Unix(0, int64(doc.Avg)*int64(time.Millisecond)))
+		}
+	}
+
+	return stats, nil
+}
+
+func (db *jobQueue) Has(ctx context.Context, repo identifier.RepositoryID) (bool, error) {
+	n, err := db.entries.CountDocuments(ctx, bson.M{
+		"repo": repo,
+	}, options.Count().SetLimit(1))
+
+	return n > 0, err
+}
+
+// acquire claims the lease of the repository if it is not leased or its lease
+// is expired. The upsert fails with a duplicate key if the repository is leased.
+func (db *jobQueue) acquire(ctx context.Context, repo identifier.RepositoryID, org identifier.OrganizationID, owner string, expires time.Time) (bool, error) {
+	_, err := db.leases.UpdateOne(ctx, bson.M{
+		"_id":     repo,
+		"expires": bson.M{"$lte": time.Now()},
+	}, bson.M{
+		"$set": bson.M{
+			"owner":   owner,
+			"org":     org,
+			"expires": expires,
+		},
+	}, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func (db *jobQueue) release(ctx context.Context, repo identifier.RepositoryID, owner string) error {
+	_, err := db.leases.DeleteOne(ctx, bson.M{
+		"_id":   repo,
+		"owner": owner,
+	})
+	return err
+}
+
+// start marks the first job of the repository as started and returns it.
+func (db *jobQueue) start(ctx context.Context, repo identifier.RepositoryID) (*jobqueue.Entry, error) {
+	var e jobqueue.Entry
+	err := db.entries.FindOneAndUpdate(ctx, bson.M{
+		"repo": repo,
+	}, bson.M{
+		"$set": bson.M{"started_at": time.Now()},
+	}, startEntryOpts).Decode(&e)
+	if err != nil {
+		return nil, err
+	}
+
+	return &e, nil
+}
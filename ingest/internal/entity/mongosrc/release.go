@@ -0,0 +1,111 @@
+package mongosrc
+
+import (
+	"context"
+
+	"github.com/repofuel/repofuel/ingest/internal/entity"
+	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const releasesCollection = "releases"
+
+type releaseDataSource struct {
+	collection *mongo.Collection
+}
+
+func NewReleaseDataSource(ctx context.Context, db *mongo.Database) *releaseDataSource {
+	c := db.Collection(releasesCollection)
+
+	_, err := c.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "repo", Value: 1}, {Key: "tag", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: ascReleaseIndex},
+	})
+	if err != nil {
+		log.Ctx(ctx).Fatal().Err(err).Msg("create indexes on releases collection")
+	}
+
+	return &releaseDataSource{
+		collection: c,
+	}
+}
+
+var ascReleaseIndex = bson.D{
+	{Key: "repo", Value: 1},
+	{Key: "date", Value: 1},
+	{Key: "_id", Value: 1},
+}
+
+var descReleaseIndex = bson.D{
+	{Key: "repo", Value: -1},
+	{Key: "date", Value: -1},
+	{Key: "_id", Value: -1},
+}
+
+func (db *releaseDataSource) RepositoryReleaseConnection(repoID identifier.RepositoryID, direction *entity.OrderDirection, pageCfg *entity.PaginationInput) entity.ReleaseConnection {
+	filter := bson.M{"repo": repoID}
+
+	orderCfg := &orderDirectionConfig{
+		Direction: getOrderDirection(direction, entity.OrderDirectionAsc),
+		DescIndex: descReleaseIndex,
+		AscIndex:  ascReleaseIndex,
+	}
+
+	return newReleaseConnection(db.collection, filter, pageCfg, orderCfg, releaseCursorParser(repoID))
+}
+
+func (db *releaseDataSource) FindByTag(ctx context.Context, repoID identifier.RepositoryID, tag string) (*entity.Release, error) {
+	var doc entity.Release
+	err := db.collection.FindOne(ctx, bson.M{
+		"repo": repoID,
+		"tag":  tag,
+	}).Decode(&doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &doc, nil
+}
+
+func (db *releaseDataSource) ReplaceRepoReleases(ctx context.Context, repoID identifier.RepositoryID, releases []*entity.Release) error {
+	tags := make([]string, len(releases))
+	models := make([]mongo.WriteModel, len(releases))
+	for i, r := range releases {
+		r.Repo = repoID
+		tags[i] = r.Tag
+		models[i] = mongo.NewUpdateOneModel().SetFilter(bson.M{
+			"repo": repoID,
+			"tag":  r.Tag,
+		}).SetUpdate(bson.M{
+			"$set": bson.M{
+				"commit": r.Commit,
+				"date":   r.Date,
+			},
+		}).SetUpsert(true)
+	}
+
+	_, err := db.collection.DeleteMany(ctx, bson.M{
+		"repo": repoID,
+		"tag":  bson.M{"$nin": tags},
+	})
+	if err != nil || len(models) == 0 {
+		return err
+	}
+
+	_, err = db.collection.BulkWrite(ctx, models)
+	return err
+}
+
+func (db *releaseDataSource) DeleteRepoReleases(ctx context.Context, repoID identifier.RepositoryID) error {
+	_, err := db.collection.DeleteMany(ctx, bson.M{
+		"repo": repoID,
+	})
+
+	return err
+}
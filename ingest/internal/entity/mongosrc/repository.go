@@ -11,8 +11,14 @@ import (
 
 	"github.com/repofuel/repofuel/accounts/pkg/permission"
 	"github.com/repofuel/repofuel/ingest/internal/entity"
+	"github.com/repofuel/repofuel/ingest/pkg/branchfilter"
+	"github.com/repofuel/repofuel/ingest/pkg/classify"
 	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+	"github.com/repofuel/repofuel/ingest/pkg/identity"
+	"github.com/repofuel/repofuel/ingest/pkg/repoconfig"
 	"github.com/repofuel/repofuel/ingest/pkg/status"
+	"github.com/repofuel/repofuel/ingest/pkg/subsystem"
+	"github.com/repofuel/repofuel/ingest/pkg/szz"
 	"github.com/repofuel/repofuel/pkg/common"
 	"github.com/repofuel/repofuel/pkg/repofuel"
 	"go.mongodb.org/mongo-driver/bson"
@@ -361,6 +367,20 @@ func (db *repositoryDataSource) SaveBuggyCount(ctx context.Context, id identifie
 		bson.M{"$set": bson.M{"buggy_count": count}})
 }
 
+// SaveFileConfig saves the config of the `.repofuel.yml` file, a nil config
+// removes it.
+func (db *repositoryDataSource) SaveFileConfig(ctx context.Context, id identifier.RepositoryID, cfg *repoconfig.Config) error {
+	if cfg == nil {
+		return db.updateOne(ctx,
+			bson.M{"_id": id},
+			bson.M{"$unset": bson.M{"file_config": ""}})
+	}
+
+	return db.updateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"file_config": cfg}})
+}
+
 var branchesOpts = options.FindOne().SetProjection(bson.M{"_id": 0, "branches": 1})
 
 func (db *repositoryDataSource) Branches(ctx context.Context, id identifier.RepositoryID) (map[string]identifier.Hash, error) {
@@ -478,6 +498,126 @@ func (db *repositoryDataSource) FindAndUpdateChecksConfig(ctx context.Context, i
 	return &doc, nil
 }
 
+func (db *repositoryDataSource) FindAndUpdateDeveloperAliases(ctx context.Context, id identifier.RepositoryID, aliases []*identity.Alias) (*entity.Repository, error) {
+	var doc entity.Repository
+	var opts = options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var filter = bson.M{
+		"_id": id,
+	}
+	var update = bson.M{
+		"$set": bson.M{
+			"developer_aliases": aliases,
+		},
+	}
+
+	err := db.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &doc, nil
+}
+
+func (db *repositoryDataSource) FindAndUpdateSubsystemConfig(ctx context.Context, id identifier.RepositoryID, cfg *subsystem.Config) (*entity.Repository, error) {
+	var doc entity.Repository
+	var opts = options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var filter = bson.M{
+		"_id": id,
+	}
+	var update = bson.M{
+		"$set": bson.M{
+			"subsystem_config": cfg,
+		},
+	}
+
+	err := db.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &doc, nil
+}
+
+func (db *repositoryDataSource) FindAndUpdatePathConfig(ctx context.Context, id identifier.RepositoryID, cfg *classify.PathConfig) (*entity.Repository, error) {
+	var doc entity.Repository
+	var opts = options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var filter = bson.M{
+		"_id": id,
+	}
+	var update = bson.M{
+		"$set": bson.M{
+			"path_config": cfg,
+		},
+	}
+
+	err := db.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &doc, nil
+}
+
+func (db *repositoryDataSource) FindAndUpdateBranchConfig(ctx context.Context, id identifier.RepositoryID, cfg *branchfilter.Config) (*entity.Repository, error) {
+	var doc entity.Repository
+	var opts = options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var filter = bson.M{
+		"_id": id,
+	}
+	var update = bson.M{
+		"$set": bson.M{
+			"branch_config": cfg,
+		},
+	}
+
+	err := db.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &doc, nil
+}
+
+func (db *repositoryDataSource) FindAndUpdateSZZConfig(ctx context.Context, id identifier.RepositoryID, cfg *szz.Config) (*entity.Repository, error) {
+	var doc entity.Repository
+	var opts = options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var filter = bson.M{
+		"_id": id,
+	}
+	var update = bson.M{
+		"$set": bson.M{
+			"szz_config": cfg,
+		},
+	}
+
+	err := db.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &doc, nil
+}
+
+func (db *repositoryDataSource) FindAndUpdateMetricsConfig(ctx context.Context, id identifier.RepositoryID, cfg *entity.MetricsConfig) (*entity.Repository, error) {
+	var doc entity.Repository
+	var opts = options.FindOneAndUpdate().SetReturnDocument(options.After)
+	var filter = bson.M{
+		"_id": id,
+	}
+	var update = bson.M{
+		"$set": bson.M{
+			"metrics_config": cfg,
+		},
+	}
+
+	err := db.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &doc, nil
+}
+
 type sharedAccountIter struct {
 	cur *mongo.Cursor
 }
@@ -0,0 +1,46 @@
+package entity
+
+import (
+	"context"
+	"time"
+
+	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ReleaseDataSource interface {
+	// RepositoryReleaseConnection returns the releases of the repository
+	// ordered by their dates.
+	RepositoryReleaseConnection(identifier.RepositoryID, *OrderDirection, *PaginationInput) ReleaseConnection
+	FindByTag(context.Context, identifier.RepositoryID, string) (*Release, error)
+	// ReplaceRepoReleases saves the releases of the repository, and deletes
+	// the releases that are not in the list.
+	ReplaceRepoReleases(context.Context, identifier.RepositoryID, []*Release) error
+	DeleteRepoReleases(context.Context, identifier.RepositoryID) error
+}
+
+// Release is a tag of the repository. Every commit belongs to the first
+// release that contains it, by the dates of the releases.
+type Release struct {
+	ID     primitive.ObjectID      `bson:"_id,omitempty"`
+	Repo   identifier.RepositoryID `bson:"repo"`
+	Tag    string                  `bson:"tag"`
+	Commit identifier.Hash         `bson:"commit"`
+	// Date is the date of the annotated tag, or the commit date if the tag
+	// is lightweight.
+	Date time.Time `bson:"date"`
+}
+
+func (r *Release) CommitID() *identifier.CommitID {
+	return identifier.NewCommitID(r.Repo, r.Commit)
+}
+
+// ReleaseStatistics summarize the commits that are first shipped in a release.
+type ReleaseStatistics struct {
+	CommitsCount int `bson:"commits_count"`
+	// BuggyCommitsCount is the number of the commits that induced bugs, which
+	// are fixed later.
+	BuggyCommitsCount int     `bson:"buggy_count"`
+	AvgRisk           float64 `bson:"avg_risk"`
+	MaxRisk           float64 `bson:"max_risk"`
+}
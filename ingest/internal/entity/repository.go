@@ -10,8 +10,14 @@ import (
 	"time"
 
 	"github.com/repofuel/repofuel/accounts/pkg/permission"
+	"github.com/repofuel/repofuel/ingest/pkg/branchfilter"
+	"github.com/repofuel/repofuel/ingest/pkg/classify"
 	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+	"github.com/repofuel/repofuel/ingest/pkg/identity"
+	"github.com/repofuel/repofuel/ingest/pkg/repoconfig"
 	"github.com/repofuel/repofuel/ingest/pkg/status"
+	"github.com/repofuel/repofuel/ingest/pkg/subsystem"
+	"github.com/repofuel/repofuel/ingest/pkg/szz"
 	"github.com/repofuel/repofuel/pkg/common"
 	"github.com/repofuel/repofuel/pkg/repofuel"
 )
@@ -57,6 +63,13 @@ type RepositoryDataSource interface {
 	//deeprecate
 	UpdateSource(context.Context, identifier.RepositoryID, *common.Repository) error
 	FindAndUpdateChecksConfig(context.Context, identifier.RepositoryID, *ChecksConfig) (*Repository, error)
+	FindAndUpdateDeveloperAliases(context.Context, identifier.RepositoryID, []*identity.Alias) (*Repository, error)
+	FindAndUpdateSubsystemConfig(context.Context, identifier.RepositoryID, *subsystem.Config) (*Repository, error)
+	FindAndUpdatePathConfig(context.Context, identifier.RepositoryID, *classify.PathConfig) (*Repository, error)
+	FindAndUpdateBranchConfig(context.Context, identifier.RepositoryID, *branchfilter.Config) (*Repository, error)
+	FindAndUpdateSZZConfig(context.Context, identifier.RepositoryID, *szz.Config) (*Repository, error)
+	FindAndUpdateMetricsConfig(context.Context, identifier.RepositoryID, *MetricsConfig) (*Repository, error)
+	SaveFileConfig(context.Context, identifier.RepositoryID, *repoconfig.Config) error
 	AddCollaborator(ctx context.Context, platform string, repo string, user string, p common.Permissions) error
 	DeleteCollaborator(ctx context.Context, platform string, repo string, user string) error
 
@@ -106,15 +119,50 @@ type Repository struct {
 	CommitsCount  int                           `json:"commits_count"             bson:"commits_count,omitempty"`
 	BuggyCount    int                           `json:"buggy_count"               bson:"buggy_count,omitempty"`
 	ChecksConfig  *ChecksConfig                 `json:"checks_config,omitempty"   bson:"checks_config,omitempty"`
+	SZZConfig     *szz.Config                   `json:"szz_config,omitempty"      bson:"szz_config,omitempty"`
+	MetricsConfig *MetricsConfig                `json:"metrics_config,omitempty"  bson:"metrics_config,omitempty"`
 	DataVersion   uint32                        `json:"version"                   bson:"version,omitempty"`
 	CreatedAt     time.Time                     `json:"created_at"                bson:"created_at,omitempty"`
 	UpdatedAt     time.Time                     `json:"updated_at"                bson:"updated_at,omitempty"`
+
+	// DeveloperAliases are the groups of emails and accounts that belong to
+	// the same developers.
+	DeveloperAliases []*identity.Alias `json:"developer_aliases,omitempty"  bson:"developer_aliases,omitempty"`
+	// BotConfig lists the bot accounts in addition to the detected ones.
+	BotConfig *identity.BotConfig `json:"bot_config,omitempty"  bson:"bot_config,omitempty"`
+	// SubsystemConfig maps the paths to the subsystems, changing it recomputes
+	// the subsystem metrics of the analyzed commits.
+	SubsystemConfig *subsystem.Config `json:"subsystem_config,omitempty"  bson:"subsystem_config,omitempty"`
+	// PathConfig classifies the paths before the heuristics, it applies to the
+	// commits that are analyzed after changing it.
+	PathConfig *classify.PathConfig `json:"path_config,omitempty"  bson:"path_config,omitempty"`
+	// BranchConfig selects the analyzed branches, the commits that are only
+	// in the other branches are pruned.
+	BranchConfig *branchfilter.Config `json:"branch_config,omitempty"  bson:"branch_config,omitempty"`
+	// FileConfig is the valid `.repofuel.yml` of the default branch, its
+	// sections override the stored configs.
+	FileConfig *repoconfig.Config `json:"file_config,omitempty"  bson:"file_config,omitempty"`
 }
 
 type ChecksConfig struct {
 	Enable bool `bson:"enable"`
 }
 
+// MetricsConfig is the configurations of calculating the change metrics of a
+// repository, a nil config keeps the default behaviour.
+type MetricsConfig struct {
+	// ExcludeNonCode calculates the size metrics from the code lines only, it
+	// ignores the blank and comment-only lines. They are not blamed by the
+	// plain SZZ as well.
+	ExcludeNonCode bool `json:"exclude_non_code"  bson:"exclude_non_code,omitempty"`
+	// AuthorOnly credits the author of the commit only in the experience and
+	// developer metrics, otherwise the committer and co-authors are credited.
+	AuthorOnly bool `json:"author_only"  bson:"author_only,omitempty"`
+	// SkipBots skips predicting the commits of the bots, they are analyzed
+	// and tagged but do not get risk scores.
+	SkipBots bool `json:"skip_bots"  bson:"skip_bots,omitempty"`
+}
+
 //deprecated
 func (r *Repository) IsNode() {}
 
@@ -130,9 +178,56 @@ func (r *Repository) Path() string {
 }
 
 func (r *Repository) IsChecksEnabled() bool {
+	if r.FileConfig != nil && r.FileConfig.Checks != nil && r.FileConfig.Checks.Enable != nil {
+		return *r.FileConfig.Checks.Enable
+	}
 	return r.ChecksConfig != nil && r.ChecksConfig.Enable
 }
 
+// RiskThresholds returns the thresholds of the check runs.
+func (r *Repository) RiskThresholds() repoconfig.Thresholds {
+	if r.FileConfig == nil {
+		return repoconfig.DefaultThresholds
+	}
+	return r.FileConfig.Checks.Thresholds()
+}
+
+// EffectiveBotConfig returns the bot config of the file if it has one,
+// otherwise the stored config.
+func (r *Repository) EffectiveBotConfig() *identity.BotConfig {
+	if r.FileConfig != nil && r.FileConfig.Bots != nil {
+		return r.FileConfig.Bots
+	}
+	return r.BotConfig
+}
+
+// EffectiveSubsystemConfig returns the subsystem config of the file if it has
+// one, otherwise the stored config.
+func (r *Repository) EffectiveSubsystemConfig() *subsystem.Config {
+	if r.FileConfig != nil && r.FileConfig.Subsystems != nil {
+		return r.FileConfig.Subsystems
+	}
+	return r.SubsystemConfig
+}
+
+// EffectivePathConfig returns the path config of the file if it has one,
+// otherwise the stored config.
+func (r *Repository) EffectivePathConfig() *classify.PathConfig {
+	if r.FileConfig != nil && r.FileConfig.Paths != nil {
+		return r.FileConfig.Paths
+	}
+	return r.PathConfig
+}
+
+// EffectiveBranchConfig returns the branch config of the file if it has one,
+// otherwise the stored config.
+func (r *Repository) EffectiveBranchConfig() *branchfilter.Config {
+	if r.FileConfig != nil && r.FileConfig.Branches != nil {
+		return r.FileConfig.Branches
+	}
+	return r.BranchConfig
+}
+
 func pathFromID(provider string, repoID identifier.RepositoryID) string {
 	idStr := repoID.Hex()
 	return path.Join(RepositoryLocation, provider, idStr[:4], idStr)
@@ -10,9 +10,14 @@ import (
 	common "github.com/repofuel/repofuel/pkg/common"
 	repofuel "github.com/repofuel/repofuel/pkg/repofuel"
 	entity "github.com/repofuel/repofuel/ingest/internal/entity"
+	branchfilter "github.com/repofuel/repofuel/ingest/pkg/branchfilter"
 	classify "github.com/repofuel/repofuel/ingest/pkg/classify"
 	identifier "github.com/repofuel/repofuel/ingest/pkg/identifier"
+	identity "github.com/repofuel/repofuel/ingest/pkg/identity"
+	repoconfig "github.com/repofuel/repofuel/ingest/pkg/repoconfig"
 	status "github.com/repofuel/repofuel/ingest/pkg/status"
+	subsystem "github.com/repofuel/repofuel/ingest/pkg/subsystem"
+	szz "github.com/repofuel/repofuel/ingest/pkg/szz"
 	options "go.mongodb.org/mongo-driver/mongo/options"
 	reflect "reflect"
 	time "time"
@@ -264,6 +269,21 @@ func (mr *MockCommitDataSourceMockRecorder) DevelopersAggregatedMetrics(arg0, ar
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DevelopersAggregatedMetrics", reflect.TypeOf((*MockCommitDataSource)(nil).DevelopersAggregatedMetrics), arg0, arg1)
 }
 
+// DroppedBugsCount mocks base method
+func (m *MockCommitDataSource) DroppedBugsCount(arg0 context.Context, arg1 identifier.RepositoryID) ([]*entity.DropReasonCount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DroppedBugsCount", arg0, arg1)
+	ret0, _ := ret[0].([]*entity.DropReasonCount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DroppedBugsCount indicates an expected call of DroppedBugsCount
+func (mr *MockCommitDataSourceMockRecorder) DroppedBugsCount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DroppedBugsCount", reflect.TypeOf((*MockCommitDataSource)(nil).DroppedBugsCount), arg0, arg1)
+}
+
 // FileAggregatedMetrics mocks base method
 func (m *MockCommitDataSource) FileAggregatedMetrics(arg0 context.Context, arg1 identifier.RepositoryID) (entity.FileMeasuresIter, error) {
 	m.ctrl.T.Helper()
@@ -400,11 +420,12 @@ func (mr *MockCommitDataSourceMockRecorder) FindRepoCommits(arg0, arg1 interface
 }
 
 // InsertOrReplace mocks base method
-func (m *MockCommitDataSource) InsertOrReplace(arg0 context.Context, arg1 *entity.Commit) error {
+func (m *MockCommitDataSource) InsertOrReplace(arg0 context.Context, arg1 *entity.Commit) (bool, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "InsertOrReplace", arg0, arg1)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // InsertOrReplace indicates an expected call of InsertOrReplace
@@ -514,6 +535,21 @@ func (mr *MockCommitDataSourceMockRecorder) ReTagPullRequest(arg0, arg1, arg2, a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReTagPullRequest", reflect.TypeOf((*MockCommitDataSource)(nil).ReTagPullRequest), arg0, arg1, arg2, arg3)
 }
 
+// ReleaseStatistics mocks base method
+func (m *MockCommitDataSource) ReleaseStatistics(arg0 context.Context, arg1 identifier.RepositoryID, arg2 string) (*entity.ReleaseStatistics, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReleaseStatistics", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*entity.ReleaseStatistics)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReleaseStatistics indicates an expected call of ReleaseStatistics
+func (mr *MockCommitDataSourceMockRecorder) ReleaseStatistics(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReleaseStatistics", reflect.TypeOf((*MockCommitDataSource)(nil).ReleaseStatistics), arg0, arg1, arg2)
+}
+
 // RemoveBranch mocks base method
 func (m *MockCommitDataSource) RemoveBranch(arg0 context.Context, arg1 identifier.RepositoryID, arg2 string) error {
 	m.ctrl.T.Helper()
@@ -592,6 +628,39 @@ func (mr *MockCommitDataSourceMockRecorder) SaveCommitAnalysis(arg0 interface{},
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveCommitAnalysis", reflect.TypeOf((*MockCommitDataSource)(nil).SaveCommitAnalysis), varargs...)
 }
 
+// SaveReleases mocks base method
+func (m *MockCommitDataSource) SaveReleases(arg0 context.Context, arg1 identifier.RepositoryID, arg2 map[identifier.Hash]string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveReleases", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveReleases indicates an expected call of SaveReleases
+func (mr *MockCommitDataSourceMockRecorder) SaveReleases(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveReleases", reflect.TypeOf((*MockCommitDataSource)(nil).SaveReleases), arg0, arg1, arg2)
+}
+
+// SaveSubsystems mocks base method
+func (m *MockCommitDataSource) SaveSubsystems(arg0 context.Context, arg1 ...*entity.CommitSubsystemsHolder) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "SaveSubsystems", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveSubsystems indicates an expected call of SaveSubsystems
+func (mr *MockCommitDataSourceMockRecorder) SaveSubsystems(arg0 interface{}, arg1 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveSubsystems", reflect.TypeOf((*MockCommitDataSource)(nil).SaveSubsystems), varargs...)
+}
+
 // SelectedCommitConnection mocks base method
 func (m *MockCommitDataSource) SelectedCommitConnection(arg0 context.Context, arg1 identifier.RepositoryID, arg2 []identifier.Hash, arg3 *entity.OrderDirection, arg4 *entity.PaginationInput) (entity.CommitConnection, error) {
 	m.ctrl.T.Helper()
@@ -717,6 +786,21 @@ func (mr *MockRepositoryDataSourceMockRecorder) FindAllReposConnection(arg0, arg
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllReposConnection", reflect.TypeOf((*MockRepositoryDataSource)(nil).FindAllReposConnection), arg0, arg1, arg2)
 }
 
+// FindAndUpdateBranchConfig mocks base method
+func (m *MockRepositoryDataSource) FindAndUpdateBranchConfig(arg0 context.Context, arg1 identifier.RepositoryID, arg2 *branchfilter.Config) (*entity.Repository, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAndUpdateBranchConfig", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*entity.Repository)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAndUpdateBranchConfig indicates an expected call of FindAndUpdateBranchConfig
+func (mr *MockRepositoryDataSourceMockRecorder) FindAndUpdateBranchConfig(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAndUpdateBranchConfig", reflect.TypeOf((*MockRepositoryDataSource)(nil).FindAndUpdateBranchConfig), arg0, arg1, arg2)
+}
+
 // FindAndUpdateChecksConfig mocks base method
 func (m *MockRepositoryDataSource) FindAndUpdateChecksConfig(arg0 context.Context, arg1 identifier.RepositoryID, arg2 *entity.ChecksConfig) (*entity.Repository, error) {
 	m.ctrl.T.Helper()
@@ -732,6 +816,81 @@ func (mr *MockRepositoryDataSourceMockRecorder) FindAndUpdateChecksConfig(arg0,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAndUpdateChecksConfig", reflect.TypeOf((*MockRepositoryDataSource)(nil).FindAndUpdateChecksConfig), arg0, arg1, arg2)
 }
 
+// FindAndUpdateDeveloperAliases mocks base method
+func (m *MockRepositoryDataSource) FindAndUpdateDeveloperAliases(arg0 context.Context, arg1 identifier.RepositoryID, arg2 []*identity.Alias) (*entity.Repository, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAndUpdateDeveloperAliases", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*entity.Repository)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAndUpdateDeveloperAliases indicates an expected call of FindAndUpdateDeveloperAliases
+func (mr *MockRepositoryDataSourceMockRecorder) FindAndUpdateDeveloperAliases(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAndUpdateDeveloperAliases", reflect.TypeOf((*MockRepositoryDataSource)(nil).FindAndUpdateDeveloperAliases), arg0, arg1, arg2)
+}
+
+// FindAndUpdateMetricsConfig mocks base method
+func (m *MockRepositoryDataSource) FindAndUpdateMetricsConfig(arg0 context.Context, arg1 identifier.RepositoryID, arg2 *entity.MetricsConfig) (*entity.Repository, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAndUpdateMetricsConfig", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*entity.Repository)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAndUpdateMetricsConfig indicates an expected call of FindAndUpdateMetricsConfig
+func (mr *MockRepositoryDataSourceMockRecorder) FindAndUpdateMetricsConfig(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAndUpdateMetricsConfig", reflect.TypeOf((*MockRepositoryDataSource)(nil).FindAndUpdateMetricsConfig), arg0, arg1, arg2)
+}
+
+// FindAndUpdatePathConfig mocks base method
+func (m *MockRepositoryDataSource) FindAndUpdatePathConfig(arg0 context.Context, arg1 identifier.RepositoryID, arg2 *classify.PathConfig) (*entity.Repository, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAndUpdatePathConfig", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*entity.Repository)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAndUpdatePathConfig indicates an expected call of FindAndUpdatePathConfig
+func (mr *MockRepositoryDataSourceMockRecorder) FindAndUpdatePathConfig(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAndUpdatePathConfig", reflect.TypeOf((*MockRepositoryDataSource)(nil).FindAndUpdatePathConfig), arg0, arg1, arg2)
+}
+
+// FindAndUpdateSZZConfig mocks base method
+func (m *MockRepositoryDataSource) FindAndUpdateSZZConfig(arg0 context.Context, arg1 identifier.RepositoryID, arg2 *szz.Config) (*entity.Repository, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAndUpdateSZZConfig", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*entity.Repository)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAndUpdateSZZConfig indicates an expected call of FindAndUpdateSZZConfig
+func (mr *MockRepositoryDataSourceMockRecorder) FindAndUpdateSZZConfig(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAndUpdateSZZConfig", reflect.TypeOf((*MockRepositoryDataSource)(nil).FindAndUpdateSZZConfig), arg0, arg1, arg2)
+}
+
+// FindAndUpdateSubsystemConfig mocks base method
+func (m *MockRepositoryDataSource) FindAndUpdateSubsystemConfig(arg0 context.Context, arg1 identifier.RepositoryID, arg2 *subsystem.Config) (*entity.Repository, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAndUpdateSubsystemConfig", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*entity.Repository)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAndUpdateSubsystemConfig indicates an expected call of FindAndUpdateSubsystemConfig
+func (mr *MockRepositoryDataSourceMockRecorder) FindAndUpdateSubsystemConfig(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAndUpdateSubsystemConfig", reflect.TypeOf((*MockRepositoryDataSource)(nil).FindAndUpdateSubsystemConfig), arg0, arg1, arg2)
+}
+
 // FindByCollaborator mocks base method
 func (m *MockRepositoryDataSource) FindByCollaborator(arg0 context.Context, arg1 map[string]string) (entity.RepositoryIter, error) {
 	m.ctrl.T.Helper()
@@ -972,6 +1131,20 @@ func (mr *MockRepositoryDataSourceMockRecorder) SaveConfidence(arg0, arg1, arg2
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveConfidence", reflect.TypeOf((*MockRepositoryDataSource)(nil).SaveConfidence), arg0, arg1, arg2)
 }
 
+// SaveFileConfig mocks base method
+func (m *MockRepositoryDataSource) SaveFileConfig(arg0 context.Context, arg1 identifier.RepositoryID, arg2 *repoconfig.Config) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveFileConfig", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveFileConfig indicates an expected call of SaveFileConfig
+func (mr *MockRepositoryDataSourceMockRecorder) SaveFileConfig(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveFileConfig", reflect.TypeOf((*MockRepositoryDataSource)(nil).SaveFileConfig), arg0, arg1, arg2)
+}
+
 // SaveQuality mocks base method
 func (m *MockRepositoryDataSource) SaveQuality(arg0 context.Context, arg1 identifier.RepositoryID, arg2 repofuel.PredictionStatus) error {
 	m.ctrl.T.Helper()
@@ -648,7 +648,15 @@ func structValues(m interface{}) []string {
 	n := val.NumField()
 	r := make([]string, n)
 	for i := 0; i < n; i++ {
-		r[i] = fmt.Sprint(val.Field(i).Interface())
+		field := val.Field(i)
+		if field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				// the missing values are empty
+				continue
+			}
+			field = field.Elem()
+		}
+		r[i] = fmt.Sprint(field.Interface())
 	}
 
 	return r
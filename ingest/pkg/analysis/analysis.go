@@ -9,13 +9,20 @@ import (
 	"errors"
 	"math"
 	"path"
+	"sync"
+	"time"
 
 	"github.com/go-enry/go-enry/v2"
 	"github.com/repofuel/repofuel/ingest/internal/entity"
 	"github.com/repofuel/repofuel/ingest/pkg/classify"
 	"github.com/repofuel/repofuel/ingest/pkg/commitmsg"
+	"github.com/repofuel/repofuel/ingest/pkg/complexity"
 	"github.com/repofuel/repofuel/ingest/pkg/engine"
 	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+	"github.com/repofuel/repofuel/ingest/pkg/identity"
+	"github.com/repofuel/repofuel/ingest/pkg/subsystem"
+	"github.com/repofuel/repofuel/ingest/pkg/szz"
+	"github.com/repofuel/repofuel/pkg/common"
 	"github.com/repofuel/repofuel/pkg/metrics"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -24,12 +31,8 @@ import (
 var (
 	ErrFixWithNoParents      = errors.New("fix commit does not have any parent")
 	ErrNoFilesForCalculation = errors.New("commit doesn't have modified source code for metrics calculation")
-)
 
-const (
-	szzMaxHD = 100
-	szzMaxLD = 5000
-	szzMaxNF = 50
+	errNoLastChange = errors.New("cannot find last change")
 )
 
 type RepositoryAnalysis struct {
@@ -37,8 +40,36 @@ type RepositoryAnalysis struct {
 	job     identifier.JobID
 	tracker ProgressTracker
 	logger  *zerolog.Logger
+	szzCfg  *szz.Config
+	szz     szz.Strategy
+
+	metricsCfg  *entity.MetricsConfig
+	subsystems  *subsystem.Mapper
+	paths       *classify.PathClassifier
+	history     *historyCache
+	parallelism int
+	checkpoint  Checkpoint
+	progressMu  sync.Mutex
+
+	commitsDB  entity.CommitDataSource
+	bugLinksDB entity.BugLinkDataSource
+}
 
-	commitsDB entity.CommitDataSource
+// Options are the repository specific configurations of the analysis.
+type Options struct {
+	SZZ     *szz.Config
+	Metrics *entity.MetricsConfig
+	// Subsystems maps the paths of the code files to their subsystems, the
+	// first directory of the paths is taken if it is nil.
+	Subsystems *subsystem.Mapper
+	// Paths classifies the paths before the heuristics.
+	Paths *classify.PathClassifier
+	// Parallelism is the number of commits that are analyzed concurrently, the
+	// commits are analyzed one by one if it is less than two.
+	Parallelism int
+	// Checkpoint records the analyzed commits to resume the analysis if it is
+	// interrupted, it is optional.
+	Checkpoint Checkpoint
 }
 
 func (a *RepositoryAnalysis) Finish(context.Context) error {
@@ -49,25 +80,77 @@ type ProgressTracker interface {
 	IncreaseProgress(int)
 }
 
-func NewRepositoryAnalysis(repo *engine.Repository, job identifier.JobID, commitsDB entity.CommitDataSource, tracker ProgressTracker) *RepositoryAnalysis {
-	return &RepositoryAnalysis{
-		repo:      repo,
-		job:       job,
-		commitsDB: commitsDB,
-		tracker:   tracker,
+// Checkpoint keeps the analyzed commits of an interrupted analysis. The
+// commits that are analyzed before the interruption are skipped, but their
+// histories are carried to their children from their stored files.
+type Checkpoint interface {
+	// Has reports whether the commit is analyzed before the interruption.
+	Has(identifier.Hash) bool
+	// Add records the analyzed commit, it is called concurrently if the
+	// commits are analyzed in parallel.
+	Add(context.Context, identifier.Hash) error
+}
+
+func NewRepositoryAnalysis(repo *engine.Repository, job identifier.JobID, commitsDB entity.CommitDataSource, bugLinksDB entity.BugLinkDataSource, tracker ProgressTracker, opts *Options) (*RepositoryAnalysis, error) {
+	// the non-code lines are excluded from both of the metrics and the SZZ
+	strategy, err := szz.New(opts.SZZ, opts.Metrics != nil && opts.Metrics.ExcludeNonCode)
+	if err != nil {
+		return nil, err
 	}
+
+	return &RepositoryAnalysis{
+		repo:        repo,
+		job:         job,
+		commitsDB:   commitsDB,
+		bugLinksDB:  bugLinksDB,
+		tracker:     tracker,
+		szzCfg:      opts.SZZ,
+		szz:         strategy,
+		metricsCfg:  opts.Metrics,
+		subsystems:  opts.Subsystems,
+		paths:       opts.Paths,
+		parallelism: opts.Parallelism,
+		checkpoint:  opts.Checkpoint,
+	}, nil
 }
 
 func (a *RepositoryAnalysis) Run(ctx context.Context, roots engine.CommitSet) error {
+	start := roots.Slice()
 	a.logger = log.Ctx(ctx)
+	a.history = newHistoryCache(start, a.metricsCfg != nil && a.metricsCfg.AuthorOnly)
 
-	return engine.RunForwardAnalysis(ctx, a, roots.Slice())
+	return engine.RunParallelForwardAnalysis(ctx, a, start, a.parallelism)
 }
 
 func (a *RepositoryAnalysis) AnalyzeCommit(ctx context.Context, c engine.Commit) error {
+	if a.checkpoint != nil && a.checkpoint.Has(c.Hash()) {
+		a.progressMu.Lock()
+		a.tracker.IncreaseProgress(1)
+		a.progressMu.Unlock()
+
+		return a.history.Add(ctx, c)
+	}
+
+	err := a.analyzeCommit(ctx, c)
+	if err != nil {
+		return err
+	}
+
+	// the history is carried to the children after the files are analyzed
+	err = a.history.Add(ctx, c)
+	if err != nil || a.checkpoint == nil {
+		return err
+	}
+
+	return a.checkpoint.Add(ctx, c.Hash())
+}
+
+func (a *RepositoryAnalysis) analyzeCommit(ctx context.Context, c engine.Commit) error {
 	a.logger.Debug().Hex("commit", c.Hash().Bytes()).Msg("analyze commit")
 
+	a.progressMu.Lock()
 	a.tracker.IncreaseProgress(1)
+	a.progressMu.Unlock()
 
 	obj, err := c.Object()
 	if err != nil {
@@ -80,11 +163,18 @@ func (a *RepositoryAnalysis) AnalyzeCommit(ctx context.Context, c engine.Commit)
 	var ec entity.Commit
 	ec.ID = identifier.NewCommitID(a.repo.ID, obj.Hash())
 	ec.Author = obj.Author()
+	if committer := obj.Committer(); committer.Email != ec.Author.Email {
+		ec.Committer = committer
+	}
+	ec.CoAuthors = commitmsg.CoAuthors(message)
+
+	name, email := a.repo.ResolveIdentity(ec.Author.Name, ec.Author.Email)
+	ec.Identity = &identity.Identity{Name: name, Email: email}
 	ec.Message = entity.LimitedMessage(message)
 	ec.Job = a.job
 	ec.Branches = c.Branches().Slice()
 
-	analyzedFiles, err := analyzeFiles(ctx, obj)
+	analyzedFiles, err := analyzeFiles(ctx, obj, a.paths)
 	if err != nil {
 		a.logger.Err(err).
 			Hex("commit", ec.ID.CommitHash[:]).
@@ -92,7 +182,19 @@ func (a *RepositoryAnalysis) AnalyzeCommit(ctx context.Context, c engine.Commit)
 		return a.storeCommit(ctx, &ec, analyzedFiles)
 	}
 
-	ec.Tags = classify.FindCategories(message).Slice()
+	for _, fa := range analyzedFiles {
+		if fa.Type == classify.FileCode {
+			fa.Subsystem = a.subsystems.Subsystem(fa.Path)
+		}
+	}
+
+	setLineMeasures(analyzedFiles, a.metricsCfg != nil && a.metricsCfg.ExcludeNonCode)
+
+	tags := classify.FindCategories(message)
+	if c.Bot() {
+		tags.Add(classify.Bot)
+	}
+	ec.Tags = tags.Slice()
 
 	c.SetFiles(codeFilesList(analyzedFiles))
 
@@ -102,7 +204,15 @@ func (a *RepositoryAnalysis) AnalyzeCommit(ctx context.Context, c engine.Commit)
 		return a.storeCommit(ctx, &ec, analyzedFiles)
 	}
 
-	m, err := calculateMetrics(ctx, c, analyzedFiles)
+	var history *historyState
+	if c.HasParent() {
+		history, err = a.history.State(ctx, c.FirstParent())
+		if err != nil {
+			return err
+		}
+	}
+
+	m, err := calculateMetrics(ctx, c, analyzedFiles, history, a.metricsCfg != nil && a.metricsCfg.AuthorOnly)
 	if err != nil {
 		if err != ErrNoFilesForCalculation {
 			a.logger.Err(err).
@@ -112,6 +222,8 @@ func (a *RepositoryAnalysis) AnalyzeCommit(ctx context.Context, c engine.Commit)
 		return a.storeCommit(ctx, &ec, analyzedFiles)
 	}
 	ec.Metrics = m
+	ec.RawLines, ec.CodeLines = sumLineMeasures(analyzedFiles)
+	setComplexityMeasures(m, analyzedFiles)
 
 	issues, includeBug, err := a.repo.IssuesFromText(ctx, message)
 	if err != nil {
@@ -123,12 +235,16 @@ func (a *RepositoryAnalysis) AnalyzeCommit(ctx context.Context, c engine.Commit)
 	ec.Issues = issues
 
 	// ignore big commits
-	if (includeBug || commitmsg.IsCorrective(message)) &&
-		m.LD < szzMaxLD &&
-		m.HD < szzMaxHD &&
-		m.NF < szzMaxNF {
-
+	if (includeBug || commitmsg.IsCorrective(message)) && a.szzCfg.IsTraceable(m) {
 		ec.Fix = true
+		ec.SZZ = a.szz.Variant()
+
+		// the links of an earlier analysis of the fix are replaced
+		err = a.bugLinksDB.DeleteFixLinks(ctx, a.repo.ID, c.Hash())
+		if err != nil {
+			return err
+		}
+
 		bugs, err := a.traceDeletedChunks(ctx, c, analyzedFiles)
 		if err != nil {
 			a.logger.Err(err).
@@ -137,10 +253,19 @@ func (a *RepositoryAnalysis) AnalyzeCommit(ctx context.Context, c engine.Commit)
 			return a.storeCommit(ctx, &ec, analyzedFiles)
 		}
 
+		if a.szzCfg != nil && a.szzCfg.IssueDateFilter {
+			ec.DroppedBugs = a.filterByIssueDate(issues, bugs, analyzedFiles)
+		}
+
 		err = a.commitsDB.MarkBuggy(ctx, a.repo.ID, c.Hash(), bugs)
 		if err != nil {
 			return err
 		}
+
+		err = a.storeBugLinks(ctx, c.Hash(), bugs, analyzedFiles)
+		if err != nil {
+			return err
+		}
 	}
 
 	return a.storeCommit(ctx, &ec, analyzedFiles)
@@ -173,11 +298,46 @@ func (a *RepositoryAnalysis) storeCommit(ctx context.Context, ec *entity.Commit,
 			Metrics:       &fa.FileMeasures,
 			SameDeveloper: fa.SameDeveloper,
 		}
+
+		if fa.Type == classify.FileCode {
+			files[i].RawLines = &fa.RawLines
+			files[i].CodeLines = &fa.CodeLines
+			files[i].FunctionChanges = fa.functionChanges()
+			files[i].Complexity = &fa.complexity
+		}
 	}
 
 	ec.Files = files
 
-	return a.commitsDB.InsertOrReplace(ctx, ec)
+	replaced, err := a.commitsDB.InsertOrReplace(ctx, ec)
+	if err != nil || !replaced || ec.Fix {
+		return err
+	}
+
+	// the links of an earlier analysis are stale if the commit is not detected
+	// as a fix anymore
+	return a.bugLinksDB.DeleteFixLinks(ctx, a.repo.ID, ec.ID.CommitHash)
+}
+
+// readContents reads the contents of the file before and after the commit, they
+// are read for the fixes only so the contents of the analyzed commits are not
+// kept in the memory.
+func (a *RepositoryAnalysis) readContents(c engine.Commit, f *fileAnalysis) (oldContent, newContent []byte, err error) {
+	if f.Action != engine.DeltaAdded {
+		oldContent, err = a.repo.ReadFile(c.FirstParent().Hash(), f.OldOrNewPath())
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if f.Action != engine.DeltaDeleted {
+		newContent, err = a.repo.ReadFile(c.Hash(), f.Path)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return oldContent, newContent, nil
 }
 
 func (a *RepositoryAnalysis) traceDeletedChunks(ctx context.Context, c engine.Commit, analyzedFiles []*fileAnalysis) (identifier.HashSet, error) {
@@ -187,17 +347,55 @@ func (a *RepositoryAnalysis) traceDeletedChunks(ctx context.Context, c engine.Co
 		return nil, ErrFixWithNoParents
 	}
 
-	buggies := identifier.NewHashSet()
+	var fixFiles []*fileAnalysis
+	var szzFiles []*szz.File
 	for _, f := range analyzedFiles {
-		if len(f.DeletedChunks) == 0 {
+		if len(f.Hunks) == 0 {
 			continue
 		}
 
-		hashes, err := a.repo.InducingCommits(ctx, c.FirstParent().Hash(), f.OldOrNewPath(), f.DeletedChunks...)
+		file := &szz.File{
+			Language: f.Language,
+			Hunks:    f.Hunks,
+		}
+		if f.Type == classify.FileCode && a.szz.NeedsContent() {
+			var err error
+			file.OldContent, file.NewContent, err = a.readContents(c, f)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		fixFiles = append(fixFiles, f)
+		szzFiles = append(szzFiles, file)
+	}
+
+	deletedChunks := a.szz.DeletedChunks(szzFiles)
+
+	buggies := identifier.NewHashSet()
+	for i, f := range fixFiles {
+		if len(deletedChunks[i]) == 0 {
+			continue
+		}
+
+		sets, err := a.repo.InducingCommitsByChunk(ctx, c.FirstParent().Hash(), f.OldOrNewPath(), deletedChunks[i]...)
 		if err != nil {
 			return nil, err
 		}
 
+		hashes := identifier.NewHashSet()
+		for j, s := range sets {
+			chunk := deletedChunks[i][j]
+			hashes.Update(s)
+			blame := &chunkBlame{
+				deleted:  chunk,
+				inducing: s,
+			}
+			blame.functions = f.blamedFunctions(chunk)
+			f.blames = append(f.blames, blame)
+			f.traceFunctions(chunk, s)
+		}
+
 		buggies.Update(hashes)
 		f.Fixing = hashes.Slice()
 		f.Fix = len(hashes) > 0
@@ -205,6 +403,78 @@ func (a *RepositoryAnalysis) traceDeletedChunks(ctx context.Context, c engine.Co
 	return buggies, nil
 }
 
+// storeBugLinks links the blamed chunks of the fix to the bug-inducing commits,
+// the earlier links of the fix are deleted before the commit is analyzed. The
+// dropped candidates are not linked.
+func (a *RepositoryAnalysis) storeBugLinks(ctx context.Context, fix identifier.Hash, bugs identifier.HashSet, analyzedFiles []*fileAnalysis) error {
+	var links []*entity.BugLink
+	for _, f := range analyzedFiles {
+		for _, b := range f.blames {
+			var inducing []identifier.Hash
+			for h := range b.inducing {
+				if bugs.Has(h) {
+					inducing = append(inducing, h)
+				}
+			}
+
+			for _, h := range inducing {
+				links = append(links, &entity.BugLink{
+					Repo:       a.repo.ID,
+					Fix:        fix,
+					Inducing:   h,
+					Path:       f.OldOrNewPath(),
+					Deleted:    b.deleted,
+					Functions:  b.functions,
+					SZZ:        a.szz.Variant(),
+					Confidence: 1 / float64(len(inducing)),
+				})
+			}
+		}
+	}
+
+	return a.bugLinksDB.InsertMany(ctx, links)
+}
+
+// filterByIssueDate drops the bug-inducing candidates that are authored after
+// the issues of the fix were reported, and removes them from the fixed files.
+func (a *RepositoryAnalysis) filterByIssueDate(issues []common.Issue, bugs identifier.HashSet, analyzedFiles []*fileAnalysis) []*szz.DroppedCandidate {
+	dropped := szz.FilterByIssueDate(bugs, szz.IssueReportDate(issues), func(h identifier.Hash) (time.Time, bool) {
+		c, ok := a.repo.Commit(h)
+		if !ok {
+			return time.Time{}, false
+		}
+		return c.AuthorDate(), true
+	})
+	if len(dropped) == 0 {
+		return nil
+	}
+
+	for _, f := range analyzedFiles {
+		if len(f.Fixing) == 0 {
+			continue
+		}
+
+		fixing := f.Fixing[:0]
+		for _, h := range f.Fixing {
+			if bugs.Has(h) {
+				fixing = append(fixing, h)
+			}
+		}
+		f.Fixing = fixing
+		f.Fix = len(fixing) > 0
+
+		for _, fn := range f.functions {
+			for h := range fn.fixing {
+				if !bugs.Has(h) {
+					fn.fixing.Delete(h)
+				}
+			}
+		}
+	}
+
+	return dropped
+}
+
 type fileAnalysis struct {
 	*engine.FileInfo
 	metrics.FileMeasures
@@ -212,9 +482,44 @@ type fileAnalysis struct {
 	SameDeveloper bool
 	Language      string
 	Type          classify.FileType
-	DeletedChunks []engine.ChunkAddr
+	Hunks         []szz.Hunk
 	Fixing        []identifier.Hash
 	Developers    engine.DeveloperSet
+	RawLines      metrics.LineMeasures
+	CodeLines     metrics.LineMeasures
+
+	complexity metrics.ComplexityMeasures
+
+	// the line types are used to count the code lines of the hunks
+	oldLines []classify.LineType
+	newLines []classify.LineType
+
+	// the functions of the contents are used to find the changed functions
+	oldFunctions classify.Functions
+	newFunctions classify.Functions
+	functions    []*functionAnalysis
+
+	// blames are the blamed chunks of the fix with their inducing commits
+	blames []*chunkBlame
+}
+
+type chunkBlame struct {
+	deleted   engine.ChunkAddr
+	functions []string
+	inducing  identifier.HashSet
+}
+
+// functionAnalysis is a changed function of a file, the changed lines are
+// counted in the innermost functions that enclose them.
+type functionAnalysis struct {
+	metrics.FunctionMeasures
+
+	function   classify.Function
+	deleted    bool
+	fixing     identifier.HashSet
+	developers engine.DeveloperSet
+	rawLines   metrics.LineMeasures
+	codeLines  metrics.LineMeasures
 }
 
 func (f *fileAnalysis) OldOrNewPath() string {
@@ -224,11 +529,11 @@ func (f *fileAnalysis) OldOrNewPath() string {
 	return f.OldPath
 }
 
-func analyzeFiles(ctx context.Context, obj engine.CommitObject) ([]*fileAnalysis, error) {
+func analyzeFiles(ctx context.Context, obj engine.CommitObject, paths *classify.PathClassifier) ([]*fileAnalysis, error) {
 	var analyzedFiles []*fileAnalysis //todo: we can know the size from the diff before iterate over
 
 	err := obj.DiffHunks(ctx, func(delta engine.DiffDelta) (engine.HunkAnalysis, error) {
-		f, err := analyzeFile(delta)
+		f, err := analyzeFile(delta, paths)
 		if err != nil {
 			return nil, err
 		}
@@ -244,7 +549,7 @@ func analyzeFiles(ctx context.Context, obj engine.CommitObject) ([]*fileAnalysis
 	return analyzedFiles, nil
 }
 
-func analyzeFile(f engine.DiffDelta) (*fileAnalysis, error) {
+func analyzeFile(f engine.DiffDelta, paths *classify.PathClassifier) (*fileAnalysis, error) {
 	fa := &fileAnalysis{
 		FileInfo:   new(engine.FileInfo),
 		Developers: engine.NewDeveloperSet(),
@@ -252,7 +557,7 @@ func analyzeFile(f engine.DiffDelta) (*fileAnalysis, error) {
 
 	fa.Action = f.Action()
 	switch fa.Action {
-	case engine.DeltaRenamed:
+	case engine.DeltaRenamed, engine.DeltaCopied:
 		fa.Path = f.ToPath()
 		fa.OldPath = f.FromPath()
 	case engine.DeltaDeleted:
@@ -261,7 +566,10 @@ func analyzeFile(f engine.DiffDelta) (*fileAnalysis, error) {
 		fa.Path = f.ToPath()
 	}
 
+	fa.Type = paths.Classify(fa.Path)
 	switch {
+	case fa.Type != 0:
+		// the paths of the repository config override the heuristics
 	case f.IsSymlink():
 		fa.Type = classify.FileSymlink
 	case f.IsBinary():
@@ -303,13 +611,43 @@ func analyzeFile(f engine.DiffDelta) (*fileAnalysis, error) {
 	lang := enry.GetLanguage(fa.Path, content)
 	if enry.GetLanguageType(lang) == enry.Programming {
 		fa.Type = classify.FileCode
-		fa.Subsystem = engine.SubsystemFromPath(fa.Path)
 		fa.Language = lang
+
+		var oldContent, newContent []byte
+		if fa.Action == engine.DeltaDeleted {
+			oldContent = content
+		} else {
+			newContent = content
+			oldContent, err = f.OldContent()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		fa.oldLines = classify.ClassifyLines(lang, oldContent)
+		fa.newLines = classify.ClassifyLines(lang, newContent)
+		fa.oldFunctions = classify.FindFunctions(lang, oldContent)
+		fa.newFunctions = classify.FindFunctions(lang, newContent)
+		fa.complexity = measureComplexity(lang, oldContent, newContent)
 	}
 
 	return fa, nil
 }
 
+// measureComplexity measures the new content, and the change of its cyclomatic
+// complexity from the old content.
+func measureComplexity(lang string, oldContent, newContent []byte) metrics.ComplexityMeasures {
+	m := complexity.Measure(lang, newContent)
+	old := complexity.Measure(lang, oldContent)
+
+	return metrics.ComplexityMeasures{
+		CC:   float64(m.Cyclomatic),
+		DCC:  float64(m.Cyclomatic - old.Cyclomatic),
+		NEST: float64(m.MaxNesting),
+		NFN:  float64(m.Functions),
+	}
+}
+
 var lineSep = []byte{'\n'}
 
 func numLines(content []byte) int {
@@ -333,19 +671,231 @@ func (f *fileAnalysis) AnalyzeHunk(hunk engine.DiffHunk) error {
 	}
 
 	if hunk.LinesAdded() > 0 {
-		f.HA += 1
-		f.LA += float64(hunk.LinesAdded())
+		f.RawLines.HA += 1
+		f.RawLines.LA += float64(hunk.LinesAdded())
+	}
+
+	if n := countCodeLines(f.newLines, hunk.AddressAdded()); n > 0 {
+		f.CodeLines.HA += 1
+		f.CodeLines.LA += float64(n)
 	}
 
 	if hunk.LinesDeleted() > 0 {
-		f.HD += 1
-		f.LD += float64(hunk.LinesDeleted())
-		f.DeletedChunks = append(f.DeletedChunks, hunk.AddressDeleted())
+		f.RawLines.HD += 1
+		f.RawLines.LD += float64(hunk.LinesDeleted())
+	}
+
+	if n := countCodeLines(f.oldLines, hunk.AddressDeleted()); n > 0 {
+		f.CodeLines.HD += 1
+		f.CodeLines.LD += float64(n)
 	}
 
+	f.analyzeFunctionLines(hunk.AddressDeleted(), false)
+	f.analyzeFunctionLines(hunk.AddressAdded(), true)
+
+	f.Hunks = append(f.Hunks, szz.Hunk{
+		Deleted: hunk.AddressDeleted(),
+		Added:   hunk.AddressAdded(),
+	})
+
 	return nil
 }
 
+// analyzeFunctionLines counts the lines of the chunk in the functions that
+// enclose them, the added lines are located in the new content and the deleted
+// lines in the old one.
+func (f *fileAnalysis) analyzeFunctionLines(chunk engine.ChunkAddr, added bool) {
+	functions, types := f.oldFunctions, f.oldLines
+	if added {
+		functions, types = f.newFunctions, f.newLines
+	}
+
+	for line := chunk.Start; line <= chunk.End; line++ {
+		fn := functions.At(line)
+		if fn == nil {
+			continue
+		}
+
+		fa := f.function(fn.Key())
+		code := line < 1 || line > len(types) || types[line-1] == classify.LineCode
+		if added {
+			fa.rawLines.LA += 1
+			if code {
+				fa.codeLines.LA += 1
+			}
+		} else {
+			fa.rawLines.LD += 1
+			if code {
+				fa.codeLines.LD += 1
+			}
+		}
+	}
+}
+
+// function returns the analysis of the changed function by its key, it is
+// added on the first change. The function is located in the new content, or in
+// the old one if it is deleted.
+func (f *fileAnalysis) function(key string) *functionAnalysis {
+	for _, fa := range f.functions {
+		if fa.function.Key() == key {
+			return fa
+		}
+	}
+
+	fa := &functionAnalysis{
+		fixing:     identifier.NewHashSet(),
+		developers: engine.NewDeveloperSet(),
+	}
+	if fn := findFunction(f.newFunctions, key); fn != nil {
+		fa.function = *fn
+	} else {
+		fa.function = *findFunction(f.oldFunctions, key)
+		fa.deleted = true
+	}
+
+	f.functions = append(f.functions, fa)
+	f.Functions = append(f.Functions, key)
+	return fa
+}
+
+func findFunction(functions classify.Functions, key string) *classify.Function {
+	for i := range functions {
+		if functions[i].Key() == key {
+			return &functions[i]
+		}
+	}
+	return nil
+}
+
+// traceFunctions adds the inducing commits of a deleted chunk to the functions
+// that enclose its lines.
+func (f *fileAnalysis) traceFunctions(chunk engine.ChunkAddr, inducing identifier.HashSet) {
+	for line := chunk.Start; line <= chunk.End; line++ {
+		fn := f.oldFunctions.At(line)
+		if fn == nil {
+			continue
+		}
+		f.function(fn.Key()).fixing.Update(inducing)
+	}
+}
+
+// blamedFunctions returns the keys of the functions that enclose the lines of
+// a deleted chunk, each line is counted in its innermost function.
+func (f *fileAnalysis) blamedFunctions(chunk engine.ChunkAddr) []string {
+	var keys []string
+	for line := chunk.Start; line <= chunk.End; line++ {
+		fn := f.oldFunctions.At(line)
+		if fn == nil {
+			continue
+		}
+
+		key := fn.Key()
+		if !containsString(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (f *fileAnalysis) functionChanges() []*entity.FunctionChange {
+	if len(f.functions) == 0 {
+		return nil
+	}
+
+	changes := make([]*entity.FunctionChange, len(f.functions))
+	for i, fa := range f.functions {
+		changes[i] = &entity.FunctionChange{
+			Name:      fa.function.Name,
+			Signature: fa.function.Signature,
+			Start:     fa.function.Start,
+			End:       fa.function.End,
+			Deleted:   fa.deleted,
+			Metrics:   &fa.FunctionMeasures,
+		}
+		if len(fa.fixing) > 0 {
+			changes[i].Fixing = fa.fixing.Slice()
+		}
+	}
+	return changes
+}
+
+// hasFunction reports whether the function of the key is changed in the file.
+func hasFunction(f *engine.FileInfo, key string) bool {
+	return containsString(f.Functions, key)
+}
+
+func containsString(s []string, v string) bool {
+	for _, e := range s {
+		if e == v {
+			return true
+		}
+	}
+	return false
+}
+
+// countCodeLines returns the number of code lines in the chunk, the lines that
+// are out of the classified content are counted as code.
+func countCodeLines(types []classify.LineType, chunk engine.ChunkAddr) int {
+	var n int
+	for line := chunk.Start; line <= chunk.End; line++ {
+		if line < 1 || line > len(types) || types[line-1] == classify.LineCode {
+			n++
+		}
+	}
+	return n
+}
+
+// setLineMeasures sets the size metrics of the files either from all the
+// changed lines or from the code lines only.
+func setLineMeasures(files []*fileAnalysis, codeOnly bool) {
+	for _, f := range files {
+		m := &f.RawLines
+		if codeOnly {
+			m = &f.CodeLines
+		}
+		f.LA, f.LD, f.HA, f.HD = m.LA, m.LD, m.HA, m.HD
+
+		for _, fn := range f.functions {
+			m := &fn.rawLines
+			if codeOnly {
+				m = &fn.codeLines
+			}
+			fn.LA, fn.LD = m.LA, m.LD
+		}
+	}
+}
+
+func sumLineMeasures(files []*fileAnalysis) (raw, code *metrics.LineMeasures) {
+	raw, code = new(metrics.LineMeasures), new(metrics.LineMeasures)
+	for _, f := range files {
+		if f.Type == classify.FileCode {
+			raw.Add(&f.RawLines)
+			code.Add(&f.CodeLines)
+		}
+	}
+	return raw, code
+}
+
+// setComplexityMeasures sums the complexity of the files that are counted in
+// the metrics of the commit, the nesting is the maximum of the files.
+func setComplexityMeasures(m *metrics.ChangeMeasures, files []*fileAnalysis) {
+	var cc, dcc, nest, nfn float64
+	for _, f := range files {
+		if isIgnoredFile(f) {
+			continue
+		}
+
+		cc += f.complexity.CC
+		dcc += f.complexity.DCC
+		nfn += f.complexity.NFN
+		if f.complexity.NEST > nest {
+			nest = f.complexity.NEST
+		}
+	}
+
+	m.CC, m.DCC, m.NEST, m.NFN = &cc, &dcc, &nest, &nfn
+}
+
 func calculatePositiveAge(c engine.Commit, ancestor engine.Commit) float64 {
 	age := c.AuthorDate().Sub(ancestor.AuthorDate()).Hours() / 24
 	if age < 1 {
@@ -355,10 +905,31 @@ func calculatePositiveAge(c engine.Commit, ancestor engine.Commit) float64 {
 	return age
 }
 
-//TODO: bots should be marked or filtered out
-//TODO: consider the co-author in the experience
-//TODO: consider the committer (if different developer) in the experience
-func calculateMetrics(ctx context.Context, c engine.Commit, analyzedFiles []*fileAnalysis) (*metrics.ChangeMeasures, error) {
+// creditedDevelopers returns the developers that are credited for the commit in
+// the metrics, they are the contributors unless only the author is credited.
+func creditedDevelopers(c engine.Commit, authorOnly bool) engine.DeveloperSet {
+	if authorOnly || c.Contributors() == nil {
+		devs := engine.NewDeveloperSet()
+		devs.Add(c.Developer())
+		return devs
+	}
+	return c.Contributors()
+}
+
+func haveCommonDeveloper(s1, s2 engine.DeveloperSet) bool {
+	for dev := range s1 {
+		if s2.Has(dev) {
+			return true
+		}
+	}
+	return false
+}
+
+// calculateMetrics calculates the metrics of a commit from the history state of
+// its parent, the state is nil for the commits without parents. The changes of
+// the bots are not counted in the experience and history metrics.
+func calculateMetrics(ctx context.Context, c engine.Commit, analyzedFiles []*fileAnalysis, history *historyState, authorOnly bool) (*metrics.ChangeMeasures, error) {
+	var devs = creditedDevelopers(c, authorOnly)
 	var subsystems = engine.NewStringSet()
 	var directories = engine.NewStringSet()
 	var trackedFiles = make(map[string]*fileAnalysis, len(analyzedFiles))
@@ -373,15 +944,19 @@ func calculateMetrics(ctx context.Context, c engine.Commit, analyzedFiles []*fil
 		directories.Add(fa.Directory())
 
 		if fa.Action != engine.DeltaAdded {
-			lastChange, err := engine.LastFileChange(ctx, c, fa.OldOrNewPath())
-			if err != nil {
+			var lastChange engine.Commit
+			if history != nil {
+				lastChange = history.lastChange(fa.OldOrNewPath())
+			}
+
+			if lastChange == nil {
 				lastChange = c
-				log.Ctx(ctx).Err(err).
+				log.Ctx(ctx).Err(errNoLastChange).
 					Hex("commit", c.Hash().Bytes()).
 					Str("file", fa.OldOrNewPath()).
 					Msg("find last change for a file")
 			} else {
-				fa.SameDeveloper = c.Developer() == lastChange.Developer()
+				fa.SameDeveloper = haveCommonDeveloper(devs, creditedDevelopers(lastChange, authorOnly))
 			}
 
 			fa.AGE = c.AuthorDate().Sub(lastChange.AuthorDate()).Hours() / 24
@@ -413,78 +988,63 @@ func calculateMetrics(ctx context.Context, c engine.Commit, analyzedFiles []*fil
 	changes := engine.NewCommitSet()
 	developers := engine.NewDeveloperSet()
 
-	err := VisitAncestors(ctx, c, trackedFiles, func(p engine.Commit, trackedFiles map[string]*fileAnalysis) {
-		var commonDevSubsystem bool
-		var commonDevFile bool
-		var commonFile bool
-		var dev = p.Developer()
-		var commonDev = c.Developer() == dev
-
-		for filepath, f := range p.Files() {
-			// common files
-			if fa, ok := trackedFiles[filepath]; ok {
-
-				if !commonFile {
-					commonFile = true
-					if commonDev {
-						commonDevFile = true
-					}
+	if history != nil {
+		for _, fa := range trackedFiles {
+			// the renamed and copied files carry the history of their sources
+			for l := history.files[fa.OldOrNewPath()]; l != nil; l = l.next {
+				p := l.commit
+				pDevs := creditedDevelopers(p, authorOnly)
 
+				if !changes.Has(p) {
 					changes.Add(p)
-					developers.Add(dev)
+					developers.Update(pDevs)
+
+					if haveCommonDeveloper(devs, pDevs) {
+						rexp += 1 / calculatePositiveAge(c, p)
+					}
 				}
 
-				if !fa.Developers.Has(dev) {
-					fa.Developers.Add(dev)
-					fa.NDEV += 1
+				for dev := range pDevs {
+					if !fa.Developers.Has(dev) {
+						fa.Developers.Add(dev)
+						fa.NDEV += 1
+					}
 				}
 
-				if f.Fix {
+				if l.fix {
 					fa.NFC += 1
 				}
 
-				if commonDev {
+				if haveCommonDeveloper(devs, pDevs) {
 					fa.EXP += 1
 					fa.REXP += 1 / calculatePositiveAge(c, p)
 				}
 
 				fa.NUC += 1
 
-				// adjust the file list to follow the renames
-				switch f.Action {
-				case engine.DeltaAdded:
-					delete(trackedFiles, filepath)
-				case engine.DeltaRenamed:
-					delete(trackedFiles, filepath)
-					trackedFiles[f.OldPath] = fa
-				}
-			}
-
-			// common developer subsystems
-			if commonDev && !commonDevSubsystem && subsystems.Has(f.Subsystem) {
-				commonDevSubsystem = true
-			}
-		}
+				for _, fn := range fa.functions {
+					if l.file == nil || !hasFunction(l.file, fn.function.Key()) {
+						continue
+					}
 
-		if nf == 0 {
-			// Do not count experience if no code files
-			return
-		}
+					for dev := range pDevs {
+						if !fn.developers.Has(dev) {
+							fn.developers.Add(dev)
+							fn.NDEV += 1
+						}
+					}
 
-		if commonDev {
-			exp += 1
-		}
+					if l.fix {
+						fn.NFC += 1
+					}
 
-		if commonDevSubsystem {
-			sexp += 1
+					fn.NUC += 1
+				}
+			}
 		}
 
-		if commonDevFile {
-			rexp += 1 / calculatePositiveAge(c, p)
-		}
-	})
-	if err != nil {
-		return nil, err
+		exp = float64(countDeveloperChanges(history, devs, nil, authorOnly))
+		sexp = float64(countDeveloperChanges(history, devs, subsystems, authorOnly))
 	}
 
 	return &metrics.ChangeMeasures{
@@ -506,6 +1066,67 @@ func calculateMetrics(ctx context.Context, c engine.Commit, analyzedFiles []*fil
 	}, nil
 }
 
+// countDeveloperChanges counts the commits in the history that credit any of the
+// developers, and touch any of the subsystems if they are given.
+func countDeveloperChanges(history *historyState, devs engine.DeveloperSet, subsystems engine.StringSet, authorOnly bool) int {
+	var lists []*change
+	var keys []devSubsystem
+	for dev := range devs {
+		if subsystems == nil {
+			lists = append(lists, history.devs[dev])
+			keys = append(keys, devSubsystem{dev: dev})
+			continue
+		}
+		for subsystem := range subsystems {
+			k := devSubsystem{dev: dev, subsystem: subsystem}
+			lists = append(lists, history.devSubsystems[k])
+			keys = append(keys, k)
+		}
+	}
+
+	if len(lists) == 0 {
+		return 0
+	}
+
+	// the longest list is counted as is, a commit in the other lists is
+	// counted if it is not in the lists before it
+	longest := 0
+	for i := range lists {
+		if lists[i].size() > lists[longest].size() {
+			longest = i
+		}
+	}
+	lists[0], lists[longest] = lists[longest], lists[0]
+	keys[0], keys[longest] = keys[longest], keys[0]
+
+	count := lists[0].size()
+	for i := 1; i < len(lists); i++ {
+		for l := lists[i]; l != nil; l = l.next {
+			if !inDeveloperChanges(l.commit, keys[:i], subsystems != nil, authorOnly) {
+				count += 1
+			}
+		}
+	}
+
+	return count
+}
+
+func inDeveloperChanges(c engine.Commit, keys []devSubsystem, bySubsystem bool, authorOnly bool) bool {
+	devs := creditedDevelopers(c, authorOnly)
+
+	var subsystems engine.StringSet
+	if bySubsystem {
+		subsystems = commitSubsystems(c)
+	}
+
+	for _, k := range keys {
+		if devs.Has(k.dev) && (!bySubsystem || subsystems.Has(k.subsystem)) {
+			return true
+		}
+	}
+	return false
+}
+
 func calculateEntropy(la, ld float64, files []*fileAnalysis) float64 {
 	// Number of modified lines in all files
 	modLines := la + ld
@@ -529,73 +1150,3 @@ func isIgnoredFile(f *fileAnalysis) bool {
 	// we do  not consider files that don't change code
 	return f.Type != classify.FileCode || f.LA+f.LD == 0
 }
-
-func VisitAncestors(ctx context.Context, c engine.Commit, trackedFiles map[string]*fileAnalysis, fn func(engine.Commit, map[string]*fileAnalysis)) error {
-	if !c.HasParent() {
-		return nil
-	}
-
-	seen := engine.NewCommitSet()
-	commitsStack := engine.CommitsStack{c.FirstParent()}
-	filesStack := fileAnalysisStack{trackedFiles}
-
-	// skip the  current commit and add its parents
-
-	for !commitsStack.IsEmpty() {
-		c := commitsStack.Pop()
-		f := filesStack.Pop()
-		fn(c, f)
-
-		parents := c.Parents()
-		for i := len(parents) - 1; i >= 0; i -= 1 {
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			default:
-			}
-			p := parents[i]
-
-			if seen.Has(p) {
-				continue
-			}
-
-			if i > 0 {
-				f = cloneFileAnalysis(f)
-			}
-
-			seen.Add(p)
-			commitsStack.Push(p)
-			filesStack.Push(f)
-		}
-	}
-	return nil
-}
-
-func cloneFileAnalysis(m map[string]*fileAnalysis) map[string]*fileAnalysis {
-	r := make(map[string]*fileAnalysis, len(m))
-	for k, v := range m {
-		r[k] = v
-	}
-	return r
-}
-
-type fileAnalysisStack []map[string]*fileAnalysis
-
-func (s *fileAnalysisStack) Pop() map[string]*fileAnalysis {
-	n := len(*s) - 1
-	// ge the last item
-	item := (*s)[n]
-	// avoid memory leak
-	(*s)[n] = nil
-	// delete the item from the stack
-	*s = (*s)[:n]
-	return item
-}
-
-func (s *fileAnalysisStack) Push(item map[string]*fileAnalysis) {
-	*s = append(*s, item)
-}
-
-func (s fileAnalysisStack) IsEmpty() bool {
-	return len(s) == 0
-}
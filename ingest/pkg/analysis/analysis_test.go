@@ -0,0 +1,207 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/repofuel/repofuel/ingest/internal/entity"
+	"github.com/repofuel/repofuel/ingest/pkg/classify"
+	"github.com/repofuel/repofuel/ingest/pkg/engine"
+	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+	"github.com/repofuel/repofuel/pkg/metrics"
+)
+
+type hunk struct {
+	deleted, added engine.ChunkAddr
+}
+
+func (h hunk) LinesAdded() int                  { return h.added.Len() }
+func (h hunk) LinesDeleted() int                { return h.deleted.Len() }
+func (h hunk) AddressDeleted() engine.ChunkAddr { return h.deleted }
+func (h hunk) AddressAdded() engine.ChunkAddr   { return h.added }
+
+type delta struct {
+	path    string
+	content []byte
+}
+
+func (d delta) FromPath() string                 { return d.path }
+func (d delta) ToPath() string                   { return d.path }
+func (d delta) Action() engine.DeltaType         { return engine.DeltaModified }
+func (d delta) IsBinary() bool                   { return false }
+func (d delta) IsSymlink() bool                  { return false }
+func (d delta) OldContent() ([]byte, error)      { return d.content, nil }
+func (d delta) NewContent() ([]byte, error)      { return d.content, nil }
+func (d delta) Patch() (engine.FilePatch, error) { return nil, nil }
+
+func TestAnalyzeFile_PathConfig(t *testing.T) {
+	paths, err := classify.NewPathClassifier(&classify.PathConfig{
+		Generated: []string{"/gen/"},
+		Ignored:   []string{"scripts/"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := []byte("package main\n")
+	cases := map[string]classify.FileType{
+		"gen/model.go":       classify.FileGenerated,
+		"tools/scripts/a.go": classify.FileIgnored,
+		"vendor/x/x.go":      classify.FileDependency,
+		"main.go":            classify.FileCode,
+	}
+	for p, want := range cases {
+		fa, err := analyzeFile(delta{path: p, content: content}, paths)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fa.Type != want {
+			t.Errorf("%s: got %v, want %v", p, fa.Type, want)
+		}
+	}
+}
+
+func TestFileAnalysis_AnalyzeHunk(t *testing.T) {
+	oldContent := []byte("package main\n" +
+		"\n" +
+		"x := 1\n")
+	newContent := []byte("package main\n" +
+		"\n" +
+		"// comment\n" +
+		"x := 2\n" +
+		"\n")
+
+	f := &fileAnalysis{
+		FileInfo: new(engine.FileInfo),
+		Type:     classify.FileCode,
+		oldLines: classify.ClassifyLines("Go", oldContent),
+		newLines: classify.ClassifyLines("Go", newContent),
+	}
+
+	hunks := []hunk{
+		// the comment is added before the modified line
+		{deleted: engine.ChunkAddr{Start: 3, End: 3}, added: engine.ChunkAddr{Start: 3, End: 4}},
+		// a blank line is added at the end
+		{deleted: engine.ChunkAddr{Start: 4, End: 3}, added: engine.ChunkAddr{Start: 5, End: 5}},
+	}
+	for _, h := range hunks {
+		if err := f.AnalyzeHunk(h); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if want := (metrics.LineMeasures{LA: 3, LD: 1, HA: 2, HD: 1}); f.RawLines != want {
+		t.Errorf("raw lines: got %+v, want %+v", f.RawLines, want)
+	}
+	if want := (metrics.LineMeasures{LA: 1, LD: 1, HA: 1, HD: 1}); f.CodeLines != want {
+		t.Errorf("code lines: got %+v, want %+v", f.CodeLines, want)
+	}
+
+	setLineMeasures([]*fileAnalysis{f}, true)
+	if f.LA != 1 || f.LD != 1 || f.HA != 1 || f.HD != 1 {
+		t.Errorf("expected the code lines in the file metrics, got %+v", f.FileMeasures)
+	}
+}
+
+func TestFileAnalysis_Functions(t *testing.T) {
+	oldContent := []byte("package main\n" +
+		"\n" +
+		"func a() {\n" +
+		"\tx := 1\n" +
+		"}\n" +
+		"\n" +
+		"func b() {\n" +
+		"}\n")
+	newContent := []byte("package main\n" +
+		"\n" +
+		"func a() {\n" +
+		"\t// comment\n" +
+		"\tx := 2\n" +
+		"}\n")
+
+	f := &fileAnalysis{
+		FileInfo:     new(engine.FileInfo),
+		Type:         classify.FileCode,
+		oldLines:     classify.ClassifyLines("Go", oldContent),
+		newLines:     classify.ClassifyLines("Go", newContent),
+		oldFunctions: classify.FindFunctions("Go", oldContent),
+		newFunctions: classify.FindFunctions("Go", newContent),
+	}
+
+	hunks := []hunk{
+		// the body of a is modified
+		{deleted: engine.ChunkAddr{Start: 4, End: 4}, added: engine.ChunkAddr{Start: 4, End: 5}},
+		// b is deleted
+		{deleted: engine.ChunkAddr{Start: 6, End: 8}, added: engine.ChunkAddr{Start: 7, End: 6}},
+	}
+	for _, h := range hunks {
+		if err := f.AnalyzeHunk(h); err != nil {
+			t.Fatal(err)
+		}
+	}
+	setLineMeasures([]*fileAnalysis{f}, true)
+
+	if want := []string{"a", "b"}; !reflect.DeepEqual(f.Functions, want) {
+		t.Fatalf("changed functions: got %v, want %v", f.Functions, want)
+	}
+
+	want := []*entity.FunctionChange{
+		{Name: "a", Start: 3, End: 6, Metrics: &metrics.FunctionMeasures{LA: 1, LD: 1}},
+		{Name: "b", Start: 7, End: 8, Deleted: true, Metrics: &metrics.FunctionMeasures{LD: 2}},
+	}
+	if got := f.functionChanges(); !reflect.DeepEqual(got, want) {
+		t.Errorf("function changes:\ngot  %+v %+v\nwant %+v %+v", got[0], got[1], want[0], want[1])
+	}
+}
+
+func TestFileAnalysis_Overloads(t *testing.T) {
+	content := []byte("class Writer {\n" +
+		"    void write(int b) {\n" +
+		"        out(b);\n" +
+		"    }\n" +
+		"\n" +
+		"    void write(byte[] b) {\n" +
+		"        out(b);\n" +
+		"    }\n" +
+		"}\n")
+
+	f := &fileAnalysis{
+		FileInfo:     new(engine.FileInfo),
+		Type:         classify.FileCode,
+		oldLines:     classify.ClassifyLines("Java", content),
+		oldFunctions: classify.FindFunctions("Java", content),
+	}
+
+	// the chunk spans the ends of both of the overloads
+	chunk := engine.ChunkAddr{Start: 3, End: 7}
+	want := []string{"Writer.write(int b)", "Writer.write(byte[] b)"}
+	if got := f.blamedFunctions(chunk); !reflect.DeepEqual(got, want) {
+		t.Errorf("blamed functions: got %v, want %v", got, want)
+	}
+
+	f.traceFunctions(chunk, identifier.NewHashSet())
+	if !reflect.DeepEqual(f.Functions, want) {
+		t.Errorf("changed functions: got %v, want %v", f.Functions, want)
+	}
+}
+
+func TestSetComplexityMeasures(t *testing.T) {
+	code := func(la float64, c metrics.ComplexityMeasures) *fileAnalysis {
+		f := &fileAnalysis{FileInfo: new(engine.FileInfo), Type: classify.FileCode, complexity: c}
+		f.LA = la
+		return f
+	}
+
+	files := []*fileAnalysis{
+		code(1, metrics.ComplexityMeasures{CC: 4, DCC: 1, NEST: 2, NFN: 2}),
+		code(1, metrics.ComplexityMeasures{CC: 3, DCC: -2, NEST: 3, NFN: 1}),
+		// the file does not change code, it is ignored
+		code(0, metrics.ComplexityMeasures{CC: 10, DCC: 10, NEST: 5, NFN: 5}),
+	}
+
+	var m metrics.ChangeMeasures
+	setComplexityMeasures(&m, files)
+	if *m.CC != 7 || *m.DCC != -1 || *m.NEST != 3 || *m.NFN != 3 {
+		t.Errorf("unexpected complexity measures: %+v", m)
+	}
+}
@@ -0,0 +1,568 @@
+package analysis
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+
+	"github.com/repofuel/repofuel/ingest/pkg/engine"
+)
+
+// change is a commit in the history of a file or a developer, the changes are
+// linked from the newest to the oldest and they are shared between the states.
+type change struct {
+	commit engine.Commit
+	// file is the changed file by the commit, it is nil in the histories of
+	// the developers.
+	file  *engine.FileInfo
+	fix   bool
+	next  *change
+	count int
+}
+
+func (l *change) push(c engine.Commit, file *engine.FileInfo) *change {
+	return &change{commit: c, file: file, fix: file != nil && file.Fix, next: l, count: l.size() + 1}
+}
+
+func (l *change) size() int {
+	if l == nil {
+		return 0
+	}
+	return l.count
+}
+
+type devSubsystem struct {
+	dev       engine.Developer
+	subsystem string
+}
+
+// historyState summarizes the history of a commit, including the commit itself,
+// to calculate the metrics of its children without visiting the ancestors. It
+// gives the same results of visiting the ancestors in depth-first order.
+type historyState struct {
+	// files are the changes of the paths, a history follows the renames and
+	// the copies, and it stops where the file is added.
+	files map[string]*change
+	// last is the last commit that touched a path, the renames are not followed.
+	last map[string]engine.Commit
+	// devs and devSubsystems are the commits that credit the developers.
+	devs          map[engine.Developer]*change
+	devSubsystems map[devSubsystem]*change
+	// chain maps the paths of the commit to the paths that the depth-first visit
+	// tracks after visiting the commit and its first parents, up to the next
+	// merge or root. The visit shares the tracked paths of the first parent of a
+	// merge with the next parent, so the renames and additions of this chain
+	// apply to the merged parent too. An empty path means it is not tracked
+	// anymore, and the missing paths are not changed.
+	chain map[string]string
+	// refs is the number of children that did not derive their states yet.
+	refs int
+}
+
+func newHistoryState() *historyState {
+	return &historyState{
+		files:         make(map[string]*change),
+		last:          make(map[string]engine.Commit),
+		devs:          make(map[engine.Developer]*change),
+		devSubsystems: make(map[devSubsystem]*change),
+		chain:         make(map[string]string),
+	}
+}
+
+func (s *historyState) clone() *historyState {
+	c := &historyState{
+		files:         make(map[string]*change, len(s.files)),
+		last:          make(map[string]engine.Commit, len(s.last)),
+		devs:          make(map[engine.Developer]*change, len(s.devs)),
+		devSubsystems: make(map[devSubsystem]*change, len(s.devSubsystems)),
+		chain:         make(map[string]string, len(s.chain)),
+	}
+	for k, v := range s.files {
+		c.files[k] = v
+	}
+	for k, v := range s.last {
+		c.last[k] = v
+	}
+	for k, v := range s.devs {
+		c.devs[k] = v
+	}
+	for k, v := range s.devSubsystems {
+		c.devSubsystems[k] = v
+	}
+	for k, v := range s.chain {
+		c.chain[k] = v
+	}
+	return c
+}
+
+// lastChange returns the latest commit that touched the path, the ties are
+// resolved as `engine.LastFileChange` does.
+func (s *historyState) lastChange(path string) engine.Commit {
+	return s.last[path]
+}
+
+// historyCache keeps the history states of the analyzed commits until their
+// children are analyzed. The states of the commits that are analyzed in the
+// previous jobs are built on demand from their ancestors. The cache is safe for
+// concurrent use by the commits that are analyzed in parallel.
+type historyCache struct {
+	authorOnly bool
+	states     map[engine.Commit]*historyState
+	pending    engine.CommitSet
+	gens       map[engine.Commit]int
+	mu         sync.Mutex
+}
+
+// newHistoryCache creates a cache for analyzing the roots and their descendants.
+func newHistoryCache(roots []engine.Commit, authorOnly bool) *historyCache {
+	pending := engine.NewCommitSet()
+	stack := engine.NewCommitStack(roots...)
+	for !stack.IsEmpty() {
+		c := stack.Pop()
+		if pending.Has(c) {
+			continue
+		}
+		pending.Add(c)
+		stack.Push(c.Children()...)
+	}
+
+	return &historyCache{
+		authorOnly: authorOnly,
+		states:     make(map[engine.Commit]*historyState),
+		pending:    pending,
+		gens:       make(map[engine.Commit]int),
+	}
+}
+
+// State returns the history state of an analyzed commit, the state should not
+// be modified. The state is kept unchanged until the child that requested it is
+// added, because the child is counted in the references of the state.
+func (h *historyCache) State(ctx context.Context, c engine.Commit) (*historyState, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if s, ok := h.states[c]; ok {
+		return s, nil
+	}
+	return h.build(ctx, c)
+}
+
+// Add records the history state of a commit after analyzing it, the files of
+// the commit should not change after adding it.
+func (h *historyCache) Add(ctx context.Context, c engine.Commit) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, p := range c.Parents() {
+		if _, ok := h.states[p]; !ok {
+			_, err := h.build(ctx, p)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	s := h.derive(c)
+	h.pending.Delete(c)
+	h.store(c, s, 0)
+	return nil
+}
+
+// build derives the missing states of the commit and its ancestors, starting
+// from the oldest ones.
+func (h *historyCache) build(ctx context.Context, c engine.Commit) (*historyState, error) {
+	type frame struct {
+		commit engine.Commit
+		ready  bool
+	}
+
+	var order []engine.Commit
+	scope := engine.NewCommitSet()
+	stack := []frame{{commit: c}}
+
+	for len(stack) > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		f := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if f.ready {
+			order = append(order, f.commit)
+			continue
+		}
+
+		if scope.Has(f.commit) {
+			continue
+		}
+		scope.Add(f.commit)
+
+		stack = append(stack, frame{commit: f.commit, ready: true})
+		for _, p := range f.commit.Parents() {
+			if ps, ok := h.states[p]; ok {
+				// the commit will derive its state from the stored one
+				ps.refs += 1
+			} else if !scope.Has(p) {
+				stack = append(stack, frame{commit: p})
+			}
+		}
+	}
+
+	var s *historyState
+	for _, a := range order {
+		s = h.derive(a)
+
+		var children int
+		for _, child := range a.Children() {
+			if scope.Has(child) {
+				children += 1
+			}
+		}
+		h.store(a, s, children)
+	}
+
+	return s, nil
+}
+
+// store keeps the state while some children still need it, they are the ones
+// to be analyzed and the given number of children that are being built.
+func (h *historyCache) store(c engine.Commit, s *historyState, children int) {
+	s.refs = children
+	for _, child := range c.Children() {
+		if h.pending.Has(child) {
+			s.refs += 1
+		}
+	}
+
+	if s.refs > 0 {
+		h.states[c] = s
+	}
+}
+
+// derive builds the state of a commit from the states of its parents, the
+// parents states should be available.
+func (h *historyCache) derive(c engine.Commit) *historyState {
+	parents := c.Parents()
+	if len(parents) == 0 {
+		s := newHistoryState()
+		h.apply(s, c)
+		return s
+	}
+
+	s := h.take(parents[0])
+
+	// only the first merged parent that has unique commits shares the tracked
+	// paths with the first parent
+	chain := s.chain
+	for i := 1; i < len(parents); i++ {
+		unique := h.uniqueAncestors(parents[i], parents[:i])
+		h.merge(s, parents[i], unique, chain)
+		h.release(parents[i])
+		if len(unique) > 0 {
+			chain = nil
+		}
+	}
+
+	h.apply(s, c)
+	return s
+}
+
+// take returns the state of the commit to be modified by a child, it is taken
+// over without copying if no other child needs it.
+func (h *historyCache) take(c engine.Commit) *historyState {
+	s, ok := h.states[c]
+	if !ok {
+		return newHistoryState()
+	}
+
+	if s.refs <= 1 {
+		delete(h.states, c)
+		return s
+	}
+
+	s.refs -= 1
+	return s.clone()
+}
+
+func (h *historyCache) release(c engine.Commit) {
+	s, ok := h.states[c]
+	if !ok {
+		return
+	}
+
+	s.refs -= 1
+	if s.refs <= 0 {
+		delete(h.states, c)
+	}
+}
+
+// apply adds the changes of a commit to the state of its parents.
+func (h *historyCache) apply(s *historyState, c engine.Commit) {
+	files := c.Files()
+	bot := c.Bot()
+
+	// the histories are updated after reading them all to not follow a rename
+	// into a path that is changed by the same commit
+	histories := make(map[string]*change, len(files))
+	for path, f := range files {
+		var l *change
+		switch f.Action {
+		case engine.DeltaAdded:
+		case engine.DeltaRenamed, engine.DeltaCopied:
+			l = s.files[f.OldPath]
+		default:
+			l = s.files[path]
+		}
+
+		if !bot {
+			l = l.push(c, f)
+		}
+		histories[path] = l
+	}
+
+	for path, l := range histories {
+		s.last[path] = c
+		if l == nil {
+			delete(s.files, path)
+			continue
+		}
+		s.files[path] = l
+	}
+
+	h.applyChain(s, c)
+
+	if bot {
+		return
+	}
+
+	h.applyDeveloper(s, c)
+}
+
+// applyChain extends the chain of the first parent with the commit, or starts
+// a new chain if the commit is a merge or a root.
+func (h *historyCache) applyChain(s *historyState, c engine.Commit) {
+	chain := s.chain
+	if c.NumParents() != 1 {
+		chain = make(map[string]string)
+	}
+
+	paths := make(map[string]string)
+	for path, f := range c.Files() {
+		switch f.Action {
+		case engine.DeltaAdded:
+			paths[path] = ""
+		case engine.DeltaRenamed, engine.DeltaCopied:
+			old, ok := chain[f.OldPath]
+			if !ok {
+				old = f.OldPath
+			}
+			paths[path] = old
+		}
+	}
+
+	for path, next := range paths {
+		chain[path] = next
+	}
+	s.chain = chain
+}
+
+// applyDeveloper adds the commit to the histories of its developers.
+func (h *historyCache) applyDeveloper(s *historyState, c engine.Commit) {
+	subsystems := commitSubsystems(c)
+	for dev := range creditedDevelopers(c, h.authorOnly) {
+		s.devs[dev] = s.devs[dev].push(c, nil)
+		for subsystem := range subsystems {
+			k := devSubsystem{dev: dev, subsystem: subsystem}
+			s.devSubsystems[k] = s.devSubsystems[k].push(c, nil)
+		}
+	}
+}
+
+// merge adds the history of a merged parent to the state. The unique commits are
+// the ones that are not reachable from the previous parents, they are the only
+// commits that the depth-first visit reaches through the merged parent. The
+// paths of the merged parent are mapped back by the chain of the first parent.
+func (h *historyCache) merge(s *historyState, parent engine.Commit, unique engine.CommitSet, chain map[string]string) {
+	ps, ok := h.states[parent]
+	if !ok {
+		return
+	}
+
+	paths := make(map[string]string, len(chain))
+	for path, next := range chain {
+		if next != "" {
+			paths[next] = path
+		}
+	}
+
+	for parentPath, l := range ps.files {
+		path, ok := paths[parentPath]
+		if !ok {
+			if _, ok := chain[parentPath]; ok {
+				continue
+			}
+			path = parentPath
+		}
+
+		base := s.files[path]
+		if l == base || len(unique) == 0 {
+			continue
+		}
+
+		var added []*change
+		for ; l != nil; l = l.next {
+			if unique.Has(l.commit) {
+				added = append(added, l)
+			}
+		}
+
+		for i := len(added) - 1; i >= 0; i-- {
+			base = base.push(added[i].commit, added[i].file)
+		}
+		s.files[path] = base
+	}
+
+	// the parents are visited from the last one, the latest change that is
+	// found first wins the ties
+	for path, c := range ps.last {
+		last, ok := s.last[path]
+		if !ok || !c.AuthorDate().Before(last.AuthorDate()) {
+			s.last[path] = c
+		}
+	}
+
+	for c := range unique {
+		if !c.Bot() {
+			h.applyDeveloper(s, c)
+		}
+	}
+}
+
+// uniqueAncestors returns the commits that are reachable from the commit but
+// not from the others. The commits are visited from the highest generation to
+// stop once the remaining commits are reachable from the others.
+func (h *historyCache) uniqueAncestors(c engine.Commit, others []engine.Commit) engine.CommitSet {
+	const (
+		fromOthers = 1 << iota
+		fromCommit
+	)
+
+	flags := make(map[engine.Commit]int)
+	queue := &generationQueue{gens: h.gens}
+	var remaining int
+
+	mark := func(c engine.Commit, flag int) {
+		old := flags[c]
+		if old|flag == old {
+			return
+		}
+		flags[c] = old | flag
+		if old == 0 {
+			h.generation(c)
+			heap.Push(queue, c)
+			if flag == fromCommit {
+				remaining += 1
+			}
+		} else if old == fromCommit {
+			remaining -= 1
+		}
+	}
+
+	for _, o := range others {
+		mark(o, fromOthers)
+	}
+	mark(c, fromCommit)
+
+	unique := engine.NewCommitSet()
+	for remaining > 0 {
+		next := heap.Pop(queue).(engine.Commit)
+		flag := flags[next]
+		if flag == fromCommit {
+			remaining -= 1
+			unique.Add(next)
+		}
+
+		for _, p := range next.Parents() {
+			mark(p, flag)
+		}
+	}
+
+	return unique
+}
+
+// generation is the length of the longest path from the commit to a root.
+func (h *historyCache) generation(c engine.Commit) int {
+	if g, ok := h.gens[c]; ok {
+		return g
+	}
+
+	stack := engine.CommitsStack{c}
+	for !stack.IsEmpty() {
+		c := stack.Pop()
+		if _, ok := h.gens[c]; ok {
+			continue
+		}
+
+		var missing bool
+		g := 1
+		for _, p := range c.Parents() {
+			pg, ok := h.gens[p]
+			if !ok {
+				if !missing {
+					stack.Push(c)
+					missing = true
+				}
+				stack.Push(p)
+				continue
+			}
+			if pg+1 > g {
+				g = pg + 1
+			}
+		}
+
+		if !missing {
+			h.gens[c] = g
+		}
+	}
+
+	return h.gens[c]
+}
+
+// generationQueue pops the commits of the highest generation first.
+type generationQueue struct {
+	commits []engine.Commit
+	gens    map[engine.Commit]int
+}
+
+func (q generationQueue) Len() int { return len(q.commits) }
+
+func (q generationQueue) Less(i, j int) bool {
+	return q.gens[q.commits[i]] > q.gens[q.commits[j]]
+}
+
+func (q generationQueue) Swap(i, j int) {
+	q.commits[i], q.commits[j] = q.commits[j], q.commits[i]
+}
+
+func (q *generationQueue) Push(x interface{}) {
+	q.commits = append(q.commits, x.(engine.Commit))
+}
+
+func (q *generationQueue) Pop() interface{} {
+	n := len(q.commits) - 1
+	c := q.commits[n]
+	q.commits[n] = nil
+	q.commits = q.commits[:n]
+	return c
+}
+
+func commitSubsystems(c engine.Commit) engine.StringSet {
+	subsystems := engine.NewStringSet()
+	for _, f := range c.Files() {
+		subsystems.Add(f.Subsystem)
+	}
+	return subsystems
+}
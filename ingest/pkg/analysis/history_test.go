@@ -0,0 +1,718 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/repofuel/repofuel/ingest/pkg/classify"
+	"github.com/repofuel/repofuel/ingest/pkg/engine"
+	"github.com/repofuel/repofuel/ingest/pkg/engine/enginetest"
+	"github.com/repofuel/repofuel/ingest/pkg/engine/gogit"
+	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+	"github.com/repofuel/repofuel/ingest/pkg/identity"
+	"github.com/repofuel/repofuel/pkg/metrics"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	jane = "Jane Doe <jane@example.com>"
+	john = "John Roe <john@example.com>"
+	bot  = "dependabot[bot] <49699333+dependabot[bot]@users.noreply.github.com>"
+)
+
+// historyFixture has branches that merge each other, renames, re-added files,
+// fixes, and bot commits. The `first` commit is the head of the first job.
+func historyFixture(t *testing.T) (f *enginetest.Fixture, first string, heads []string) {
+	f = enginetest.NewFixture(t)
+
+	var n int
+	edit := func(paths ...string) {
+		for _, p := range paths {
+			n += 1
+			content := f.Git("show", "HEAD:"+p)
+			f.Write(p, content+fmt.Sprintf("\nline %d\n", n))
+		}
+	}
+	commit := func(label, author string, wait time.Duration) {
+		f.Advance(wait)
+		f.CommitAs(label, author)
+	}
+
+	f.Write("src/a.go", "package src\n")
+	f.Write("src/b.go", "package src\n")
+	f.Write("lib/c.go", "package lib\n")
+	f.Write("docs/readme.md", "readme\n")
+	commit("A", jane, 0)
+
+	edit("src/a.go", "lib/c.go")
+	commit("B", john, 30*time.Hour)
+
+	edit("src/a.go")
+	commit("fix a", jane, 50*time.Hour)
+
+	f.Checkout("feature")
+	edit("src/b.go")
+	f.Move("lib/c.go", "lib/d.go")
+	commit("D", john, 20*time.Hour)
+
+	edit("src/b.go", "docs/readme.md")
+	commit("E", bot, 10*time.Hour)
+
+	f.Checkout("master")
+	edit("src/a.go")
+	f.Write("lib/e.go", "package lib\n")
+	commit("F", jane, 70*time.Hour)
+
+	f.Checkout("feature")
+	f.Advance(5 * time.Hour)
+	f.Git("merge", "--quiet", "--no-ff", "-m", "G", "master")
+
+	edit("src/b.go", "lib/d.go")
+	commit("fix b", john, 40*time.Hour)
+
+	f.Checkout("master")
+	edit("src/a.go", "lib/e.go")
+	commit("H", john, 3*time.Hour)
+
+	f.Checkout("other")
+	edit("src/a.go", "lib/e.go")
+	commit("I", jane, 90*time.Hour)
+
+	f.Checkout("master")
+	f.Advance(time.Hour)
+	f.Merge("M1", "feature")
+
+	edit("src/a.go", "src/b.go", "lib/d.go")
+	commit("J", john, 25*time.Hour)
+
+	f.Remove("lib/e.go")
+	commit("K", jane, 8*time.Hour)
+
+	f.Write("lib/e.go", "package lib\n")
+	edit("src/b.go")
+	commit("L", jane, 60*time.Hour)
+
+	f.Advance(time.Hour)
+	f.Git("merge", "--quiet", "--no-ff", "-X", "theirs", "-m", "M2", "other")
+
+	edit("lib/e.go", "lib/d.go", "src/a.go")
+	commit("fix e", john, 100*time.Hour)
+
+	f.Checkout("feature")
+	edit("src/b.go")
+	commit("N", jane, 2*time.Hour)
+
+	return f, "fix a", []string{"fix e", "N"}
+}
+
+func TestHistoryCache_Equivalence(t *testing.T) {
+	for _, authorOnly := range []bool{false, true} {
+		t.Run(fmt.Sprintf("authorOnly=%v", authorOnly), func(t *testing.T) {
+			testHistoryEquivalence(t, authorOnly)
+		})
+	}
+}
+
+func testHistoryEquivalence(t *testing.T, authorOnly bool) {
+	ctx := context.Background()
+	f, first, heads := historyFixture(t)
+
+	adp := enginetest.Clone(t, func() engine.RepositoryAdapter { return gogit.NewAdapter() }, f)
+	repo := engine.NewRepository(identifier.RepositoryID{}, "", &engine.RepositoryOpts{Adapter: adp})
+	repo.SetBotDetector(identity.NewBotDetector(nil))
+
+	// the first job analyzes the beginning of the history, then the next job
+	// builds the states of the analyzed commits from their files
+	if err := repo.IngestHead(ctx, f.Hash(first)); err != nil {
+		t.Fatal(err)
+	}
+	a := &equivalenceAnalyzer{t: t, authorOnly: authorOnly}
+	a.run(ctx, repo.Roots().Slice())
+
+	firstHead, _ := repo.Commit(f.Hash(first))
+	seen := engine.AncestorsList(firstHead)
+	var headCommits []engine.Commit
+	for _, h := range heads {
+		if err := repo.IngestHead(ctx, f.Hash(h)); err != nil {
+			t.Fatal(err)
+		}
+		c, _ := repo.Commit(f.Hash(h))
+		headCommits = append(headCommits, c)
+	}
+
+	start, err := engine.UnseenRoots(ctx, seen, headCommits...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.run(ctx, start.Slice())
+
+	if a.compared != repo.CommitsCount()-3 {
+		t.Errorf("expected to compare all the commits except the merges, compared %d of %d", a.compared, repo.CommitsCount())
+	}
+}
+
+type equivalenceAnalyzer struct {
+	t          *testing.T
+	authorOnly bool
+	history    *historyCache
+	compared   int
+}
+
+func (a *equivalenceAnalyzer) run(ctx context.Context, roots []engine.Commit) {
+	a.history = newHistoryCache(roots, a.authorOnly)
+	if err := engine.RunForwardAnalysis(ctx, a, roots); err != nil {
+		a.t.Fatal(err)
+	}
+}
+
+func (a *equivalenceAnalyzer) AnalyzeCommit(ctx context.Context, c engine.Commit) error {
+	obj, err := c.Object()
+	if err != nil {
+		return err
+	}
+	defer obj.Free()
+
+	files, err := a.analyzeFiles(ctx, obj)
+	if err != nil {
+		return err
+	}
+	c.SetFiles(codeFilesList(files))
+
+	if !c.IsMerge() {
+		a.compare(ctx, c, obj, files)
+	}
+
+	return a.history.Add(ctx, c)
+}
+
+func (a *equivalenceAnalyzer) analyzeFiles(ctx context.Context, obj engine.CommitObject) ([]*fileAnalysis, error) {
+	files, err := analyzeFiles(ctx, obj, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	setLineMeasures(files, false)
+	for _, f := range files {
+		f.Fix = strings.HasPrefix(obj.Message(), "fix")
+	}
+
+	return files, nil
+}
+
+func (a *equivalenceAnalyzer) compare(ctx context.Context, c engine.Commit, obj engine.CommitObject, want []*fileAnalysis) {
+	t := a.t
+	label := strings.TrimSpace(obj.Message())
+
+	got, err := a.analyzeFiles(ctx, obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var history *historyState
+	if c.HasParent() {
+		history, err = a.history.State(ctx, c.FirstParent())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wantMetrics, wantErr := walkMetrics(ctx, c, want, a.authorOnly)
+	gotMetrics, gotErr := calculateMetrics(ctx, c, got, history, a.authorOnly)
+	if wantErr != gotErr {
+		t.Fatalf("%s: expected error %v, got %v", label, wantErr, gotErr)
+	}
+	a.compared += 1
+
+	if wantErr != nil {
+		return
+	}
+
+	if !equalMeasures(*wantMetrics, *gotMetrics) {
+		t.Errorf("%s: change measures\nwant %+v\n got %+v", label, *wantMetrics, *gotMetrics)
+	}
+
+	for i := range want {
+		if !equalMeasures(want[i].FileMeasures, got[i].FileMeasures) || want[i].SameDeveloper != got[i].SameDeveloper {
+			t.Errorf("%s: file measures of %s\nwant %+v\n got %+v", label, want[i].Path, want[i].FileMeasures, got[i].FileMeasures)
+		}
+	}
+}
+
+func (a *equivalenceAnalyzer) Finish(context.Context) error {
+	return nil
+}
+
+func TestHistoryCache_Parallel(t *testing.T) {
+	ctx := context.Background()
+	f, _, heads := historyFixture(t)
+
+	adp := enginetest.Clone(t, func() engine.RepositoryAdapter { return gogit.NewAdapter() }, f)
+	repo := engine.NewRepository(identifier.RepositoryID{}, "", &engine.RepositoryOpts{Adapter: adp})
+	repo.SetBotDetector(identity.NewBotDetector(nil))
+	for _, h := range heads {
+		if err := repo.IngestHead(ctx, f.Hash(h)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	roots := repo.Roots().Slice()
+	want := &parallelAnalyzer{history: newHistoryCache(roots, false), metrics: make(map[engine.Commit]*metrics.ChangeMeasures)}
+	if err := engine.RunForwardAnalysis(ctx, want, roots); err != nil {
+		t.Fatal(err)
+	}
+
+	got := &parallelAnalyzer{history: newHistoryCache(roots, false), metrics: make(map[engine.Commit]*metrics.ChangeMeasures)}
+	if err := engine.RunParallelForwardAnalysis(ctx, got, roots, 4); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(want.metrics) == 0 || len(got.metrics) != len(want.metrics) {
+		t.Fatalf("expected metrics of %d commits, got %d", len(want.metrics), len(got.metrics))
+	}
+	for c, m := range want.metrics {
+		if !equalMeasures(*m, *got.metrics[c]) {
+			t.Errorf("%s: change measures\nwant %+v\n got %+v", c.Hash(), *m, *got.metrics[c])
+		}
+	}
+}
+
+// TestHistoryCache_FollowsCopies splits a file by copying it, the copy carries
+// the history of its source.
+func TestHistoryCache_FollowsCopies(t *testing.T) {
+	ctx := context.Background()
+	f := enginetest.NewFixture(t)
+	f.Write("a.go", "package a\n")
+	f.CommitAs("A", jane)
+	f.Write("a.go", "package a\n\nvar x = 1\n")
+	f.CommitAs("B", john)
+	f.Write("b.go", "package a\n\nvar x = 1\n")
+	f.CommitAs("C", jane)
+	f.Write("b.go", "package a\n\nvar x = 2\n")
+	f.CommitAs("D", john)
+
+	adp := enginetest.Clone(t, func() engine.RepositoryAdapter { return gogit.NewAdapter() }, f)
+	repo := engine.NewRepository(identifier.RepositoryID{}, "", &engine.RepositoryOpts{Adapter: adp})
+	if err := repo.IngestHead(ctx, f.Hash("D")); err != nil {
+		t.Fatal(err)
+	}
+
+	// go-git does not detect the copies, so the files are set as libgit2 finds them
+	files := map[string]*engine.FileInfo{
+		"A": {Path: "a.go", Action: engine.DeltaAdded},
+		"B": {Path: "a.go", Action: engine.DeltaModified},
+		"C": {Path: "b.go", OldPath: "a.go", Action: engine.DeltaCopied},
+	}
+
+	history := newHistoryCache(repo.Roots().Slice(), false)
+	for _, label := range []string{"A", "B", "C"} {
+		c, _ := repo.Commit(f.Hash(label))
+		c.SetFiles(map[string]*engine.FileInfo{files[label].Path: files[label]})
+		if err := history.Add(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d, _ := repo.Commit(f.Hash("D"))
+	state, err := history.State(ctx, d.FirstParent())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fa := &fileAnalysis{
+		FileInfo:   &engine.FileInfo{Path: "b.go", Action: engine.DeltaModified},
+		Developers: engine.NewDeveloperSet(),
+	}
+	fa.Type = classify.FileCode
+	fa.LA, fa.LD = 1, 1
+	if _, err := calculateMetrics(ctx, d, []*fileAnalysis{fa}, state, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if fa.NUC != 3 {
+		t.Errorf("expected the copy to carry 3 changes, got %v", fa.NUC)
+	}
+	if state.files["a.go"].size() != 2 {
+		t.Errorf("expected the source to keep its 2 changes, got %d", state.files["a.go"].size())
+	}
+}
+
+// TestCalculateMetrics_Functions finds the previous changes of the functions
+// from the changed function names in the history of the file.
+func TestCalculateMetrics_Functions(t *testing.T) {
+	ctx := context.Background()
+	f := enginetest.NewFixture(t)
+	f.Write("a.go", "package a\n\nfunc foo() {\n\tx := 1\n}\n\nfunc bar() {\n\ty := 1\n}\n")
+	f.CommitAs("A", jane)
+	f.Write("a.go", "package a\n\nfunc foo() {\n\tx := 2\n}\n\nfunc bar() {\n\ty := 1\n}\n")
+	f.CommitAs("fix foo", john)
+	f.Write("a.go", "package a\n\nfunc foo() {\n\tx := 3\n}\n\nfunc bar() {\n\ty := 2\n}\n")
+	f.CommitAs("C", jane)
+
+	adp := enginetest.Clone(t, func() engine.RepositoryAdapter { return gogit.NewAdapter() }, f)
+	repo := engine.NewRepository(identifier.RepositoryID{}, "", &engine.RepositoryOpts{Adapter: adp})
+	if err := repo.IngestHead(ctx, f.Hash("C")); err != nil {
+		t.Fatal(err)
+	}
+
+	// the authors are credited only, the committer of the fixture is the same
+	history := newHistoryCache(repo.Roots().Slice(), true)
+	var files []*fileAnalysis
+	for _, label := range []string{"A", "fix foo", "C"} {
+		c, _ := repo.Commit(f.Hash(label))
+		obj, err := c.Object()
+		if err != nil {
+			t.Fatal(err)
+		}
+		files, err = analyzeFiles(ctx, obj, nil)
+		obj.Free()
+		if err != nil {
+			t.Fatal(err)
+		}
+		setLineMeasures(files, false)
+		c.SetFiles(codeFilesList(files))
+
+		if label == "fix foo" {
+			files[0].Fix = true
+		}
+		if label == "C" {
+			state, err := history.State(ctx, c.FirstParent())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := calculateMetrics(ctx, c, files, state, true); err != nil {
+				t.Fatal(err)
+			}
+			break
+		}
+
+		if err := history.Add(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want := map[string]metrics.FunctionMeasures{
+		"foo": {LA: 1, LD: 1, NDEV: 2, NUC: 2, NFC: 1},
+		"bar": {LA: 1, LD: 1, NDEV: 1, NUC: 1},
+	}
+	if len(files[0].functions) != len(want) {
+		t.Fatalf("expected %d changed functions, got %v", len(want), files[0].Functions)
+	}
+	for _, fn := range files[0].functions {
+		if fn.FunctionMeasures != want[fn.function.Name] {
+			t.Errorf("%s: got %+v, want %+v", fn.function.Name, fn.FunctionMeasures, want[fn.function.Name])
+		}
+	}
+}
+
+// parallelAnalyzer calculates the metrics of the commits concurrently, only the
+// git objects are read one at a time because go-git does not support concurrent
+// reads.
+type parallelAnalyzer struct {
+	history *historyCache
+	metrics map[engine.Commit]*metrics.ChangeMeasures
+	gitMu   sync.Mutex
+	mu      sync.Mutex
+}
+
+func (a *parallelAnalyzer) AnalyzeCommit(ctx context.Context, c engine.Commit) error {
+	a.gitMu.Lock()
+	obj, err := c.Object()
+	if err != nil {
+		a.gitMu.Unlock()
+		return err
+	}
+	files, err := analyzeFiles(ctx, obj, nil)
+	obj.Free()
+	a.gitMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	setLineMeasures(files, false)
+	c.SetFiles(codeFilesList(files))
+
+	if !c.IsMerge() && c.HasParent() {
+		history, err := a.history.State(ctx, c.FirstParent())
+		if err != nil {
+			return err
+		}
+
+		m, err := calculateMetrics(ctx, c, files, history, false)
+		if err != nil && err != ErrNoFilesForCalculation {
+			return err
+		}
+		if m != nil {
+			a.mu.Lock()
+			a.metrics[c] = m
+			a.mu.Unlock()
+		}
+	}
+
+	return a.history.Add(ctx, c)
+}
+
+func (a *parallelAnalyzer) Finish(context.Context) error {
+	return nil
+}
+
+// equalMeasures compares the measures up to the floating point summation order.
+func equalMeasures(want, got interface{}) bool {
+	wantValues, gotValues := reflect.ValueOf(want), reflect.ValueOf(got)
+	for i := 0; i < wantValues.NumField(); i++ {
+		wantField, gotField := wantValues.Field(i), gotValues.Field(i)
+		if wantField.Kind() == reflect.Ptr {
+			if wantField.IsNil() || gotField.IsNil() {
+				if wantField.IsNil() != gotField.IsNil() {
+					return false
+				}
+				continue
+			}
+			wantField, gotField = wantField.Elem(), gotField.Elem()
+		}
+
+		w, g := wantField.Float(), gotField.Float()
+		if math.Abs(w-g) > 1e-9*math.Max(1, math.Abs(w)) {
+			return false
+		}
+	}
+	return true
+}
+
+// walkMetrics is the reference of calculating the metrics by visiting all the
+// ancestors of every commit, the history states should give the same results.
+func walkMetrics(ctx context.Context, c engine.Commit, analyzedFiles []*fileAnalysis, authorOnly bool) (*metrics.ChangeMeasures, error) {
+	var devs = creditedDevelopers(c, authorOnly)
+	var subsystems = engine.NewStringSet()
+	var directories = engine.NewStringSet()
+	var trackedFiles = make(map[string]*fileAnalysis, len(analyzedFiles))
+	var nf, nfWithAge, la, ld, ha, hd, lt, sexp, exp, age, rexp float64
+
+	for _, fa := range analyzedFiles {
+		if isIgnoredFile(fa) {
+			continue
+		}
+
+		subsystems.Add(fa.Subsystem)
+		directories.Add(fa.Directory())
+
+		if fa.Action != engine.DeltaAdded {
+			lastChange, err := engine.LastFileChange(ctx, c, fa.OldOrNewPath())
+			if err != nil {
+				lastChange = c
+				log.Ctx(ctx).Err(err).
+					Hex("commit", c.Hash().Bytes()).
+					Str("file", fa.OldOrNewPath()).
+					Msg("find last change for a file")
+			} else {
+				fa.SameDeveloper = haveCommonDeveloper(devs, creditedDevelopers(lastChange, authorOnly))
+			}
+
+			fa.AGE = c.AuthorDate().Sub(lastChange.AuthorDate()).Hours() / 24
+			if fa.AGE > 0 {
+				nfWithAge += 1
+				age += fa.AGE
+			}
+		}
+
+		// the renamed and copied files are tracked from their sources
+		trackedFiles[fa.OldOrNewPath()] = fa
+
+		// Sum the file metrics
+		nf += 1
+		la += fa.LA
+		ld += fa.LD
+		ha += fa.HA
+		hd += fa.HD
+		lt += fa.LT
+	}
+
+	if nf == 0 {
+		return nil, ErrNoFilesForCalculation
+	}
+
+	if nfWithAge > 0 {
+		age = age / nfWithAge
+	}
+
+	changes := engine.NewCommitSet()
+	developers := engine.NewDeveloperSet()
+
+	err := visitAncestors(ctx, c, trackedFiles, func(p engine.Commit, trackedFiles map[string]*fileAnalysis) {
+		var commonDevSubsystem bool
+		var commonDevFile bool
+		var commonFile bool
+		var pDevs = creditedDevelopers(p, authorOnly)
+		var commonDev = haveCommonDeveloper(devs, pDevs)
+		var bot = p.Bot()
+
+		for filepath, f := range p.Files() {
+			// common files
+			if fa, ok := trackedFiles[filepath]; ok {
+				if bot {
+					followRename(trackedFiles, filepath, f, fa)
+					continue
+				}
+
+				if !commonFile {
+					commonFile = true
+					if commonDev {
+						commonDevFile = true
+					}
+
+					changes.Add(p)
+					developers.Update(pDevs)
+				}
+
+				for dev := range pDevs {
+					if !fa.Developers.Has(dev) {
+						fa.Developers.Add(dev)
+						fa.NDEV += 1
+					}
+				}
+
+				if f.Fix {
+					fa.NFC += 1
+				}
+
+				if commonDev {
+					fa.EXP += 1
+					fa.REXP += 1 / calculatePositiveAge(c, p)
+				}
+
+				fa.NUC += 1
+
+				followRename(trackedFiles, filepath, f, fa)
+			}
+
+			// common developer subsystems
+			if commonDev && !commonDevSubsystem && subsystems.Has(f.Subsystem) {
+				commonDevSubsystem = true
+			}
+		}
+
+		if nf == 0 || bot {
+			// Do not count experience if no code files or for the bots
+			return
+		}
+
+		if commonDev {
+			exp += 1
+		}
+
+		if commonDevSubsystem {
+			sexp += 1
+		}
+
+		if commonDevFile {
+			rexp += 1 / calculatePositiveAge(c, p)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &metrics.ChangeMeasures{
+		NS:      float64(subsystems.Count()),
+		ND:      float64(directories.Count()),
+		NF:      nf,
+		Entropy: calculateEntropy(la, ld, analyzedFiles),
+		LA:      la,
+		LD:      ld,
+		HA:      ha,
+		HD:      hd,
+		LT:      lt / nf, // fixme: should we keep it without normalizin?
+		NDEV:    float64(developers.Count()),
+		AGE:     age,
+		NUC:     float64(changes.Count()),
+		EXP:     exp,
+		REXP:    rexp,
+		SEXP:    sexp,
+	}, nil
+}
+
+func visitAncestors(ctx context.Context, c engine.Commit, trackedFiles map[string]*fileAnalysis, fn func(engine.Commit, map[string]*fileAnalysis)) error {
+	if !c.HasParent() {
+		return nil
+	}
+
+	seen := engine.NewCommitSet()
+	commitsStack := engine.CommitsStack{c.FirstParent()}
+	filesStack := fileAnalysisStack{trackedFiles}
+
+	// skip the  current commit and add its parents
+
+	for !commitsStack.IsEmpty() {
+		c := commitsStack.Pop()
+		f := filesStack.Pop()
+		fn(c, f)
+
+		parents := c.Parents()
+		for i := len(parents) - 1; i >= 0; i -= 1 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+			p := parents[i]
+
+			if seen.Has(p) {
+				continue
+			}
+
+			if i > 0 {
+				f = cloneFileAnalysis(f)
+			}
+
+			seen.Add(p)
+			commitsStack.Push(p)
+			filesStack.Push(f)
+		}
+	}
+	return nil
+}
+
+func cloneFileAnalysis(m map[string]*fileAnalysis) map[string]*fileAnalysis {
+	r := make(map[string]*fileAnalysis, len(m))
+	for k, v := range m {
+		r[k] = v
+	}
+	return r
+}
+
+type fileAnalysisStack []map[string]*fileAnalysis
+
+func (s *fileAnalysisStack) Pop() map[string]*fileAnalysis {
+	n := len(*s) - 1
+	// ge the last item
+	item := (*s)[n]
+	// avoid memory leak
+	(*s)[n] = nil
+	// delete the item from the stack
+	*s = (*s)[:n]
+	return item
+}
+
+func (s *fileAnalysisStack) Push(item map[string]*fileAnalysis) {
+	*s = append(*s, item)
+}
+
+func (s fileAnalysisStack) IsEmpty() bool {
+	return len(s) == 0
+}
+
+// followRename adjusts the tracked files to follow the renames and the copies.
+func followRename(trackedFiles map[string]*fileAnalysis, filepath string, f *engine.FileInfo, fa *fileAnalysis) {
+	switch f.Action {
+	case engine.DeltaAdded:
+		delete(trackedFiles, filepath)
+	case engine.DeltaRenamed, engine.DeltaCopied:
+		delete(trackedFiles, filepath)
+		trackedFiles[f.OldPath] = fa
+	}
+}
@@ -0,0 +1,150 @@
+package analysis
+
+import (
+	"context"
+
+	"github.com/repofuel/repofuel/ingest/internal/entity"
+	"github.com/repofuel/repofuel/ingest/pkg/classify"
+	"github.com/repofuel/repofuel/ingest/pkg/engine"
+	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+	"github.com/repofuel/repofuel/ingest/pkg/subsystem"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const subsystemsBatchSize = 1000
+
+var subsystemFilesOpts = options.Find().SetProjection(bson.M{
+	"files.path":       1,
+	"files.type":       1,
+	"files.metrics.la": 1,
+	"files.metrics.ld": 1,
+	"metrics.ns":       1,
+	"merge":            1,
+})
+
+// SubsystemsRecomputation recomputes the subsystems of the analyzed commits and
+// their NS and SEXP metrics, after the subsystem config of the repository is
+// changed. The other metrics do not depend on the subsystems and they are kept.
+// The files of the commits should be loaded in the engine before running it.
+type SubsystemsRecomputation struct {
+	repo       *engine.Repository
+	tracker    ProgressTracker
+	subsystems *subsystem.Mapper
+	authorOnly bool
+	history    *historyCache
+	commits    map[identifier.Hash]*entity.Commit
+	pending    []*entity.CommitSubsystemsHolder
+
+	commitsDB entity.CommitDataSource
+}
+
+func NewSubsystemsRecomputation(repo *engine.Repository, commitsDB entity.CommitDataSource, tracker ProgressTracker, opts *Options) *SubsystemsRecomputation {
+	return &SubsystemsRecomputation{
+		repo:       repo,
+		tracker:    tracker,
+		subsystems: opts.Subsystems,
+		authorOnly: opts.Metrics != nil && opts.Metrics.AuthorOnly,
+		commitsDB:  commitsDB,
+	}
+}
+
+func (r *SubsystemsRecomputation) Run(ctx context.Context) error {
+	itr, err := r.commitsDB.FindRepoCommits(ctx, r.repo.ID, subsystemFilesOpts)
+	if err != nil {
+		return err
+	}
+
+	r.commits = make(map[identifier.Hash]*entity.Commit)
+	err = itr.ForEach(ctx, func(c *entity.Commit) error {
+		r.commits[c.ID.CommitHash] = c
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	roots := r.repo.Roots().Slice()
+	r.history = newHistoryCache(roots, r.authorOnly)
+
+	return engine.RunForwardAnalysis(ctx, r, roots)
+}
+
+func (r *SubsystemsRecomputation) AnalyzeCommit(ctx context.Context, c engine.Commit) error {
+	r.tracker.IncreaseProgress(1)
+
+	// the history of the children is built from the new subsystems
+	for _, f := range c.Files() {
+		f.Subsystem = r.subsystems.Subsystem(f.Path)
+	}
+
+	ec, ok := r.commits[c.Hash()]
+	if ok {
+		holder, err := r.recompute(ctx, c, ec)
+		if err != nil {
+			return err
+		}
+
+		if holder != nil {
+			r.pending = append(r.pending, holder)
+		}
+		if len(r.pending) >= subsystemsBatchSize {
+			err = r.flush(ctx)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return r.history.Add(ctx, c)
+}
+
+// recompute maps the code files of the stored commit, and counts the subsystems
+// of the files that change code, as calculateMetrics does.
+func (r *SubsystemsRecomputation) recompute(ctx context.Context, c engine.Commit, ec *entity.Commit) (*entity.CommitSubsystemsHolder, error) {
+	holder := &entity.CommitSubsystemsHolder{
+		ID:         ec.ID,
+		Subsystems: make(map[int]string),
+	}
+
+	subsystems := engine.NewStringSet()
+	for i, f := range ec.Files {
+		if f.Type != classify.FileCode {
+			continue
+		}
+
+		s := r.subsystems.Subsystem(f.Path)
+		holder.Subsystems[i] = s
+		if f.Metrics != nil && f.Metrics.LA+f.Metrics.LD > 0 {
+			subsystems.Add(s)
+		}
+	}
+
+	if ec.Metrics != nil && !ec.Merge {
+		holder.Metrics = &entity.SubsystemMeasures{NS: float64(subsystems.Count())}
+
+		if c.HasParent() {
+			history, err := r.history.State(ctx, c.FirstParent())
+			if err != nil {
+				return nil, err
+			}
+			devs := creditedDevelopers(c, r.authorOnly)
+			holder.Metrics.SEXP = float64(countDeveloperChanges(history, devs, subsystems, r.authorOnly))
+		}
+	}
+
+	if len(holder.Subsystems) == 0 && holder.Metrics == nil {
+		return nil, nil
+	}
+	return holder, nil
+}
+
+func (r *SubsystemsRecomputation) flush(ctx context.Context) error {
+	err := r.commitsDB.SaveSubsystems(ctx, r.pending...)
+	r.pending = r.pending[:0]
+	return err
+}
+
+func (r *SubsystemsRecomputation) Finish(ctx context.Context) error {
+	return r.flush(ctx)
+}
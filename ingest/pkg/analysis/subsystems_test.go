@@ -0,0 +1,189 @@
+package analysis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/repofuel/repofuel/ingest/internal/entity"
+	"github.com/repofuel/repofuel/ingest/pkg/classify"
+	"github.com/repofuel/repofuel/ingest/pkg/engine"
+	"github.com/repofuel/repofuel/ingest/pkg/engine/enginetest"
+	"github.com/repofuel/repofuel/ingest/pkg/engine/gogit"
+	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+	"github.com/repofuel/repofuel/ingest/pkg/identity"
+	"github.com/repofuel/repofuel/ingest/pkg/subsystem"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type progress struct{}
+
+func (progress) IncreaseProgress(int) {}
+
+// commitsDB keeps the commits in memory, the other methods are not implemented.
+type commitsDB struct {
+	entity.CommitDataSource
+	commits []*entity.Commit
+	saved   map[identifier.Hash]*entity.CommitSubsystemsHolder
+}
+
+func (db *commitsDB) FindRepoCommits(context.Context, identifier.RepositoryID, ...*options.FindOptions) (entity.CommitIter, error) {
+	return commitIter(db.commits), nil
+}
+
+func (db *commitsDB) SaveSubsystems(_ context.Context, holders ...*entity.CommitSubsystemsHolder) error {
+	for _, h := range holders {
+		db.saved[h.ID.CommitHash] = h
+	}
+	return nil
+}
+
+type commitIter []*entity.Commit
+
+func (itr commitIter) ForEach(_ context.Context, fn func(*entity.Commit) error) error {
+	for _, c := range itr {
+		if err := fn(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (itr commitIter) Slice(context.Context) ([]*entity.Commit, error) {
+	return itr, nil
+}
+
+// subsystemsAnalyzer analyzes the commits as RepositoryAnalysis does, and keeps
+// the commits that it would store.
+type subsystemsAnalyzer struct {
+	subsystems *subsystem.Mapper
+	history    *historyCache
+	commits    []*entity.Commit
+}
+
+func (a *subsystemsAnalyzer) AnalyzeCommit(ctx context.Context, c engine.Commit) error {
+	obj, err := c.Object()
+	if err != nil {
+		return err
+	}
+	files, err := analyzeFiles(ctx, obj, nil)
+	obj.Free()
+	if err != nil {
+		return err
+	}
+
+	ec := &entity.Commit{ID: identifier.NewCommitID(identifier.RepositoryID{}, c.Hash()), Merge: c.IsMerge()}
+	for _, fa := range files {
+		if fa.Type == classify.FileCode {
+			fa.Subsystem = a.subsystems.Subsystem(fa.Path)
+		}
+	}
+	setLineMeasures(files, false)
+	c.SetFiles(codeFilesList(files))
+
+	if !c.IsMerge() {
+		var history *historyState
+		if c.HasParent() {
+			history, err = a.history.State(ctx, c.FirstParent())
+			if err != nil {
+				return err
+			}
+		}
+
+		ec.Metrics, err = calculateMetrics(ctx, c, files, history, false)
+		if err != nil && err != ErrNoFilesForCalculation {
+			return err
+		}
+	}
+
+	for _, fa := range files {
+		ec.Files = append(ec.Files, &entity.File{FileInfo: fa.FileInfo, Type: fa.Type, Metrics: &fa.FileMeasures})
+	}
+	a.commits = append(a.commits, ec)
+
+	return a.history.Add(ctx, c)
+}
+
+func (a *subsystemsAnalyzer) Finish(context.Context) error {
+	return nil
+}
+
+func analyzeSubsystems(t *testing.T, f *enginetest.Fixture, heads []string, mapper *subsystem.Mapper) (*engine.Repository, []*entity.Commit) {
+	ctx := context.Background()
+	adp := enginetest.Clone(t, func() engine.RepositoryAdapter { return gogit.NewAdapter() }, f)
+	repo := engine.NewRepository(identifier.RepositoryID{}, "", &engine.RepositoryOpts{Adapter: adp})
+	repo.SetBotDetector(identity.NewBotDetector(nil))
+	for _, h := range heads {
+		if err := repo.IngestHead(ctx, f.Hash(h)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	roots := repo.Roots().Slice()
+	a := &subsystemsAnalyzer{subsystems: mapper, history: newHistoryCache(roots, false)}
+	if err := engine.RunForwardAnalysis(ctx, a, roots); err != nil {
+		t.Fatal(err)
+	}
+	return repo, a.commits
+}
+
+// TestSubsystemsRecomputation recomputes the subsystems of a repository that is
+// analyzed by the first directories, the results should match analyzing it by
+// the new subsystems.
+func TestSubsystemsRecomputation(t *testing.T) {
+	ctx := context.Background()
+	f, _, heads := historyFixture(t)
+
+	mapper, err := subsystem.New(&subsystem.Config{Rules: []*subsystem.Rule{
+		{Pattern: "src/b.go", Name: "core"},
+		{Pattern: "lib/**", Name: "core"},
+	}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo, stored := analyzeSubsystems(t, f, heads, nil)
+	_, want := analyzeSubsystems(t, f, heads, mapper)
+
+	oldMetrics := make(map[identifier.Hash]*entity.ChangeMeasures)
+	for _, ec := range stored {
+		oldMetrics[ec.ID.CommitHash] = ec.Metrics
+	}
+
+	db := &commitsDB{commits: stored, saved: make(map[identifier.Hash]*entity.CommitSubsystemsHolder)}
+	r := NewSubsystemsRecomputation(repo, db, progress{}, &Options{Subsystems: mapper})
+	if err := r.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var changed int
+	for _, ec := range want {
+		h := db.saved[ec.ID.CommitHash]
+		if h == nil {
+			t.Fatalf("%s: expected the subsystems to be saved", ec.ID.CommitHash)
+		}
+
+		for j, file := range ec.Files {
+			if s, ok := h.Subsystems[j]; file.Type == classify.FileCode && (!ok || s != file.Subsystem) {
+				t.Errorf("%s: subsystem of %s: got %q, want %q", ec.ID.CommitHash, file.Path, s, file.Subsystem)
+			}
+		}
+
+		if ec.Metrics == nil {
+			if h.Metrics != nil {
+				t.Errorf("%s: expected no metrics, got %+v", ec.ID.CommitHash, h.Metrics)
+			}
+			continue
+		}
+
+		if h.Metrics == nil || h.Metrics.NS != ec.Metrics.NS || h.Metrics.SEXP != ec.Metrics.SEXP {
+			t.Errorf("%s: got %+v, want NS %v and SEXP %v", ec.ID.CommitHash, h.Metrics, ec.Metrics.NS, ec.Metrics.SEXP)
+		}
+		if old := oldMetrics[ec.ID.CommitHash]; old.NS != ec.Metrics.NS || old.SEXP != ec.Metrics.SEXP {
+			changed += 1
+		}
+	}
+
+	if changed == 0 {
+		t.Error("expected the new subsystems to change the metrics of some commits")
+	}
+}
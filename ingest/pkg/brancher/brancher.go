@@ -67,20 +67,17 @@ func (r *Rearrange) AnalyzeCommit(ctx context.Context, c engine.Commit) error {
 }
 
 func (r *Rearrange) Finish(ctx context.Context) error {
-	// update commit branches (removed branches)
+	// update commit branches (removed branches), the branches that are excluded
+	// by the branch filter are removed too
 	deletedBranches := engine.DeletedBranches(r.analyzeBranches, r.branches)
+	var excluded bool
 	for _, name := range deletedBranches {
 		err := r.commitsDB.RemoveBranch(ctx, r.repo.ID, name)
 		if err != nil {
 			return err
 		}
-	}
 
-	if len(deletedBranches) > 0 {
-		err := r.commitsDB.Prune(ctx, r.repo.ID)
-		if err != nil {
-			return err
-		}
+		excluded = excluded || !r.repo.IsBranchSelected(name)
 	}
 
 	addedBranches := engine.AddedBranches(r.analyzeBranches, r.branches)
@@ -107,5 +104,11 @@ func (r *Rearrange) Finish(ctx context.Context) error {
 		}
 	}
 
+	// the commits of the excluded branches are pruned after re-tagging, if no
+	// other branch or pull request has them
+	if excluded {
+		return r.commitsDB.Prune(ctx, r.repo.ID)
+	}
+
 	return nil
 }
@@ -0,0 +1,80 @@
+// Package branchfilter selects the branches of a repository that are analyzed,
+// the repositories with many stale branches can limit the analysis to the
+// branches that matter, such as the default and the release branches.
+package branchfilter
+
+import (
+	"fmt"
+	"path"
+)
+
+// Config is the branch patterns of a repository, a nil config selects all the
+// branches. The patterns are matched against the whole branch name, where `*`
+// does not match a slash, e.g. `release/*` matches `release/1.0`.
+type Config struct {
+	// Include selects the matching branches only, all the branches are
+	// selected if it is empty.
+	Include []string `json:"include,omitempty"  bson:"include,omitempty"`
+	// Exclude drops the matching branches, it takes precedence over Include.
+	Exclude []string `json:"exclude,omitempty"  bson:"exclude,omitempty"`
+}
+
+// Validate checks the syntax of the patterns.
+func (cfg *Config) Validate() error {
+	if cfg == nil {
+		return nil
+	}
+
+	for _, patterns := range [][]string{cfg.Include, cfg.Exclude} {
+		for _, p := range patterns {
+			if _, err := path.Match(p, ""); err != nil {
+				return fmt.Errorf("invalid branch pattern %q", p)
+			}
+		}
+	}
+	return nil
+}
+
+// Filter matches the branch names by a config, the default branch is always
+// selected because the predictions are made on it. A nil filter selects all
+// the branches.
+type Filter struct {
+	cfg           *Config
+	defaultBranch string
+}
+
+// New creates a filter for the config, it returns nil if the config does not
+// filter any branch.
+func New(cfg *Config, defaultBranch string) (*Filter, error) {
+	if cfg == nil || len(cfg.Include) == 0 && len(cfg.Exclude) == 0 {
+		return nil, nil
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &Filter{cfg: cfg, defaultBranch: defaultBranch}, nil
+}
+
+// Match reports whether the branch is selected.
+func (f *Filter) Match(name string) bool {
+	if f == nil || name == f.defaultBranch {
+		return true
+	}
+
+	if matchAny(f.cfg.Exclude, name) {
+		return false
+	}
+
+	return len(f.cfg.Include) == 0 || matchAny(f.cfg.Include, name)
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
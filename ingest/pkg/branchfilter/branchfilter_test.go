@@ -0,0 +1,59 @@
+package branchfilter
+
+import "testing"
+
+func TestFilter_Match(t *testing.T) {
+	f, err := New(&Config{
+		Include: []string{"main", "release/*", "develop"},
+		Exclude: []string{"release/*-rc", "develop"},
+	}, "trunk")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]bool{
+		"main":           true,
+		"trunk":          true,
+		"release/1.0":    true,
+		"release/2.0-rc": false,
+		"release/1.0/x":  false,
+		"develop":        false,
+		"feature/login":  false,
+	}
+	for name, want := range cases {
+		if got := f.Match(name); got != want {
+			t.Errorf("%s: got %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestFilter_ExcludeOnly(t *testing.T) {
+	f, err := New(&Config{Exclude: []string{"dependabot/*/*"}}, "master")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !f.Match("feature") {
+		t.Error("expected the branches to be included by default")
+	}
+	if f.Match("dependabot/npm_and_yarn/lodash") {
+		t.Error("expected the dependabot branch to be excluded")
+	}
+}
+
+func TestNew(t *testing.T) {
+	for _, cfg := range []*Config{nil, {}} {
+		f, err := New(cfg, "master")
+		if err != nil || f != nil {
+			t.Errorf("%+v: expected a nil filter, got %v, %v", cfg, f, err)
+		}
+		if !f.Match("any") {
+			t.Error("expected a nil filter to match all the branches")
+		}
+	}
+
+	_, err := New(&Config{Include: []string{"release/["}}, "master")
+	if err == nil {
+		t.Error("expected an error for an invalid pattern")
+	}
+}
@@ -17,6 +17,7 @@ var (
 		"FileDependency":    FileDependency,
 		"FileTests":         FileTests,
 		"FileSymlink":       FileSymlink,
+		"FileIgnored":       FileIgnored,
 	}
 
 	_FileTypeValueToName = map[FileType]string{
@@ -28,6 +29,7 @@ var (
 		FileDependency:    "FileDependency",
 		FileTests:         "FileTests",
 		FileSymlink:       "FileSymlink",
+		FileIgnored:       "FileIgnored",
 	}
 )
 
@@ -43,6 +45,7 @@ func init() {
 			interface{}(FileDependency).(fmt.Stringer).String():    FileDependency,
 			interface{}(FileTests).(fmt.Stringer).String():         FileTests,
 			interface{}(FileSymlink).(fmt.Stringer).String():       FileSymlink,
+			interface{}(FileIgnored).(fmt.Stringer).String():       FileIgnored,
 		}
 	}
 }
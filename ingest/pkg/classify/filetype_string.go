@@ -16,11 +16,12 @@ func _() {
 	_ = x[FileDependency-6]
 	_ = x[FileTests-7]
 	_ = x[FileSymlink-8]
+	_ = x[FileIgnored-9]
 }
 
-const _FileType_name = "BinaryConfigurationDocumentationCodeGeneratedDependencyTestsSymlink"
+const _FileType_name = "BinaryConfigurationDocumentationCodeGeneratedDependencyTestsSymlinkIgnored"
 
-var _FileType_index = [...]uint8{0, 6, 19, 32, 36, 45, 55, 60, 67}
+var _FileType_index = [...]uint8{0, 6, 19, 32, 36, 45, 55, 60, 67, 74}
 
 func (i FileType) String() string {
 	i -= 1
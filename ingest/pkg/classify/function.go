@@ -0,0 +1,504 @@
+package classify
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Function is a function or a method in a content, its lines are numbered from
+// one and both of the start and the end lines are included.
+type Function struct {
+	// Name is qualified by the names of the enclosing classes and functions,
+	// such as "Parser.parse".
+	Name string
+	// Signature is the declared parameter list with the spaces collapsed, such
+	// as "(String s)", it tells the overloads apart. It is empty for the
+	// languages that have no overloads.
+	Signature string
+	Start     int
+	End       int
+}
+
+// Key identifies the function in its content.
+func (fn *Function) Key() string {
+	return fn.Name + fn.Signature
+}
+
+// Functions are the functions of a content ordered by their start lines.
+type Functions []Function
+
+// At returns the innermost function that encloses the line, or nil if the line
+// is out of all the functions.
+func (fns Functions) At(line int) *Function {
+	var found *Function
+	for i := range fns {
+		if fns[i].Start > line {
+			break
+		}
+		if fns[i].End >= line {
+			found = &fns[i]
+		}
+	}
+	return found
+}
+
+// braceLanguages are the enry languages that their functions are found from the
+// blocks of braces.
+var braceLanguages = map[string]bool{
+	"C":           true,
+	"C#":          true,
+	"C++":         true,
+	"Dart":        true,
+	"Groovy":      true,
+	"Java":        true,
+	"JavaScript":  true,
+	"Kotlin":      true,
+	"PHP":         true,
+	"Rust":        true,
+	"Scala":       true,
+	"Swift":       true,
+	"TypeScript":  true,
+	"TSX":         true,
+	"Objective-C": true,
+}
+
+// FindFunctions returns the functions of the content, or nil if the language is
+// not supported. Go files are parsed, while the other languages are scanned by
+// a heuristic which could miss some functions of unusual declarations.
+func FindFunctions(lang string, content []byte) Functions {
+	switch {
+	case lang == "Go":
+		if fns, ok := goFunctions(content); ok {
+			return fns
+		}
+		// the content could have syntax errors
+		return braceFunctions(content)
+	case lang == "Python":
+		return pythonFunctions(content)
+	case braceLanguages[lang]:
+		return braceFunctions(content)
+	}
+	return nil
+}
+
+func goFunctions(content []byte) (Functions, bool) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", content, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, false
+	}
+
+	var fns Functions
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+
+		name := fn.Name.Name
+		if fn.Recv != nil && len(fn.Recv.List) > 0 {
+			if recv := receiverName(fn.Recv.List[0].Type); recv != "" {
+				name = recv + "." + name
+			}
+		}
+
+		fns = append(fns, Function{
+			Name:  name,
+			Start: fset.Position(fn.Pos()).Line,
+			End:   fset.Position(fn.End()).Line,
+		})
+	}
+
+	return fns, true
+}
+
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(t.X)
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	case *ast.IndexListExpr:
+		return receiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// braceScope is a block of braces, only the functions and the classes have
+// names.
+type braceScope struct {
+	name      string
+	signature string
+	function  bool
+	start     int
+}
+
+// braceFunctions finds the functions from the headers of the blocks, a header
+// is the code between the block and the previous statement or block.
+func braceFunctions(content []byte) Functions {
+	var fns Functions
+	var stack []braceScope
+	var header []byte
+	var headerLine int
+	line, lineStart := 1, true
+
+	for i := 0; i < len(content); i++ {
+		ch := content[i]
+		if ch == '\n' {
+			line++
+			lineStart = true
+			header = append(header, ' ')
+			continue
+		}
+
+		if lineStart && ch == '#' {
+			// preprocessor directives and attributes do not belong to the headers
+			i = skipLine(content, i)
+			continue
+		}
+		if ch != ' ' && ch != '\t' && ch != '\r' {
+			lineStart = false
+		}
+
+		switch {
+		case ch == '/' && i+1 < len(content) && content[i+1] == '/':
+			i = skipLine(content, i)
+		case ch == '/' && i+1 < len(content) && content[i+1] == '*':
+			end := bytes.Index(content[i+2:], []byte("*/"))
+			if end < 0 {
+				end = len(content) - i - 4
+			}
+			line += bytes.Count(content[i:i+end+4], []byte{'\n'})
+			i += end + 3
+		case ch == '"' || ch == '\'' || ch == '`':
+			end := skipString(content, i)
+			line += bytes.Count(content[i:end], []byte{'\n'})
+			header = append(header, ' ')
+			i = end
+		case ch == '{':
+			scope := parseBraceHeader(string(header))
+			scope.start = headerLine
+			if scope.start == 0 {
+				scope.start = line
+			}
+			if scope.name != "" {
+				scope.name = qualify(stack, scope.name)
+			}
+			stack = append(stack, scope)
+			header, headerLine = header[:0], 0
+		case ch == '}':
+			if n := len(stack); n > 0 {
+				scope := stack[n-1]
+				stack = stack[:n-1]
+				if scope.function {
+					fns = append(fns, Function{Name: scope.name, Signature: scope.signature, Start: scope.start, End: line})
+				}
+			}
+			header, headerLine = header[:0], 0
+		case ch == ';':
+			header, headerLine = header[:0], 0
+		default:
+			if headerLine == 0 && ch != ' ' && ch != '\t' && ch != '\r' {
+				headerLine = line
+			}
+			header = append(header, ch)
+		}
+	}
+
+	sort.SliceStable(fns, func(i, j int) bool {
+		return fns[i].Start < fns[j].Start
+	})
+
+	return fns
+}
+
+// skipLine returns the index before the end of the line.
+func skipLine(content []byte, i int) int {
+	end := bytes.IndexByte(content[i:], '\n')
+	if end < 0 {
+		return len(content) - 1
+	}
+	return i + end - 1
+}
+
+// skipString returns the index of the closing quote of a string literal, only
+// the backtick strings could span multiple lines.
+func skipString(content []byte, i int) int {
+	quote := content[i]
+	for j := i + 1; j < len(content); j++ {
+		switch content[j] {
+		case '\\':
+			j++
+		case quote:
+			return j
+		case '\n':
+			if quote != '`' {
+				return j - 1
+			}
+		}
+	}
+	return len(content) - 1
+}
+
+var (
+	classPattern    = regexp.MustCompile(`(?:^|\s)(?:class|interface|struct|enum|trait|object|record)\s+([A-Za-z_$][\w$]*)`)
+	assignedPattern = regexp.MustCompile(`([A-Za-z_$][\w$]*)\s*[:=]\s*(?:async\s+)?(?:function\b|\([^()]*\)\s*(?::[^=]*)?=>|[A-Za-z_$][\w$]*\s*=>)`)
+)
+
+var controlKeywords = map[string]bool{
+	"case": true, "catch": true, "do": true, "else": true, "finally": true,
+	"for": true, "foreach": true, "if": true, "lock": true, "return": true,
+	"switch": true, "synchronized": true, "try": true, "using": true,
+	"while": true, "with": true,
+}
+
+// callKeywords are followed by parentheses without declaring functions.
+var callKeywords = map[string]bool{
+	"assert": true, "await": true, "catch": true, "delete": true, "for": true,
+	"foreach": true, "function": true, "if": true, "new": true, "return": true,
+	"sizeof": true, "super": true, "switch": true, "this": true, "throw": true,
+	"typeof": true, "while": true,
+}
+
+// parseBraceHeader finds whether the header of a block declares a function or
+// a class.
+func parseBraceHeader(header string) braceScope {
+	header = strings.TrimSpace(header)
+	if header == "" || controlKeywords[firstWord(header)] {
+		return braceScope{}
+	}
+
+	prefix := header
+	if i := strings.IndexByte(header, '('); i >= 0 {
+		prefix = header[:i]
+	}
+	if m := classPattern.FindStringSubmatch(prefix); m != nil {
+		return braceScope{name: m[1]}
+	}
+
+	if m := assignedPattern.FindStringSubmatch(header); m != nil {
+		return braceScope{name: m[1], function: true}
+	}
+
+	var depth int
+	for i := 0; i < len(header); i++ {
+		switch header[i] {
+		case '(':
+			if depth == 0 {
+				if name := calledName(header[:i]); name != "" {
+					if end := closingParen(header, i); end >= 0 {
+						signature := strings.Join(strings.Fields(header[i:end+1]), " ")
+						return braceScope{name: name, signature: signature, function: true}
+					}
+				}
+			}
+			depth++
+		case ')':
+			depth--
+		case '=':
+			if depth == 0 {
+				// the block is assigned, such as an initializer
+				return braceScope{}
+			}
+		}
+	}
+
+	return braceScope{}
+}
+
+// calledName returns the name that is followed by the parentheses, it is empty
+// if they are not preceded by a name of a declared function.
+func calledName(s string) string {
+	s = strings.TrimRight(s, " \t")
+	if strings.HasSuffix(s, ">") {
+		// the type parameters
+		if i := strings.LastIndexByte(s, '<'); i >= 0 {
+			s = strings.TrimRight(s[:i], " \t")
+		}
+	}
+
+	i := len(s)
+	for i > 0 && isNameByte(s[i-1]) {
+		i--
+	}
+	name := strings.Trim(s[i:], ":")
+	if name == "" || callKeywords[name] || (name[0] >= '0' && name[0] <= '9') {
+		return ""
+	}
+
+	before := strings.TrimRight(s[:i], " \t")
+	if strings.HasSuffix(before, ".") || strings.HasSuffix(before, "@") || lastWord(before) == "new" {
+		return ""
+	}
+
+	return name
+}
+
+func closingParen(s string, open int) int {
+	var depth int
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isNameByte(b byte) bool {
+	return b == '_' || b == '$' || b == ':' || b == '~' ||
+		(b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+}
+
+func firstWord(s string) string {
+	i := 0
+	for i < len(s) && isNameByte(s[i]) && s[i] != ':' {
+		i++
+	}
+	return s[:i]
+}
+
+func lastWord(s string) string {
+	i := len(s)
+	for i > 0 && isNameByte(s[i-1]) {
+		i--
+	}
+	return s[i:]
+}
+
+func qualify(stack []braceScope, name string) string {
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i].name != "" {
+			return stack[i].name + "." + name
+		}
+	}
+	return name
+}
+
+var pythonDefinition = regexp.MustCompile(`^[ \t]*(?:async[ \t]+)?(def|class)[ \t]+(\w+)`)
+
+type pythonScope struct {
+	indent   int
+	name     string
+	function bool
+	start    int
+}
+
+// pythonFunctions finds the functions from the indentation of their bodies, a
+// function ends at the last code line before a line that is not indented more
+// than its definition.
+func pythonFunctions(content []byte) Functions {
+	var fns Functions
+	var stack []pythonScope
+	var lastCode, depth int
+	var quote string
+	var joined bool
+
+	lines := bytes.Split(content, []byte{'\n'})
+	for i, l := range lines {
+		line := string(l)
+		continued := depth > 0 || quote != "" || joined
+		depth, quote, joined = scanPythonLine(line, depth, quote)
+
+		trimmed := strings.TrimSpace(line)
+		if continued || trimmed == "" || trimmed[0] == '#' {
+			if continued {
+				lastCode = i + 1
+			}
+			continue
+		}
+
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		for len(stack) > 0 && indent <= stack[len(stack)-1].indent {
+			scope := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if scope.function {
+				fns = append(fns, Function{Name: scope.name, Start: scope.start, End: lastCode})
+			}
+		}
+
+		if m := pythonDefinition.FindStringSubmatch(line); m != nil {
+			name := m[2]
+			if n := len(stack); n > 0 {
+				name = stack[n-1].name + "." + name
+			}
+			stack = append(stack, pythonScope{
+				indent:   indent,
+				name:     name,
+				function: m[1] == "def",
+				start:    i + 1,
+			})
+		}
+
+		lastCode = i + 1
+	}
+
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i].function {
+			fns = append(fns, Function{Name: stack[i].name, Start: stack[i].start, End: lastCode})
+		}
+	}
+
+	sort.SliceStable(fns, func(i, j int) bool {
+		return fns[i].Start < fns[j].Start
+	})
+
+	return fns
+}
+
+// scanPythonLine returns the depth of the open brackets and the quote of the
+// open triple-quoted string at the end of the line, and whether the line is
+// joined with the next one by a backslash.
+func scanPythonLine(line string, depth int, quote string) (int, string, bool) {
+	for i := 0; i < len(line); i++ {
+		if quote != "" {
+			if strings.HasPrefix(line[i:], quote) {
+				i += len(quote) - 1
+				quote = ""
+			} else if line[i] == '\\' {
+				i++
+			}
+			continue
+		}
+
+		switch ch := line[i]; ch {
+		case '#':
+			return depth, "", false
+		case '"', '\'':
+			q := string(ch)
+			if strings.HasPrefix(line[i:], strings.Repeat(q, 3)) {
+				quote = strings.Repeat(q, 3)
+				i += 2
+				continue
+			}
+
+			// the single-quoted strings end in the same line
+			for i++; i < len(line) && line[i] != ch; i++ {
+				if line[i] == '\\' {
+					i++
+				}
+			}
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			if depth > 0 {
+				depth--
+			}
+		}
+	}
+
+	joined := quote == "" && strings.HasSuffix(strings.TrimRight(line, " \t\r"), "\\")
+	return depth, quote, joined
+}
@@ -0,0 +1,211 @@
+package classify
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindFunctions(t *testing.T) {
+	tests := []struct {
+		name    string
+		lang    string
+		content string
+		want    Functions
+	}{
+		{
+			name: "go",
+			lang: "Go",
+			content: `package main
+
+// Run runs.
+func (s *Server) Run() {
+	go func() {
+	}()
+}
+
+func main() {}
+`,
+			want: Functions{
+				{Name: "Server.Run", Start: 4, End: 7},
+				{Name: "main", Start: 9, End: 9},
+			},
+		},
+		{
+			name: "go with syntax errors",
+			lang: "Go",
+			content: `package main
+
+func main() {
+	if x {
+`,
+			want: nil,
+		},
+		{
+			name: "java",
+			lang: "Java",
+			content: `package demo;
+
+public class Parser {
+    private int x = 1;
+
+    @Override
+    public String parse(String s) throws IOException {
+        if (s == null) {
+            return "}";
+        }
+        for (int i = 0; i < 2; i++) {
+        }
+        return s;
+    }
+
+    /* void commented() { } */
+    Parser(int x) { this.x = x; }
+}
+`,
+			want: Functions{
+				{Name: "Parser.parse", Signature: "(String s)", Start: 6, End: 14},
+				{Name: "Parser.Parser", Signature: "(int x)", Start: 17, End: 17},
+			},
+		},
+		{
+			name: "javascript",
+			lang: "JavaScript",
+			content: `import x from 'x';
+
+function load(path) {
+  items.forEach(function (item) {
+    console.log(item);
+  });
+}
+
+const handler = async (req, res) => {
+  res.send({ok: true});
+};
+
+class Store {
+  get(key) {
+    return this.items[key];
+  }
+}
+`,
+			want: Functions{
+				{Name: "load", Signature: "(path)", Start: 3, End: 7},
+				{Name: "handler", Start: 9, End: 11},
+				{Name: "Store.get", Signature: "(key)", Start: 14, End: 16},
+			},
+		},
+		{
+			name: "c++",
+			lang: "C++",
+			content: `#include <vector>
+
+int Foo::bar(int x) const
+{
+    return x;
+}
+
+struct point {
+    int x;
+};
+`,
+			want: Functions{
+				{Name: "Foo::bar", Signature: "(int x)", Start: 3, End: 6},
+			},
+		},
+		{
+			name: "java overloads",
+			lang: "Java",
+			content: `class Writer {
+    void write(int b) {
+    }
+
+    void write(byte[] b,
+               int off) {
+    }
+}
+`,
+			want: Functions{
+				{Name: "Writer.write", Signature: "(int b)", Start: 2, End: 3},
+				{Name: "Writer.write", Signature: "(byte[] b, int off)", Start: 5, End: 7},
+			},
+		},
+		{
+			name: "python",
+			lang: "Python",
+			content: `import os
+
+
+class Loader:
+    def load(
+        self,
+        path,
+    ):
+        text = """
+not a dedent
+"""
+        return text
+
+    # comment
+
+    async def close(self): pass
+
+
+def main():
+    def helper(x):
+        return x
+
+    return helper(1)
+`,
+			want: Functions{
+				{Name: "Loader.load", Start: 5, End: 12},
+				{Name: "Loader.close", Start: 16, End: 16},
+				{Name: "main", Start: 19, End: 23},
+				{Name: "main.helper", Start: 20, End: 21},
+			},
+		},
+		{
+			name:    "unsupported language",
+			lang:    "Markdown",
+			content: "# title\n",
+			want:    nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FindFunctions(tt.lang, []byte(tt.content))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindFunctions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFunctions_At(t *testing.T) {
+	fns := Functions{
+		{Name: "outer", Start: 1, End: 10},
+		{Name: "inner", Start: 3, End: 5},
+		{Name: "next", Start: 12, End: 14},
+	}
+
+	tests := []struct {
+		line int
+		want string
+	}{
+		{line: 1, want: "outer"},
+		{line: 4, want: "inner"},
+		{line: 6, want: "outer"},
+		{line: 11, want: ""},
+		{line: 14, want: "next"},
+	}
+
+	for _, tt := range tests {
+		var got string
+		if fn := fns.At(tt.line); fn != nil {
+			got = fn.Name
+		}
+		if got != tt.want {
+			t.Errorf("At(%d) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package classify
+
+import (
+	"bytes"
+)
+
+type LineType uint8
+
+const (
+	LineCode LineType = iota + 1
+	LineBlank
+	LineComment
+)
+
+type commentSyntax struct {
+	line       []string
+	blockStart string
+	blockEnd   string
+}
+
+var (
+	cStyleComments    = &commentSyntax{line: []string{"//"}, blockStart: "/*", blockEnd: "*/"}
+	hashComments      = &commentSyntax{line: []string{"#"}}
+	dashComments      = &commentSyntax{line: []string{"--"}}
+	semicolonComments = &commentSyntax{line: []string{";"}}
+	percentComments   = &commentSyntax{line: []string{"%"}}
+)
+
+// commentSyntaxes maps the enry languages to their comment syntax, the lines
+// of the languages that are not listed are classified as code or blank only.
+var commentSyntaxes = map[string]*commentSyntax{
+	"C":             cStyleComments,
+	"C#":            cStyleComments,
+	"C++":           cStyleComments,
+	"Dart":          cStyleComments,
+	"Go":            cStyleComments,
+	"Groovy":        cStyleComments,
+	"Java":          cStyleComments,
+	"JavaScript":    cStyleComments,
+	"Kotlin":        cStyleComments,
+	"Objective-C":   cStyleComments,
+	"Objective-C++": cStyleComments,
+	"Rust":          cStyleComments,
+	"Scala":         cStyleComments,
+	"Swift":         cStyleComments,
+	"TypeScript":    cStyleComments,
+	"TSX":           cStyleComments,
+	"PHP":           {line: []string{"//", "#"}, blockStart: "/*", blockEnd: "*/"},
+	"CSS":           {blockStart: "/*", blockEnd: "*/"},
+	"SCSS":          cStyleComments,
+	"Less":          cStyleComments,
+	"Python":        hashComments,
+	"Ruby":          {line: []string{"#"}, blockStart: "=begin", blockEnd: "=end"},
+	"Perl":          hashComments,
+	"R":             hashComments,
+	"Shell":         hashComments,
+	"PowerShell":    {line: []string{"#"}, blockStart: "<#", blockEnd: "#>"},
+	"Elixir":        hashComments,
+	"CoffeeScript":  {line: []string{"#"}, blockStart: "###", blockEnd: "###"},
+	"Julia":         {line: []string{"#"}, blockStart: "#=", blockEnd: "=#"},
+	"Nim":           hashComments,
+	"Haskell":       {line: []string{"--"}, blockStart: "{-", blockEnd: "-}"},
+	"Lua":           {line: []string{"--"}, blockStart: "--[[", blockEnd: "]]"},
+	"SQL":           {line: []string{"--"}, blockStart: "/*", blockEnd: "*/"},
+	"PLSQL":         {line: []string{"--"}, blockStart: "/*", blockEnd: "*/"},
+	"Ada":           dashComments,
+	"Clojure":       semicolonComments,
+	"Emacs Lisp":    semicolonComments,
+	"Common Lisp":   {line: []string{";"}, blockStart: "#|", blockEnd: "|#"},
+	"Scheme":        semicolonComments,
+	"Erlang":        percentComments,
+	"MATLAB":        {line: []string{"%"}, blockStart: "%{", blockEnd: "%}"},
+	"OCaml":         {blockStart: "(*", blockEnd: "*)"},
+	"F#":            {line: []string{"//"}, blockStart: "(*", blockEnd: "*)"},
+	"Pascal":        {line: []string{"//"}, blockStart: "{", blockEnd: "}"},
+	"Fortran":       {line: []string{"!"}},
+	"Visual Basic":  {line: []string{"'"}},
+	"HTML":          {blockStart: "<!--", blockEnd: "-->"},
+	"Vue":           cStyleComments,
+}
+
+// ClassifyLines classifies every line of the content as blank, comment-only or
+// code based on the comment syntax of the language. It is a heuristic which does
+// not parse the strings literals, so a comment marker inside a string could
+// affect the lines that follow it.
+func ClassifyLines(lang string, content []byte) []LineType {
+	lines := bytes.Split(content, []byte{'\n'})
+	if len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		// the content ends with a new line
+		lines = lines[:len(lines)-1]
+	}
+
+	syntax := commentSyntaxes[lang]
+	types := make([]LineType, len(lines))
+
+	var inBlock bool
+	for i, line := range lines {
+		types[i], inBlock = classifyLine(syntax, bytes.TrimSpace(line), inBlock)
+	}
+
+	return types
+}
+
+func classifyLine(syntax *commentSyntax, line []byte, inBlock bool) (LineType, bool) {
+	if len(line) == 0 {
+		if inBlock {
+			return LineComment, true
+		}
+		return LineBlank, false
+	}
+
+	if syntax == nil {
+		return LineCode, false
+	}
+
+	isComment := true
+	for len(line) > 0 {
+		if inBlock {
+			end := bytes.Index(line, []byte(syntax.blockEnd))
+			if end < 0 {
+				return typeOf(isComment), true
+			}
+
+			inBlock = false
+			line = bytes.TrimSpace(line[end+len(syntax.blockEnd):])
+			continue
+		}
+
+		// the block start is checked first because it could begin with the line
+		// comment marker, such as "--[[" in Lua
+		if syntax.blockStart != "" && bytes.HasPrefix(line, []byte(syntax.blockStart)) {
+			inBlock = true
+			line = line[len(syntax.blockStart):]
+			continue
+		}
+
+		if syntax.lineCommentIndex(line) == 0 {
+			return typeOf(isComment), false
+		}
+
+		// the line has code, but it could open a block comment at its end
+		isComment = false
+		if syntax.blockStart == "" {
+			return LineCode, false
+		}
+
+		start := bytes.Index(line, []byte(syntax.blockStart))
+		if start < 0 {
+			return LineCode, false
+		}
+		if i := syntax.lineCommentIndex(line); i >= 0 && i < start {
+			return LineCode, false
+		}
+
+		inBlock = true
+		line = line[start+len(syntax.blockStart):]
+	}
+
+	return typeOf(isComment), inBlock
+}
+
+// lineCommentIndex returns the index of the first line comment marker, or -1
+// if the line does not have any.
+func (syntax *commentSyntax) lineCommentIndex(line []byte) int {
+	index := -1
+	for _, marker := range syntax.line {
+		i := bytes.Index(line, []byte(marker))
+		if i >= 0 && (index < 0 || i < index) {
+			index = i
+		}
+	}
+	return index
+}
+
+func typeOf(isComment bool) LineType {
+	if isComment {
+		return LineComment
+	}
+	return LineCode
+}
@@ -0,0 +1,59 @@
+package classify
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClassifyLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		lang    string
+		content string
+		want    []LineType
+	}{
+		{
+			name:    "go",
+			lang:    "Go",
+			content: "package main\n\n// comment\nx := 1 // trailing\n/* block\n\nstill */\ny := 2 /* open\nclosed */ z := 3\n",
+			want: []LineType{
+				LineCode, LineBlank, LineComment, LineCode,
+				LineComment, LineComment, LineComment,
+				LineCode, LineCode,
+			},
+		},
+		{
+			name:    "line comment before block start",
+			lang:    "Go",
+			content: "x := 1 // not a /* block\ny := 2\n",
+			want:    []LineType{LineCode, LineCode},
+		},
+		{
+			name:    "python",
+			lang:    "Python",
+			content: "# comment\n  \nprint('x')  # trailing\n",
+			want:    []LineType{LineComment, LineBlank, LineCode},
+		},
+		{
+			name:    "lua block",
+			lang:    "Lua",
+			content: "--[[ block\ncomment ]]\n-- line\nlocal x = 1",
+			want:    []LineType{LineComment, LineComment, LineComment, LineCode},
+		},
+		{
+			name:    "unknown language",
+			lang:    "Unknown",
+			content: "// code\n\n",
+			want:    []LineType{LineCode, LineBlank},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ClassifyLines(tt.lang, []byte(tt.content))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ClassifyLines() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package classify
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// PathConfig lists the paths of a repository that the heuristics do not classify
+// correctly, such as the custom vendored or generated directories. The lists
+// hold gitignore-style patterns, and a nil config keeps the heuristics only.
+type PathConfig struct {
+	Generated  []string `json:"generated,omitempty"   bson:"generated,omitempty"`
+	Dependency []string `json:"dependency,omitempty"  bson:"dependency,omitempty"`
+	Tests      []string `json:"tests,omitempty"       bson:"tests,omitempty"`
+	// Ignored are the paths that are excluded from the analysis.
+	Ignored []string `json:"ignored,omitempty"  bson:"ignored,omitempty"`
+}
+
+// Validate compiles the patterns of the config.
+func (cfg *PathConfig) Validate() error {
+	_, err := NewPathClassifier(cfg)
+	return err
+}
+
+type pathRule struct {
+	re       *regexp.Regexp
+	fileType FileType
+}
+
+// PathClassifier classifies the paths by the patterns of a config, the ignored
+// paths are matched first. A nil classifier does not classify any path.
+type PathClassifier struct {
+	rules []pathRule
+}
+
+func NewPathClassifier(cfg *PathConfig) (*PathClassifier, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	var c PathClassifier
+	lists := []struct {
+		patterns []string
+		fileType FileType
+	}{
+		{cfg.Ignored, FileIgnored},
+		{cfg.Generated, FileGenerated},
+		{cfg.Dependency, FileDependency},
+		{cfg.Tests, FileTests},
+	}
+	for _, l := range lists {
+		for _, p := range l.patterns {
+			re, err := CompilePathPattern(p)
+			if err != nil {
+				return nil, err
+			}
+			c.rules = append(c.rules, pathRule{re: re, fileType: l.fileType})
+		}
+	}
+
+	return &c, nil
+}
+
+// Classify returns the type of the first matching pattern, or zero if no
+// pattern matches the path.
+func (c *PathClassifier) Classify(path string) FileType {
+	if c == nil {
+		return 0
+	}
+
+	for _, r := range c.rules {
+		if r.re.MatchString(path) {
+			return r.fileType
+		}
+	}
+	return 0
+}
+
+// CompilePathPattern compiles a gitignore-style pattern. The pattern is relative
+// to the root if it has a slash before its end, otherwise it matches the names
+// in any directory, and a directory pattern matches all of its files.
+func CompilePathPattern(pattern string) (*regexp.Regexp, error) {
+	if strings.Trim(pattern, "/") == "" {
+		return nil, fmt.Errorf("invalid path pattern %q", pattern)
+	}
+
+	dir := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	var b strings.Builder
+	b.WriteString("^")
+	if !anchored {
+		b.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i += 1
+		case pattern[i] == '*':
+			b.WriteString("[^/]*")
+		case pattern[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	if dir {
+		b.WriteString("/.*$")
+	} else {
+		b.WriteString("(?:/.*)?$")
+	}
+
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,44 @@
+package classify
+
+import "testing"
+
+func TestPathClassifier(t *testing.T) {
+	c, err := NewPathClassifier(&PathConfig{
+		Generated:  []string{"*.pb.go", "/api/gen/"},
+		Dependency: []string{"third_party/"},
+		Tests:      []string{"**/testdata/**", "e2e"},
+		Ignored:    []string{"/scripts/legacy/", "third_party/tools/"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]FileType{
+		"pkg/x/x.pb.go":                  FileGenerated,
+		"api/gen/client.go":              FileGenerated,
+		"svc/api/gen/client.go":          0,
+		"third_party/lib/a.c":            FileDependency,
+		"pkg/third_party/lib/a.c":        FileDependency,
+		"pkg/x/testdata/input.go":        FileTests,
+		"e2e/login_test.js":              FileTests,
+		"scripts/legacy/run.py":          FileIgnored,
+		"third_party/tools/build/run.go": FileIgnored,
+		"pkg/x/x.go":                     0,
+	}
+	for p, want := range cases {
+		if got := c.Classify(p); got != want {
+			t.Errorf("%s: got %v, want %v", p, got, want)
+		}
+	}
+
+	var nilClassifier *PathClassifier
+	if got := nilClassifier.Classify("pkg/x/x.go"); got != 0 {
+		t.Errorf("expected a nil classifier to not classify, got %v", got)
+	}
+}
+
+func TestPathConfig_Validate(t *testing.T) {
+	if err := (&PathConfig{Ignored: []string{"/"}}).Validate(); err == nil {
+		t.Error("expected an error for the root pattern")
+	}
+}
@@ -32,6 +32,7 @@ const (
 	PerformanceImprovements // Performance Improvements
 	Reverts
 	MiscellaneousChores // Miscellaneous Chores
+	Bot
 )
 
 // keywords is not defined for `Tests`, `Documentations`, `Build`, `CI`.
@@ -145,6 +146,7 @@ const (
 	FileDependency
 	FileTests
 	FileSymlink
+	FileIgnored
 )
 
 func (t FileType) MarshalGQL(w io.Writer) {
@@ -30,6 +30,7 @@ var (
 		"PerformanceImprovements": PerformanceImprovements,
 		"Reverts":                 Reverts,
 		"MiscellaneousChores":     MiscellaneousChores,
+		"Bot":                     Bot,
 	}
 
 	_TagValueToName = map[Tag]string{
@@ -54,6 +55,7 @@ var (
 		PerformanceImprovements: "PerformanceImprovements",
 		Reverts:                 "Reverts",
 		MiscellaneousChores:     "MiscellaneousChores",
+		Bot:                     "Bot",
 	}
 )
 
@@ -82,6 +84,7 @@ func init() {
 			interface{}(PerformanceImprovements).(fmt.Stringer).String(): PerformanceImprovements,
 			interface{}(Reverts).(fmt.Stringer).String():                 Reverts,
 			interface{}(MiscellaneousChores).(fmt.Stringer).String():     MiscellaneousChores,
+			interface{}(Bot).(fmt.Stringer).String():                     Bot,
 		}
 	}
 }
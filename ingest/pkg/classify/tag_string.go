@@ -29,11 +29,12 @@ func _() {
 	_ = x[PerformanceImprovements-19]
 	_ = x[Reverts-20]
 	_ = x[MiscellaneousChores-21]
+	_ = x[Bot-22]
 }
 
-const _Tag_name = "CodeNone CodeFixBugAddUpdateFeatureTestsDocumentationsCode RefactoringLicenseBuild SystemContinuous IntegrationTechnical DebtStyleReleaseDependenciesGenerated CodePerformance ImprovementsRevertsMiscellaneous Chores"
+const _Tag_name = "CodeNone CodeFixBugAddUpdateFeatureTestsDocumentationsCode RefactoringLicenseBuild SystemContinuous IntegrationTechnical DebtStyleReleaseDependenciesGenerated CodePerformance ImprovementsRevertsMiscellaneous ChoresBot"
 
-var _Tag_index = [...]uint8{0, 4, 13, 16, 19, 22, 28, 35, 40, 54, 70, 77, 89, 111, 125, 130, 137, 149, 163, 187, 194, 214}
+var _Tag_index = [...]uint8{0, 4, 13, 16, 19, 22, 28, 35, 40, 54, 70, 77, 89, 111, 125, 130, 137, 149, 163, 187, 194, 214, 217}
 
 func (i Tag) String() string {
 	i -= 1
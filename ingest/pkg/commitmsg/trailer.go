@@ -0,0 +1,40 @@
+package commitmsg
+
+import (
+	"regexp"
+	"strings"
+)
+
+// CoAuthor is a developer that is credited by a `Co-authored-by` trailer.
+type CoAuthor struct {
+	Name  string `json:"name"   bson:"name"`
+	Email string `json:"email"  bson:"email"`
+}
+
+var coAuthorTrailer = regexp.MustCompile(`(?im)^[ \t]*co-authored-by:[ \t]*([^<\r\n]*?)[ \t]*<([^>\r\n]+)>[ \t]*$`)
+
+// CoAuthors returns the co-authors of the `Co-authored-by` trailers in the
+// commit message, a co-author is returned once even if it is repeated.
+func CoAuthors(message string) []CoAuthor {
+	matches := coAuthorTrailer.FindAllStringSubmatch(message, -1)
+	if len(matches) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(matches))
+	coAuthors := make([]CoAuthor, 0, len(matches))
+	for _, m := range matches {
+		email := strings.TrimSpace(m[2])
+		if email == "" || seen[strings.ToLower(email)] {
+			continue
+		}
+		seen[strings.ToLower(email)] = true
+
+		coAuthors = append(coAuthors, CoAuthor{
+			Name:  m[1],
+			Email: email,
+		})
+	}
+
+	return coAuthors
+}
@@ -0,0 +1,30 @@
+package commitmsg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCoAuthors(t *testing.T) {
+	msg := `Fix the parser
+
+Handle the empty input.
+
+Co-authored-by: Jane Doe <jane@example.com>
+co-authored-by: John Roe <john@example.com>
+Co-Authored-By: Jane Doe <JANE@example.com>
+Signed-off-by: Jane Doe <jane@example.com>`
+
+	want := []CoAuthor{
+		{Name: "Jane Doe", Email: "jane@example.com"},
+		{Name: "John Roe", Email: "john@example.com"},
+	}
+
+	if got := CoAuthors(msg); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	if got := CoAuthors("Fix the parser"); got != nil {
+		t.Errorf("expected no co-authors, got %v", got)
+	}
+}
@@ -0,0 +1,212 @@
+// Package complexity measures the complexity of source code. Go files are
+// parsed, while the other languages are measured by a heuristic that counts the
+// decision keywords and the indentation levels.
+package complexity
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+
+	"github.com/repofuel/repofuel/ingest/pkg/classify"
+)
+
+// Measures are the static measures of a content.
+type Measures struct {
+	// Cyclomatic is the sum of the cyclomatic complexity of the functions, the
+	// code out of the functions is counted as one function if there is none.
+	Cyclomatic int
+	// MaxNesting is the maximum depth of the nested control structures in the
+	// functions.
+	MaxNesting int
+	// Functions is the number of the declared functions.
+	Functions int
+}
+
+// Measure returns the static measures of the content, it returns zero measures
+// for an empty content.
+func Measure(lang string, content []byte) Measures {
+	if len(bytes.TrimSpace(content)) == 0 {
+		return Measures{}
+	}
+
+	if lang == "Go" {
+		if m, ok := measureGo(content); ok {
+			return m
+		}
+	}
+	return measureIndentation(lang, content)
+}
+
+func measureGo(content []byte) (Measures, bool) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", content, parser.SkipObjectResolution)
+	if err != nil {
+		return Measures{}, false
+	}
+
+	v := &goVisitor{m: new(Measures)}
+	ast.Walk(v, file)
+
+	if v.m.Cyclomatic == 0 {
+		v.m.Cyclomatic = 1
+	}
+	return *v.m, true
+}
+
+// goVisitor counts the decision points, the depth is the number of the control
+// structures that enclose the visited node.
+type goVisitor struct {
+	m     *Measures
+	depth int
+}
+
+func (v *goVisitor) Visit(n ast.Node) ast.Visitor {
+	switch n := n.(type) {
+	case *ast.FuncDecl:
+		v.m.Functions += 1
+		if n.Body != nil {
+			v.m.Cyclomatic += 1
+		}
+	case *ast.FuncLit:
+		v.m.Cyclomatic += 1
+		return &goVisitor{m: v.m}
+	case *ast.IfStmt:
+		v.m.Cyclomatic += 1
+		nested := v.nested()
+		walkIfPresent(nested, n.Init)
+		ast.Walk(nested, n.Cond)
+		ast.Walk(nested, n.Body)
+		// the else-if chain does not increase the nesting
+		walkIfPresent(v, n.Else)
+		return nil
+	case *ast.ForStmt, *ast.RangeStmt:
+		v.m.Cyclomatic += 1
+		return v.nested()
+	case *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt:
+		return v.nested()
+	case *ast.CaseClause:
+		if n.List != nil {
+			v.m.Cyclomatic += 1
+		}
+	case *ast.CommClause:
+		if n.Comm != nil {
+			v.m.Cyclomatic += 1
+		}
+	case *ast.BinaryExpr:
+		if n.Op == token.LAND || n.Op == token.LOR {
+			v.m.Cyclomatic += 1
+		}
+	}
+	return v
+}
+
+func (v *goVisitor) nested() *goVisitor {
+	depth := v.depth + 1
+	if depth > v.m.MaxNesting {
+		v.m.MaxNesting = depth
+	}
+	return &goVisitor{m: v.m, depth: depth}
+}
+
+func walkIfPresent(v ast.Visitor, n ast.Stmt) {
+	if n != nil {
+		ast.Walk(v, n)
+	}
+}
+
+var (
+	decisionPattern = regexp.MustCompile(`\b(?:if|elif|for|foreach|while|case|catch|except|and|or)\b|&&|\|\|`)
+	stringPattern   = regexp.MustCompile(`"(?:\\.|[^"\\])*"|'(?:\\.|[^'\\])*'`)
+)
+
+// measureIndentation counts the decision keywords in the code lines, and finds
+// the nesting from the indentation levels of the lines in the functions.
+func measureIndentation(lang string, content []byte) Measures {
+	lines := bytes.Split(content, []byte{'\n'})
+	types := classify.ClassifyLines(lang, content)
+	functions := classify.FindFunctions(lang, content)
+
+	levels := indentationLevels(lines, types)
+
+	var m Measures
+	for i, t := range types {
+		if t != classify.LineCode {
+			continue
+		}
+		code := stringPattern.ReplaceAll(lines[i], nil)
+		m.Cyclomatic += len(decisionPattern.FindAllIndex(code, -1))
+	}
+
+	m.Functions = len(functions)
+	if m.Functions > 0 {
+		m.Cyclomatic += m.Functions
+	} else {
+		m.Cyclomatic += 1
+	}
+
+	for _, fn := range functions {
+		base := levels[fn.Start-1]
+		for line := fn.Start + 1; line <= fn.End && line <= len(levels); line++ {
+			// the body of the function is one level deeper than its declaration
+			if nesting := levels[line-1] - base - 1; nesting > m.MaxNesting {
+				m.MaxNesting = nesting
+			}
+		}
+	}
+
+	if len(functions) == 0 {
+		for _, l := range levels {
+			if l > m.MaxNesting {
+				m.MaxNesting = l
+			}
+		}
+	}
+
+	return m
+}
+
+// indentationLevels returns the indentation level of every line, the unit of
+// the levels is the smallest indentation of the code lines. The lines that are
+// not code have the level of the previous code line.
+func indentationLevels(lines [][]byte, types []classify.LineType) []int {
+	widths := make([]int, len(types))
+	unit := 0
+	for i, t := range types {
+		if t != classify.LineCode {
+			continue
+		}
+		widths[i] = indentationWidth(lines[i])
+		if widths[i] > 0 && (unit == 0 || widths[i] < unit) {
+			unit = widths[i]
+		}
+	}
+
+	levels := make([]int, len(types))
+	var prev int
+	for i, t := range types {
+		if t == classify.LineCode && unit > 0 {
+			prev = widths[i] / unit
+		}
+		levels[i] = prev
+	}
+	return levels
+}
+
+// indentationWidth counts a tab as four spaces.
+func indentationWidth(line []byte) int {
+	var width int
+	for _, ch := range line {
+		switch ch {
+		case ' ':
+			width += 1
+		case '\t':
+			width += 4
+		default:
+			return width
+		}
+	}
+	return width
+}
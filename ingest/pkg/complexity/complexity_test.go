@@ -0,0 +1,88 @@
+package complexity
+
+import "testing"
+
+func TestMeasure(t *testing.T) {
+	tests := []struct {
+		name    string
+		lang    string
+		content string
+		want    Measures
+	}{
+		{
+			name: "go",
+			lang: "Go",
+			content: `package main
+
+func a(x int) int {
+	if x > 0 && x < 10 {
+		for i := 0; i < x; i++ {
+			x += i
+		}
+	} else if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func b(x int) {
+	switch x {
+	case 1:
+	case 2:
+	default:
+	}
+	go func() {
+		if x > 0 {
+		}
+	}()
+}
+`,
+			// a: 1 + if + && + for + else-if, b: 1 + 2 cases, the literal: 1 + if
+			want: Measures{Cyclomatic: 10, MaxNesting: 2, Functions: 2},
+		},
+		{
+			name: "python",
+			lang: "Python",
+			content: `def a(x):
+    # if it is positive
+    if x > 0 and x < 10:
+        for i in range(x):
+            print("if")
+    return x
+
+
+def b():
+    pass
+`,
+			want: Measures{Cyclomatic: 5, MaxNesting: 2, Functions: 2},
+		},
+		{
+			name: "java",
+			lang: "Java",
+			content: `class A {
+    int a(int x) {
+        while (x > 0) {
+            x--;
+        }
+        return x;
+    }
+}
+`,
+			want: Measures{Cyclomatic: 2, MaxNesting: 1, Functions: 1},
+		},
+		{
+			name:    "empty",
+			lang:    "Go",
+			content: "\n",
+			want:    Measures{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Measure(tt.lang, []byte(tt.content)); got != tt.want {
+				t.Errorf("Measure() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
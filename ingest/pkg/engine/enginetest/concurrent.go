@@ -0,0 +1,137 @@
+package enginetest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/repofuel/repofuel/ingest/pkg/engine"
+	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+)
+
+// RunConcurrentReads checks that an adapter gives the same diffs, contents and
+// blames when the commits are analyzed in parallel, it is run only against the
+// adapters that support the parallel analysis.
+func RunConcurrentReads(t *testing.T, newAdapter AdapterFactory) {
+	ctx := context.Background()
+	f := NewFixture(t)
+
+	const branches = 4
+	for i := 0; i < branches; i++ {
+		f.Write(fmt.Sprintf("f%d.go", i), numberedLines(20))
+	}
+	f.Commit("A")
+
+	// the branches are independent, so their commits are analyzed concurrently
+	for i := 0; i < branches; i++ {
+		f.Checkout("master")
+		f.Checkout(fmt.Sprintf("b%d", i))
+		for j := 1; j <= 4; j++ {
+			f.Write(fmt.Sprintf("f%d.go", i), numberedLines(20+j, j*3, j*3+1))
+			f.Commit(fmt.Sprintf("b%d-%d", i, j))
+		}
+	}
+
+	f.Checkout("master")
+	for i := 0; i < branches; i++ {
+		f.Merge(fmt.Sprintf("M%d", i), fmt.Sprintf("b%d", i))
+	}
+
+	adp := Clone(t, newAdapter, f)
+	repo := engine.NewRepository(identifier.RepositoryID{}, "", &engine.RepositoryOpts{Adapter: adp})
+	if err := repo.IngestHead(ctx, f.Hash(fmt.Sprintf("M%d", branches-1))); err != nil {
+		t.Fatal(err)
+	}
+
+	sequential := &readsAnalyzer{repo: repo, reads: make(map[identifier.Hash]string)}
+	if err := engine.RunParallelForwardAnalysis(ctx, sequential, repo.Roots().Slice(), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	parallel := &readsAnalyzer{repo: repo, reads: make(map[identifier.Hash]string)}
+	if err := engine.RunParallelForwardAnalysis(ctx, parallel, repo.Roots().Slice(), 8); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(parallel.reads) != repo.CommitsCount() {
+		t.Fatalf("expected to analyze %d commits, analyzed %d", repo.CommitsCount(), len(parallel.reads))
+	}
+	for h, want := range sequential.reads {
+		if got := parallel.reads[h]; got != want {
+			t.Errorf("commit %s: the parallel reads differ\nwant:\n%s\ngot:\n%s", h, want, got)
+		}
+	}
+}
+
+// readsAnalyzer records the diff of every commit with the contents and the
+// blames of its changed files.
+type readsAnalyzer struct {
+	repo  *engine.Repository
+	mu    sync.Mutex
+	reads map[identifier.Hash]string
+}
+
+func (a *readsAnalyzer) AnalyzeCommit(ctx context.Context, c engine.Commit) error {
+	obj, err := c.Object()
+	if err != nil {
+		return err
+	}
+
+	var files []*FileDiff
+	err = obj.DiffHunks(ctx, func(delta engine.DiffDelta) (engine.HunkAnalysis, error) {
+		f := &FileDiff{
+			Action:   delta.Action(),
+			FromPath: delta.FromPath(),
+			ToPath:   delta.ToPath(),
+		}
+		files = append(files, f)
+		return f, nil
+	})
+	obj.Free()
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	for _, f := range files {
+		content, err := a.repo.ReadFile(c.Hash(), f.ToPath)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&b, "%s %v %+v %d bytes\n", f.ToPath, f.Action, f.Hunks, len(content))
+
+		if f.Action != engine.DeltaModified || !c.HasParent() {
+			continue
+		}
+
+		for _, h := range f.Hunks {
+			if h.LinesDeleted == 0 {
+				continue
+			}
+
+			sets, err := a.repo.InducingCommitsByChunk(ctx, c.FirstParent().Hash(), f.FromPath, h.AddressDeleted)
+			if err != nil {
+				return err
+			}
+
+			inducing := sets[0].Slice()
+			sort.Slice(inducing, func(i, j int) bool {
+				return bytes.Compare(inducing[i][:], inducing[j][:]) < 0
+			})
+			fmt.Fprintf(&b, "blame %v: %v\n", h.AddressDeleted, inducing)
+		}
+	}
+
+	a.mu.Lock()
+	a.reads[c.Hash()] = b.String()
+	a.mu.Unlock()
+	return nil
+}
+
+func (a *readsAnalyzer) Finish(context.Context) error {
+	return nil
+}
@@ -0,0 +1,863 @@
+// Package enginetest provides a conformance suite for the implementations of
+// engine.RepositoryAdapter. The suite builds throwaway repositories with the
+// git command line and checks that an adapter reads them the same way.
+package enginetest
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/repofuel/repofuel/ingest/pkg/engine"
+	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+)
+
+type AdapterFactory func() engine.RepositoryAdapter
+
+// Run runs the conformance suite against the adapters that are created by newAdapter.
+func Run(t *testing.T, newAdapter AdapterFactory) {
+	tests := []struct {
+		name string
+		fn   func(*testing.T, AdapterFactory)
+	}{
+		{"Branches", testBranches},
+		{"BranchFilter", testBranchFilter},
+		{"Tags", testTags},
+		{"Graph", testGraph},
+		{"ModifiedHunks", testModifiedHunks},
+		{"WhitespaceHunks", testWhitespaceHunks},
+		{"AddedAndDeleted", testAddedAndDeleted},
+		{"Renamed", testRenamed},
+		{"Binary", testBinary},
+		{"Symlink", testSymlink},
+		{"InducingCommits", testInducingCommits},
+		{"ReadFile", testReadFile},
+		{"Lines", testLines},
+		{"Patch", testPatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newAdapter)
+		})
+	}
+}
+
+// RunDiffOptions runs the tests of the rename and copy detection against the
+// adapters that are created by newAdapter with the options, the copies are only
+// tested if the adapters detect them.
+func RunDiffOptions(t *testing.T, newAdapter func(*engine.DiffOptions) engine.RepositoryAdapter, copies bool) {
+	t.Run("RenameThreshold", func(t *testing.T) {
+		testRenameThreshold(t, newAdapter)
+	})
+
+	if copies {
+		t.Run("Copies", func(t *testing.T) {
+			testCopies(t, newAdapter)
+		})
+	}
+}
+
+// Clone clones the fixture into a temporary directory with a new adapter.
+func Clone(t *testing.T, newAdapter AdapterFactory, f *Fixture) engine.RepositoryAdapter {
+	t.Helper()
+
+	adp := newAdapter()
+	err := adp.Clone(context.Background(), f.Dir, filepath.Join(t.TempDir(), "repo"), nil)
+	if err != nil {
+		t.Fatalf("clone: %v", err)
+	}
+
+	return adp
+}
+
+// FileDiff is the collected diff of a single file in a commit.
+type FileDiff struct {
+	Action    engine.DeltaType
+	FromPath  string
+	ToPath    string
+	IsBinary  bool
+	IsSymlink bool
+	Hunks     []Hunk
+}
+
+type Hunk struct {
+	LinesAdded     int
+	LinesDeleted   int
+	AddressDeleted engine.ChunkAddr
+	AddressAdded   engine.ChunkAddr
+}
+
+// Diff collects the diff of a commit against its first parent, the files are
+// keyed by their new paths.
+func Diff(t *testing.T, adp engine.RepositoryAdapter, h identifier.Hash) map[string]*FileDiff {
+	t.Helper()
+
+	c, err := adp.Commit(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	obj, err := c.Object()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer obj.Free()
+
+	files := make(map[string]*FileDiff)
+	err = obj.DiffHunks(context.Background(), func(delta engine.DiffDelta) (engine.HunkAnalysis, error) {
+		f := &FileDiff{
+			Action:    delta.Action(),
+			FromPath:  delta.FromPath(),
+			ToPath:    delta.ToPath(),
+			IsBinary:  delta.IsBinary(),
+			IsSymlink: delta.IsSymlink(),
+		}
+		files[f.ToPath] = f
+		return f, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return files
+}
+
+func (f *FileDiff) AnalyzeHunk(h engine.DiffHunk) error {
+	f.Hunks = append(f.Hunks, Hunk{
+		LinesAdded:     h.LinesAdded(),
+		LinesDeleted:   h.LinesDeleted(),
+		AddressDeleted: h.AddressDeleted(),
+		AddressAdded:   h.AddressAdded(),
+	})
+	return nil
+}
+
+func testBranches(t *testing.T, newAdapter AdapterFactory) {
+	f := NewFixture(t)
+	f.Write("a.txt", "a\n")
+	f.Commit("A")
+	f.Checkout("feature")
+	f.Write("b.txt", "b\n")
+	f.Commit("B")
+
+	adp := Clone(t, newAdapter, f)
+
+	branches, err := adp.Branches()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]identifier.Hash{
+		"master":  f.Hash("A"),
+		"feature": f.Hash("B"),
+	}
+
+	if len(branches) != len(expected) {
+		t.Fatalf("expected %d branches, got %v", len(expected), branches)
+	}
+	for name, h := range expected {
+		if branches[name] != h {
+			t.Errorf("branch %q: expected %s, got %s", name, h, branches[name])
+		}
+	}
+}
+
+type branchMatcher func(name string) bool
+
+func (m branchMatcher) Match(name string) bool {
+	return m(name)
+}
+
+// testBranchFilter checks that the branches that are not selected are neither
+// listed nor tagged on the commits.
+func testBranchFilter(t *testing.T, newAdapter AdapterFactory) {
+	f := NewFixture(t)
+	f.Write("a.txt", "a\n")
+	f.Commit("A")
+	f.Checkout("release/1.0")
+	f.Write("b.txt", "b\n")
+	f.Commit("B")
+	f.Checkout("stale")
+	f.Write("c.txt", "c\n")
+	f.Commit("C")
+
+	adp := Clone(t, newAdapter, f)
+	repo := engine.NewRepository(identifier.RepositoryID{}, "", &engine.RepositoryOpts{Adapter: adp})
+	repo.SetBranchFilter(branchMatcher(func(name string) bool {
+		return name != "stale"
+	}))
+
+	branches, err := repo.Branches()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(branches) != 2 || branches["master"] != f.Hash("A") || branches["release/1.0"] != f.Hash("B") {
+		t.Fatalf("expected master and release/1.0 only, got %v", branches)
+	}
+
+	ctx := context.Background()
+	for _, h := range []identifier.Hash{f.Hash("B"), f.Hash("C")} {
+		if err := repo.IngestHead(ctx, h); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the excluded branch is skipped even if it is passed
+	branches["stale"] = f.Hash("C")
+	err = repo.TagBranchesOnCommits(branches, repo.Roots())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, label := range []string{"A", "B", "C"} {
+		c, _ := repo.Commit(f.Hash(label))
+		if c.Branches().Has("stale") {
+			t.Errorf("commit %s: expected the stale branch to be skipped", label)
+		}
+	}
+
+	a, _ := repo.Commit(f.Hash("A"))
+	if !a.Branches().Has("master") || !a.Branches().Has("release/1.0") {
+		t.Errorf("expected commit A to be tagged with the selected branches, got %v", a.Branches())
+	}
+}
+
+// testTags checks the listing of the tags and assigning the commits to their
+// first releases on the history:
+//
+//	A - B - C - M - E
+//	     \     /
+//	      D ---
+//
+// where v1 is a lightweight tag of B, and v2 is an annotated tag of M.
+func testTags(t *testing.T, newAdapter AdapterFactory) {
+	f := NewFixture(t)
+	f.Write("a.txt", "a\n")
+	f.Commit("A")
+	f.Advance(time.Hour)
+	f.Write("b.txt", "b\n")
+	f.Commit("B")
+	f.Tag("v1", "")
+	f.Advance(time.Hour)
+	f.Checkout("feature")
+	f.Write("d.txt", "d\n")
+	f.Commit("D")
+	f.Checkout("master")
+	f.Write("c.txt", "c\n")
+	f.Commit("C")
+	f.Merge("M", "feature")
+	f.Advance(time.Hour)
+	f.Tag("v2", "the second release")
+	f.Git("tag", "tree", "HEAD^{tree}")
+	f.Write("e.txt", "e\n")
+	f.Commit("E")
+
+	adp := Clone(t, newAdapter, f)
+	repo := engine.NewRepository(identifier.RepositoryID{}, "", &engine.RepositoryOpts{Adapter: adp})
+
+	tags, err := repo.Tags()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	engine.SortTags(tags)
+	if len(tags) != 2 {
+		t.Fatalf("expected the tags of the commits only, got %d tags", len(tags))
+	}
+	if tags[0].Name != "v1" || tags[0].Commit != f.Hash("B") || !tags[0].Date.Equal(tagDate(t, f, "v1")) {
+		t.Errorf("unexpected lightweight tag %+v", tags[0])
+	}
+	if tags[1].Name != "v2" || tags[1].Commit != f.Hash("M") || !tags[1].Date.Equal(tagDate(t, f, "v2")) {
+		t.Errorf("unexpected annotated tag %+v", tags[1])
+	}
+
+	err = repo.IngestHead(context.Background(), f.Hash("E"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the tags of the commits that are not ingested are skipped
+	tags = append(tags, &engine.Tag{Name: "v0", Commit: identifier.NewHash("0123456789abcdef0123456789abcdef01234567")})
+
+	releases := repo.FirstReleases(tags)
+	expected := map[string]string{"A": "v1", "B": "v1", "C": "v2", "D": "v2", "M": "v2"}
+	if len(releases) != len(expected) {
+		t.Errorf("expected %d commits in the releases, got %d", len(expected), len(releases))
+	}
+	for label, release := range expected {
+		if got := releases[f.Hash(label)]; got != release {
+			t.Errorf("commit %s: expected release %q, got %q", label, release, got)
+		}
+	}
+}
+
+// tagDate returns the date of the annotated tag, or the commit date of the
+// lightweight tag.
+func tagDate(t *testing.T, f *Fixture, name string) time.Time {
+	t.Helper()
+
+	date, err := time.Parse(time.RFC3339, f.Git("for-each-ref", "--format=%(creatordate:iso-strict)", "refs/tags/"+name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return date
+}
+
+// testGraph checks the parents and children wiring on a history of a branch
+// that is forked from a linear history and merged back:
+//
+//	A - B - D - M
+//	     \     /
+//	      C ---
+func testGraph(t *testing.T, newAdapter AdapterFactory) {
+	f := NewFixture(t)
+	f.Write("a.txt", "a\n")
+	f.Commit("A")
+	f.Write("a.txt", "a\nb\n")
+	f.Commit("B")
+	f.Checkout("feature")
+	f.Write("c.txt", "c\n")
+	f.Commit("C")
+	f.Checkout("master")
+	f.Write("d.txt", "d\n")
+	f.Commit("D")
+	f.Merge("M", "feature")
+
+	adp := Clone(t, newAdapter, f)
+	repo := engine.NewRepository(identifier.RepositoryID{}, "", &engine.RepositoryOpts{Adapter: adp})
+
+	err := repo.IngestHead(context.Background(), f.Hash("M"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if repo.CommitsCount() != 5 {
+		t.Fatalf("expected 5 commits, got %d", repo.CommitsCount())
+	}
+
+	a, _ := repo.Commit(f.Hash("A"))
+	if repo.NumRoots() != 1 || !repo.Roots().Has(a) {
+		t.Fatal("expected A to be the only root")
+	}
+
+	expectHashes(t, "parents of M", parentHashes(repo, f.Hash("M")), f.Hash("D"), f.Hash("C"))
+	expectHashes(t, "parents of B", parentHashes(repo, f.Hash("B")), f.Hash("A"))
+	expectHashes(t, "parents of A", parentHashes(repo, f.Hash("A")))
+	expectHashSet(t, "children of B", childrenHashes(repo, f.Hash("B")), f.Hash("C"), f.Hash("D"))
+	expectHashSet(t, "children of M", childrenHashes(repo, f.Hash("M")))
+
+	m, _ := repo.Commit(f.Hash("M"))
+	if !m.IsMerge() {
+		t.Error("expected M to be a merge commit")
+	}
+
+	obj, err := m.Object()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer obj.Free()
+
+	if obj.Hash() != f.Hash("M") {
+		t.Errorf("unexpected object hash: %s", obj.Hash())
+	}
+	if obj.FirstParentHash() != f.Hash("D") {
+		t.Errorf("expected the first parent to be D, got %s", obj.FirstParentHash())
+	}
+	if strings.TrimSpace(obj.Message()) != "M" {
+		t.Errorf("unexpected message: %q", obj.Message())
+	}
+	if obj.AuthorEmail() != "tester@example.com" || obj.AuthorName() != "Tester" {
+		t.Errorf("unexpected author: %s <%s>", obj.AuthorName(), obj.AuthorEmail())
+	}
+	if !m.AuthorDate().Equal(obj.AuthorDate()) || !obj.AuthorDate().Equal(fixtureEpoch.Add(4*time.Minute)) {
+		t.Errorf("unexpected author date: %s", obj.AuthorDate())
+	}
+	if c := obj.Committer(); c.Email != "tester@example.com" || c.Name != "Tester" {
+		t.Errorf("unexpected committer: %s <%s>", c.Name, c.Email)
+	}
+	if devs := m.Contributors(); devs.Count() != 1 || !devs.Has("tester@example.com") {
+		t.Errorf("unexpected contributors: %v", devs.Slice())
+	}
+}
+
+func testModifiedHunks(t *testing.T, newAdapter AdapterFactory) {
+	f := NewFixture(t)
+	f.Write("a.txt", "one\ntwo\nthree\nfour\nfive\nsix\n")
+	f.Commit("A")
+	f.Write("a.txt", "one\nTWO\nthree\nfive\nsix\nseven\n")
+	f.Commit("B")
+
+	adp := Clone(t, newAdapter, f)
+	files := Diff(t, adp, f.Hash("B"))
+
+	file := expectFile(t, files, "a.txt", engine.DeltaModified)
+	expectHunks(t, file,
+		Hunk{LinesAdded: 1, LinesDeleted: 1, AddressDeleted: engine.ChunkAddr{Start: 2, End: 2}, AddressAdded: engine.ChunkAddr{Start: 2, End: 2}},
+		Hunk{LinesAdded: 0, LinesDeleted: 1, AddressDeleted: engine.ChunkAddr{Start: 4, End: 4}, AddressAdded: engine.ChunkAddr{Start: 3, End: 2}},
+		Hunk{LinesAdded: 1, LinesDeleted: 0, AddressDeleted: engine.ChunkAddr{Start: 6, End: 5}, AddressAdded: engine.ChunkAddr{Start: 6, End: 6}},
+	)
+}
+
+func testWhitespaceHunks(t *testing.T, newAdapter AdapterFactory) {
+	f := NewFixture(t)
+	f.Write("a.txt", "one\ntwo\nthree\n")
+	f.Commit("A")
+	f.Write("a.txt", "one\n  two\t\nthree\n")
+	f.Commit("B")
+
+	adp := Clone(t, newAdapter, f)
+	files := Diff(t, adp, f.Hash("B"))
+
+	// the delta could be reported or not, but it should not have hunks
+	if file, ok := files["a.txt"]; ok {
+		expectHunks(t, file)
+	}
+}
+
+func testAddedAndDeleted(t *testing.T, newAdapter AdapterFactory) {
+	f := NewFixture(t)
+	f.Write("old.txt", "one\ntwo\nthree\n")
+	f.Commit("A")
+	f.Remove("old.txt")
+	f.Write("new.txt", "first\nsecond\n")
+	f.Commit("B")
+
+	adp := Clone(t, newAdapter, f)
+
+	files := Diff(t, adp, f.Hash("A"))
+	if len(files) != 1 {
+		t.Fatalf("expected one file in the root commit, got %d", len(files))
+	}
+	file := expectFile(t, files, "old.txt", engine.DeltaAdded)
+	expectHunks(t, file, Hunk{LinesAdded: 3, LinesDeleted: 0, AddressDeleted: engine.ChunkAddr{Start: 0, End: -1}, AddressAdded: engine.ChunkAddr{Start: 1, End: 3}})
+
+	files = Diff(t, adp, f.Hash("B"))
+	if len(files) != 2 {
+		t.Fatalf("expected two files, got %d", len(files))
+	}
+
+	file = expectFile(t, files, "new.txt", engine.DeltaAdded)
+	expectHunks(t, file, Hunk{LinesAdded: 2, LinesDeleted: 0, AddressDeleted: engine.ChunkAddr{Start: 0, End: -1}, AddressAdded: engine.ChunkAddr{Start: 1, End: 2}})
+
+	file = expectFile(t, files, "old.txt", engine.DeltaDeleted)
+	if file.FromPath != "old.txt" {
+		t.Errorf("unexpected old path of the deleted file: %q", file.FromPath)
+	}
+	expectHunks(t, file, Hunk{LinesAdded: 0, LinesDeleted: 3, AddressDeleted: engine.ChunkAddr{Start: 1, End: 3}, AddressAdded: engine.ChunkAddr{Start: 0, End: -1}})
+}
+
+func testRenamed(t *testing.T, newAdapter AdapterFactory) {
+	content := "package main\n\nfunc main() {\n\tprintln(\"hello\")\n}\n"
+
+	f := NewFixture(t)
+	f.Write("old.go", content)
+	f.Commit("A")
+	f.Move("old.go", "new.go")
+	f.Commit("B")
+
+	adp := Clone(t, newAdapter, f)
+	files := Diff(t, adp, f.Hash("B"))
+
+	if len(files) != 1 {
+		t.Fatalf("expected the rename to be one delta, got %d", len(files))
+	}
+
+	file := expectFile(t, files, "new.go", engine.DeltaRenamed)
+	if file.FromPath != "old.go" {
+		t.Errorf("unexpected old path of the renamed file: %q", file.FromPath)
+	}
+	expectHunks(t, file)
+}
+
+// numberedLines returns the content of n lines, the lines in edited are changed.
+func numberedLines(n int, edited ...int) string {
+	var b strings.Builder
+	for i := 1; i <= n; i++ {
+		line := fmt.Sprintf("line number %d of the file", i)
+		for _, e := range edited {
+			if e == i {
+				line = fmt.Sprintf("edited %d", i)
+			}
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// testRenameThreshold renames a file with heavy edits, it is only a rename with
+// a low threshold.
+func testRenameThreshold(t *testing.T, newAdapter func(*engine.DiffOptions) engine.RepositoryAdapter) {
+	f := NewFixture(t)
+	f.Write("old.txt", numberedLines(10))
+	f.Commit("A")
+	f.Remove("old.txt")
+	f.Write("new.txt", numberedLines(10, 2, 4, 6, 8))
+	f.Commit("B")
+
+	factory := func(opts *engine.DiffOptions) AdapterFactory {
+		return func() engine.RepositoryAdapter { return newAdapter(opts) }
+	}
+
+	files := Diff(t, Clone(t, factory(&engine.DiffOptions{RenameThreshold: 90}), f), f.Hash("B"))
+	expectFile(t, files, "new.txt", engine.DeltaAdded)
+	expectFile(t, files, "old.txt", engine.DeltaDeleted)
+
+	files = Diff(t, Clone(t, factory(&engine.DiffOptions{RenameThreshold: 30}), f), f.Hash("B"))
+	file := expectFile(t, files, "new.txt", engine.DeltaRenamed)
+	if file.FromPath != "old.txt" {
+		t.Errorf("unexpected old path of the renamed file: %q", file.FromPath)
+	}
+}
+
+// testCopies splits a file by copying it, the copy is only detected when the
+// copies are enabled.
+func testCopies(t *testing.T, newAdapter func(*engine.DiffOptions) engine.RepositoryAdapter) {
+	f := NewFixture(t)
+	f.Write("a.txt", numberedLines(10))
+	f.Commit("A")
+	f.Write("a.txt", numberedLines(10, 10))
+	f.Write("b.txt", numberedLines(10))
+	f.Commit("B")
+
+	files := Diff(t, Clone(t, func() engine.RepositoryAdapter { return newAdapter(nil) }, f), f.Hash("B"))
+	expectFile(t, files, "b.txt", engine.DeltaAdded)
+
+	files = Diff(t, Clone(t, func() engine.RepositoryAdapter { return newAdapter(&engine.DiffOptions{Copies: true}) }, f), f.Hash("B"))
+	file := expectFile(t, files, "b.txt", engine.DeltaCopied)
+	if file.FromPath != "a.txt" {
+		t.Errorf("unexpected source of the copied file: %q", file.FromPath)
+	}
+	expectFile(t, files, "a.txt", engine.DeltaModified)
+}
+
+func testBinary(t *testing.T, newAdapter AdapterFactory) {
+	f := NewFixture(t)
+	f.Write("a.bin", "\x00\x01\x02\x03\n")
+	f.Write("a.txt", "a\n")
+	f.Commit("A")
+
+	adp := Clone(t, newAdapter, f)
+	files := Diff(t, adp, f.Hash("A"))
+
+	file := expectFile(t, files, "a.bin", engine.DeltaAdded)
+	if !file.IsBinary {
+		t.Error("expected a binary file")
+	}
+	expectHunks(t, file)
+
+	file = expectFile(t, files, "a.txt", engine.DeltaAdded)
+	if file.IsBinary {
+		t.Error("expected a text file")
+	}
+}
+
+func testSymlink(t *testing.T, newAdapter AdapterFactory) {
+	f := NewFixture(t)
+	f.Write("a.txt", "a\n")
+	f.Symlink("a.txt", "link")
+	f.Commit("A")
+
+	adp := Clone(t, newAdapter, f)
+	files := Diff(t, adp, f.Hash("A"))
+
+	if file := expectFile(t, files, "link", engine.DeltaAdded); !file.IsSymlink {
+		t.Error("expected a symlink")
+	}
+	if file := expectFile(t, files, "a.txt", engine.DeltaAdded); file.IsSymlink {
+		t.Error("expected a regular file")
+	}
+}
+
+// testInducingCommits blames the deleted lines of a fix on its parent, which
+// is the same way the SZZ algorithm uses the adapter.
+func testInducingCommits(t *testing.T, newAdapter AdapterFactory) {
+	f := NewFixture(t)
+	f.Write("a.txt", "one\ntwo\nthree\nfour\n")
+	f.Commit("A")
+	f.Write("a.txt", "one\nTWO\nthree\nfour\n")
+	f.Commit("B")
+	f.Write("a.txt", "one\nTwo\nTHREE\nfour\n")
+	f.Commit("C")
+
+	adp := Clone(t, newAdapter, f)
+	files := Diff(t, adp, f.Hash("C"))
+
+	file := expectFile(t, files, "a.txt", engine.DeltaModified)
+	expectHunks(t, file, Hunk{LinesAdded: 2, LinesDeleted: 2, AddressDeleted: engine.ChunkAddr{Start: 2, End: 3}, AddressAdded: engine.ChunkAddr{Start: 2, End: 3}})
+
+	ctx := context.Background()
+	ids, err := adp.InducingCommits(ctx, f.Hash("B"), "a.txt", file.Hunks[0].AddressDeleted)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectHashSet(t, "inducing commits of C", ids.Slice(), f.Hash("A"), f.Hash("B"))
+
+	ids, err = adp.InducingCommits(ctx, f.Hash("B"), "a.txt", engine.ChunkAddr{Start: 2, End: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectHashSet(t, "inducing commits of the second line", ids.Slice(), f.Hash("B"))
+
+	sets, err := adp.InducingCommitsByChunk(ctx, f.Hash("B"), "a.txt", engine.ChunkAddr{Start: 1, End: 1}, engine.ChunkAddr{Start: 2, End: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sets) != 2 {
+		t.Fatalf("expected the commits of 2 chunks, got %d", len(sets))
+	}
+	expectHashSet(t, "inducing commits of the first chunk", sets[0].Slice(), f.Hash("A"))
+	expectHashSet(t, "inducing commits of the second chunk", sets[1].Slice(), f.Hash("A"), f.Hash("B"))
+}
+
+func testReadFile(t *testing.T, newAdapter AdapterFactory) {
+	f := NewFixture(t)
+	f.Write("dir/a.txt", "one\n")
+	f.Commit("A")
+	f.Write("dir/a.txt", "two\n")
+	f.Commit("B")
+
+	adp := Clone(t, newAdapter, f)
+
+	content, err := adp.ReadFile(f.Hash("A"), "dir/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "one\n" {
+		t.Errorf("unexpected content: %q", content)
+	}
+
+	_, err = adp.ReadFile(f.Hash("B"), "missing.txt")
+	if err != engine.ErrFileNotFound {
+		t.Errorf("expected ErrFileNotFound, got %v", err)
+	}
+}
+
+func testLines(t *testing.T, newAdapter AdapterFactory) {
+	f := NewFixture(t)
+	f.Write("a.txt", "one\ntwo\nthree\nfour\nfive\nsix\n")
+	f.Commit("A")
+	f.Write("a.txt", "one\nTWO\nthree\nfive\nsix\nseven\n")
+	f.Commit("B")
+
+	adp := Clone(t, newAdapter, f)
+	c, err := adp.Commit(f.Hash("B"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	obj, err := c.Object()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer obj.Free()
+
+	file := &lineCollector{}
+	err = obj.DiffLines(context.Background(), func(delta engine.DiffDelta) (engine.LineAnalysis, error) {
+		return file, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(file.hunks) != 3 {
+		t.Errorf("expected 3 hunks, got %d", len(file.hunks))
+	}
+	expectLines(t, file.lines,
+		"Deleted 2 -1 two",
+		"Added -1 2 TWO",
+		"Deleted 4 -1 four",
+		"Added -1 6 seven",
+	)
+}
+
+// testPatch checks the context lines of the patch, the close changes are in
+// the same hunk and the far ones are separated.
+func testPatch(t *testing.T, newAdapter AdapterFactory) {
+	var old, new strings.Builder
+	for i := 1; i <= 20; i++ {
+		fmt.Fprintf(&old, "line %d\n", i)
+		switch i {
+		case 2, 15:
+			fmt.Fprintf(&new, "LINE %d\n", i)
+		case 7:
+		default:
+			fmt.Fprintf(&new, "line %d\n", i)
+		}
+	}
+
+	f := NewFixture(t)
+	f.Write("a.txt", old.String())
+	f.Commit("A")
+	f.Write("a.txt", new.String())
+	f.Commit("B")
+
+	adp := Clone(t, newAdapter, f)
+	var patch engine.FilePatch
+	c, err := adp.Commit(f.Hash("B"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	obj, err := c.Object()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer obj.Free()
+
+	err = obj.DiffHunks(context.Background(), func(delta engine.DiffDelta) (engine.HunkAnalysis, error) {
+		patch, err = delta.Patch()
+		return &FileDiff{}, err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectLines(t, patch.Lines(),
+		"Context 1 1 line 1",
+		"Deleted 2 -1 line 2",
+		"Added -1 2 LINE 2",
+		"Context 3 3 line 3",
+		"Context 4 4 line 4",
+		"Context 5 5 line 5",
+		"Context 6 6 line 6",
+		"Deleted 7 -1 line 7",
+		"Context 8 7 line 8",
+		"Context 9 8 line 9",
+		"Context 10 9 line 10",
+		"Context 12 11 line 12",
+		"Context 13 12 line 13",
+		"Context 14 13 line 14",
+		"Deleted 15 -1 line 15",
+		"Added -1 14 LINE 15",
+		"Context 16 15 line 16",
+		"Context 17 16 line 17",
+		"Context 18 17 line 18",
+	)
+
+	chunks := patch.Chunks()
+	if len(chunks) != 10 {
+		t.Fatalf("expected 10 chunks, got %d", len(chunks))
+	}
+	if chunks[3].Action() != engine.DeltaUnmodified || chunks[3].Size() != 4 || chunks[3].Content() != "line 3\nline 4\nline 5\nline 6\n" {
+		t.Errorf("unexpected context chunk: %s %d %q", chunks[3].Action(), chunks[3].Size(), chunks[3].Content())
+	}
+	if patch.IsBinary() {
+		t.Error("expected a text patch")
+	}
+}
+
+type lineCollector struct {
+	hunks []engine.DiffHunk
+	lines []engine.DiffLine
+}
+
+func (c *lineCollector) AnalyzeHunk(h engine.DiffHunk) error {
+	c.hunks = append(c.hunks, h)
+	return nil
+}
+
+func (c *lineCollector) AnalyzeLine(l engine.DiffLine) error {
+	c.lines = append(c.lines, l)
+	return nil
+}
+
+func expectLines(t *testing.T, lines []engine.DiffLine, expected ...string) {
+	t.Helper()
+
+	actual := make([]string, len(lines))
+	for i, l := range lines {
+		actual[i] = fmt.Sprintf("%s %d %d %s", l.Origin(), l.OldLineNumber(), l.NewLineNumber(), l.Content())
+	}
+
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected lines:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(actual, "\n"))
+	}
+}
+
+func expectFile(t *testing.T, files map[string]*FileDiff, path string, action engine.DeltaType) *FileDiff {
+	t.Helper()
+
+	file, ok := files[path]
+	if !ok {
+		t.Fatalf("missed the delta of %q", path)
+	}
+
+	if file.Action != action {
+		t.Errorf("%s: expected action %s, got %s", path, action, file.Action)
+	}
+
+	return file
+}
+
+func expectHunks(t *testing.T, file *FileDiff, hunks ...Hunk) {
+	t.Helper()
+
+	if len(file.Hunks) != len(hunks) {
+		t.Fatalf("%s: expected %d hunks, got %+v", file.ToPath, len(hunks), file.Hunks)
+	}
+
+	for i := range hunks {
+		if file.Hunks[i] != hunks[i] {
+			t.Errorf("%s: hunk %d: expected %+v, got %+v", file.ToPath, i, hunks[i], file.Hunks[i])
+		}
+	}
+}
+
+func parentHashes(repo *engine.Repository, h identifier.Hash) []identifier.Hash {
+	c, _ := repo.Commit(h)
+	return commitHashes(c.Parents())
+}
+
+func childrenHashes(repo *engine.Repository, h identifier.Hash) []identifier.Hash {
+	c, _ := repo.Commit(h)
+	return commitHashes(c.Children())
+}
+
+func commitHashes(commits []engine.Commit) []identifier.Hash {
+	hashes := make([]identifier.Hash, len(commits))
+	for i, c := range commits {
+		hashes[i] = c.Hash()
+	}
+	return hashes
+}
+
+func expectHashes(t *testing.T, name string, actual []identifier.Hash, expected ...identifier.Hash) {
+	t.Helper()
+
+	if len(actual) != len(expected) {
+		t.Errorf("%s: expected %v, got %v", name, expected, actual)
+		return
+	}
+
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("%s: expected %v, got %v", name, expected, actual)
+			return
+		}
+	}
+}
+
+func expectHashSet(t *testing.T, name string, actual []identifier.Hash, expected ...identifier.Hash) {
+	t.Helper()
+
+	set := identifier.NewHashSet()
+	for _, h := range actual {
+		set.Add(h)
+	}
+
+	if set.Count() != len(expected) || len(actual) != len(expected) {
+		t.Errorf("%s: expected %v, got %v", name, expected, actual)
+		return
+	}
+
+	for _, h := range expected {
+		if !set.Has(h) {
+			t.Errorf("%s: expected %v, got %v", name, expected, actual)
+			return
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package enginetest
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+)
+
+// fixtureEpoch is the author date of the first commit in the fixtures, every
+// following commit is one minute later to keep the hashes deterministic.
+var fixtureEpoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Fixture is a throwaway git repository that is built by the git command line,
+// it is the reference that the adapters are compared against.
+type Fixture struct {
+	t       testing.TB
+	Dir     string
+	commits map[string]identifier.Hash
+	clock   time.Time
+}
+
+// NewFixture initializes an empty repository in a temporary directory with
+// master as the initial branch. The test is skipped if git is not installed.
+func NewFixture(t testing.TB) *Fixture {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	f := &Fixture{
+		t:       t,
+		Dir:     t.TempDir(),
+		commits: make(map[string]identifier.Hash),
+		clock:   fixtureEpoch,
+	}
+
+	f.Git("init", "--quiet")
+	f.Git("symbolic-ref", "HEAD", "refs/heads/master")
+
+	return f
+}
+
+// Git runs a git command in the fixture directory and returns its trimmed output.
+func (f *Fixture) Git(args ...string) string {
+	f.t.Helper()
+
+	date := f.clock.Format(time.RFC3339)
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = f.Dir
+	cmd.Env = append(os.Environ(),
+		"HOME="+f.Dir,
+		"GIT_CONFIG_NOSYSTEM=1",
+		"GIT_AUTHOR_NAME=Tester",
+		"GIT_AUTHOR_EMAIL=tester@example.com",
+		"GIT_AUTHOR_DATE="+date,
+		"GIT_COMMITTER_NAME=Tester",
+		"GIT_COMMITTER_EMAIL=tester@example.com",
+		"GIT_COMMITTER_DATE="+date,
+	)
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		f.t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+// Write creates or overwrites a file in the working tree and stages it.
+func (f *Fixture) Write(path, content string) {
+	f.t.Helper()
+
+	p := filepath.Join(f.Dir, path)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		f.t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+		f.t.Fatal(err)
+	}
+
+	f.Git("add", path)
+}
+
+// Symlink creates a symbolic link in the working tree and stages it.
+func (f *Fixture) Symlink(target, path string) {
+	f.t.Helper()
+
+	if err := os.Symlink(target, filepath.Join(f.Dir, path)); err != nil {
+		f.t.Fatal(err)
+	}
+
+	f.Git("add", path)
+}
+
+// Remove deletes a file from the working tree and the index.
+func (f *Fixture) Remove(path string) {
+	f.t.Helper()
+	f.Git("rm", "--quiet", path)
+}
+
+// Move renames a file in the working tree and the index.
+func (f *Fixture) Move(from, to string) {
+	f.t.Helper()
+	f.Git("mv", from, to)
+}
+
+// Commit records the staged changes and labels the commit with the given name.
+func (f *Fixture) Commit(label string) identifier.Hash {
+	f.t.Helper()
+
+	f.Git("commit", "--quiet", "--allow-empty", "-m", label)
+	return f.label(label)
+}
+
+// CommitAs records the staged changes with an author in the `Name <email>` form,
+// the committer is kept as the default one.
+func (f *Fixture) CommitAs(label, author string) identifier.Hash {
+	f.t.Helper()
+
+	f.Git("commit", "--quiet", "--allow-empty", "--author", author, "-m", label)
+	return f.label(label)
+}
+
+// Advance moves the dates of the next commits forward.
+func (f *Fixture) Advance(d time.Duration) {
+	f.clock = f.clock.Add(d)
+}
+
+// Merge merges a branch into the current branch with a merge commit, even if
+// it could be fast-forwarded.
+func (f *Fixture) Merge(label, branch string) identifier.Hash {
+	f.t.Helper()
+
+	f.Git("merge", "--quiet", "--no-ff", "-m", label, branch)
+	return f.label(label)
+}
+
+// Tag tags the current commit, the tag is annotated if it has a message.
+func (f *Fixture) Tag(name, message string) {
+	f.t.Helper()
+
+	if message == "" {
+		f.Git("tag", name)
+		return
+	}
+	f.Git("tag", "--annotate", "-m", message, name)
+}
+
+// Checkout switches to a branch, the branch is created if it does not exist.
+func (f *Fixture) Checkout(branch string) {
+	f.t.Helper()
+
+	if f.hasBranch(branch) {
+		f.Git("checkout", "--quiet", branch)
+		return
+	}
+	f.Git("checkout", "--quiet", "-b", branch)
+}
+
+// Hash returns the hash of a labeled commit.
+func (f *Fixture) Hash(label string) identifier.Hash {
+	f.t.Helper()
+
+	h, ok := f.commits[label]
+	if !ok {
+		f.t.Fatalf("unknown commit label: %q", label)
+	}
+	return h
+}
+
+func (f *Fixture) hasBranch(branch string) bool {
+	cmd := exec.Command("git", "show-ref", "--verify", "--quiet", "refs/heads/"+branch)
+	cmd.Dir = f.Dir
+	return cmd.Run() == nil
+}
+
+func (f *Fixture) label(label string) identifier.Hash {
+	f.t.Helper()
+
+	h := identifier.NewHash(f.Git("rev-parse", "HEAD"))
+	if _, ok := f.commits[label]; ok {
+		f.t.Fatalf("duplicated commit label: %q", label)
+	}
+
+	f.commits[label] = h
+	f.clock = f.clock.Add(time.Minute)
+
+	return h
+}
@@ -13,6 +13,9 @@ import (
 var diffOptions git.DiffOptions
 var diffFindOptions git.DiffFindOptions
 
+// patchOptions are the diff options with the default number of context lines.
+var patchOptions git.DiffOptions
+
 func init() {
 	var err error
 	diffOptions, err = git.DefaultDiffOptions()
@@ -24,6 +27,9 @@ func init() {
 	diffOptions.OldPrefix = ""
 	diffOptions.NewPrefix = ""
 
+	patchOptions = diffOptions
+	patchOptions.ContextLines = 3
+
 	diffFindOptions, err = git.DefaultDiffFindOptions()
 	if err != nil {
 		panic(err)
@@ -31,15 +37,30 @@ func init() {
 	diffFindOptions.Flags = git.DiffFindRenames
 }
 
+// newDiffFindOptions returns the find options of the renames and the copies
+// that are tuned by the options.
+func newDiffFindOptions(opts *engine.DiffOptions) *git.DiffFindOptions {
+	findOptions := diffFindOptions
+	findOptions.RenameThreshold = uint16(opts.RenameSimilarity())
+	if opts.DetectCopies() {
+		findOptions.Flags |= git.DiffFindCopies
+		findOptions.CopyThreshold = uint16(opts.CopySimilarity())
+	}
+	return &findOptions
+}
+
 type commit struct {
-	id         git.Oid
-	repo       *git.Repository
-	developer  engine.Developer
-	authorDate time.Time
-	files      map[string]*engine.FileInfo
-	children   []engine.Commit
-	parents    []engine.Commit
-	branches   engine.StringSet
+	id           git.Oid
+	handles      *handlePool
+	findOptions  *git.DiffFindOptions
+	developer    engine.Developer
+	contributors engine.DeveloperSet
+	bot          bool
+	authorDate   time.Time
+	files        map[string]*engine.FileInfo
+	children     []engine.Commit
+	parents      []engine.Commit
+	branches     engine.StringSet
 }
 
 func (c *commit) Branches() engine.StringSet {
@@ -74,10 +95,11 @@ func (c *commit) SetFiles(files map[string]*engine.FileInfo) {
 	c.files = files
 }
 
-func newCommit(repo *git.Repository, id git.Oid) engine.Commit {
+func newCommit(handles *handlePool, id git.Oid, findOptions *git.DiffFindOptions) engine.Commit {
 	return &commit{
-		id:   id,
-		repo: repo,
+		id:          id,
+		handles:     handles,
+		findOptions: findOptions,
 	}
 }
 
@@ -93,15 +115,41 @@ func (c *commit) SetDeveloper(dev engine.Developer) {
 	c.developer = dev
 }
 
+func (c *commit) SetContributors(devs engine.DeveloperSet) {
+	c.contributors = devs
+}
+
+func (c *commit) Contributors() engine.DeveloperSet {
+	return c.contributors
+}
+
+func (c *commit) SetBot(bot bool) {
+	c.bot = bot
+}
+
+func (c *commit) Bot() bool {
+	return c.bot
+}
+
+// Object looks up the commit with a handle of the repository that is kept
+// until the object is freed, so the objects can be read concurrently.
 func (c *commit) Object() (engine.CommitObject, error) {
-	obj, err := c.repo.LookupCommit(&c.id)
+	repo, err := c.handles.get()
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := repo.LookupCommit(&c.id)
 	if err != nil {
+		c.handles.put(repo)
 		return nil, translateGitError(err)
 	}
 
 	return &commitObject{
-		Repository: c.repo,
-		Commit:     obj,
+		Repository:  repo,
+		Commit:      obj,
+		findOptions: c.findOptions,
+		handles:     c.handles,
 	}, nil
 }
 
@@ -126,6 +174,8 @@ func (c *commit) Developer() engine.Developer {
 type commitObject struct {
 	*git.Repository
 	*git.Commit
+	findOptions *git.DiffFindOptions
+	handles     *handlePool
 }
 
 func (c *commitObject) FirstParentHash() identifier.Hash {
@@ -168,6 +218,10 @@ func (c *commit) IsMerge() bool {
 func (c *commitObject) CommitterDate() time.Time {
 	return c.Commit.Committer().When
 }
+func (c *commitObject) Committer() *engine.Signature {
+	return (*engine.Signature)(c.Commit.Committer())
+}
+
 func (c *commitObject) Author() *engine.Signature {
 	return (*engine.Signature)(c.Commit.Author())
 }
@@ -242,7 +296,7 @@ func (c *commitObject) diff(ctx context.Context, cbFile git.DiffForEachFileCallb
 	}
 	defer diff.Free()
 
-	err = diff.FindSimilar(&diffFindOptions)
+	err = diff.FindSimilar(c.findOptions)
 	if err != nil {
 		return err
 	}
@@ -292,6 +346,10 @@ func (c *commitObject) DiffLines(ctx context.Context, cbFile engine.LineDiffCB)
 				default:
 				}
 
+				if !isContentLine(&line) {
+					return nil
+				}
+
 				return file.AnalyzeLine(newDiffLine(&line))
 			}, err
 		}, err
@@ -300,6 +358,7 @@ func (c *commitObject) DiffLines(ctx context.Context, cbFile engine.LineDiffCB)
 
 func (c *commitObject) Free() {
 	c.Commit.Free()
+	c.handles.put(c.Repository)
 }
 
 func (c *commit) FirstParent() engine.Commit {
@@ -1,6 +1,8 @@
 package git2go
 
 import (
+	"strings"
+
 	git "github.com/libgit2/git2go/v31"
 	"github.com/repofuel/repofuel/ingest/pkg/engine"
 	"github.com/rs/zerolog/log"
@@ -54,17 +56,59 @@ func (d *diffDelta) NewContent() ([]byte, error) {
 }
 
 func (d *diffDelta) content(oid *git.Oid) ([]byte, error) {
-	if oid == nil || oid.IsZero() {
-		return nil, nil
+	blob, err := d.blob(oid)
+	if err != nil || blob == nil {
+		return nil, err
+	}
+	defer blob.Free()
+
+	return blob.Contents(), nil
+}
+
+func (d *diffDelta) Patch() (engine.FilePatch, error) {
+	if d.IsBinary() {
+		return engine.NewFilePatch(true, nil), nil
 	}
 
-	blob, err := d.repo.LookupBlob(oid)
+	oldBlob, err := d.blob(d.DiffDelta.OldFile.Oid)
 	if err != nil {
 		return nil, err
 	}
-	defer blob.Free()
+	if oldBlob != nil {
+		defer oldBlob.Free()
+	}
 
-	return blob.Contents(), nil
+	newBlob, err := d.blob(d.DiffDelta.NewFile.Oid)
+	if err != nil {
+		return nil, err
+	}
+	if newBlob != nil {
+		defer newBlob.Free()
+	}
+
+	var lines []engine.DiffLine
+	err = git.DiffBlobs(oldBlob, d.FromPath(), newBlob, d.ToPath(), &patchOptions, func(git.DiffDelta, float64) (git.DiffForEachHunkCallback, error) {
+		return func(git.DiffHunk) (git.DiffForEachLineCallback, error) {
+			return func(line git.DiffLine) error {
+				if isContentLine(&line) {
+					lines = append(lines, newDiffLine(&line))
+				}
+				return nil
+			}, nil
+		}, nil
+	}, git.DiffDetailLines)
+	if err != nil {
+		return nil, err
+	}
+
+	return engine.NewFilePatch(false, lines), nil
+}
+
+func (d *diffDelta) blob(oid *git.Oid) (*git.Blob, error) {
+	if oid == nil || oid.IsZero() {
+		return nil, nil
+	}
+	return d.repo.LookupBlob(oid)
 }
 
 func (d *diffDelta) FromPath() string {
@@ -76,7 +120,7 @@ func (d *diffDelta) ToPath() string {
 }
 
 func (d *diffDelta) IsBinary() bool {
-	return d.DiffDelta.Flags == git.DiffFlagBinary
+	return d.DiffDelta.Flags&git.DiffFlagBinary != 0
 }
 
 func (d *diffDelta) IsSymlink() bool {
@@ -102,6 +146,13 @@ func (h *diffHunk) AddressDeleted() engine.ChunkAddr {
 	}
 }
 
+func (h *diffHunk) AddressAdded() engine.ChunkAddr {
+	return engine.ChunkAddr{
+		Start: h.DiffHunk.NewStart,
+		End:   h.DiffHunk.NewStart + h.DiffHunk.NewLines - 1,
+	}
+}
+
 func newDiffHunk(h *git.DiffHunk) *diffHunk {
 	return &diffHunk{DiffHunk: h}
 }
@@ -113,3 +164,37 @@ type diffLine struct {
 func newDiffLine(l *git.DiffLine) *diffLine {
 	return &diffLine{DiffLine: l}
 }
+
+// isContentLine reports whether the line is a line of the files, and not a
+// marker of a missing line ending at the end of a file.
+func isContentLine(l *git.DiffLine) bool {
+	switch l.Origin {
+	case git.DiffLineContext, git.DiffLineAddition, git.DiffLineDeletion:
+		return true
+	default:
+		return false
+	}
+}
+
+func (l *diffLine) Origin() engine.LineOrigin {
+	switch l.DiffLine.Origin {
+	case git.DiffLineAddition:
+		return engine.LineAdded
+	case git.DiffLineDeletion:
+		return engine.LineDeleted
+	default:
+		return engine.LineContext
+	}
+}
+
+func (l *diffLine) OldLineNumber() int {
+	return l.DiffLine.OldLineno
+}
+
+func (l *diffLine) NewLineNumber() int {
+	return l.DiffLine.NewLineno
+}
+
+func (l *diffLine) Content() string {
+	return strings.TrimSuffix(l.DiffLine.Content, "\n")
+}
@@ -5,9 +5,11 @@ package git2go
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
+	"sync"
 
 	git "github.com/libgit2/git2go/v31"
 	"github.com/repofuel/repofuel/ingest/pkg/engine"
@@ -32,11 +34,49 @@ func init() {
 }
 
 type Adapter struct {
-	git *git.Repository
+	git         *git.Repository
+	findOptions *git.DiffFindOptions
+	// handles are used by the reads that could run concurrently, such as the
+	// diffs and the blames of the parallel analysis.
+	handles *handlePool
+}
+
+// handlePool keeps the opened handles of the repository to be reused, a libgit2
+// repository handle should not be used by several threads at the same time, so
+// every concurrent read takes its own handle.
+type handlePool struct {
+	path string
+	mu   sync.Mutex
+	free []*git.Repository
+}
+
+func (p *handlePool) get() (*git.Repository, error) {
+	p.mu.Lock()
+	if n := len(p.free); n > 0 {
+		repo := p.free[n-1]
+		p.free = p.free[:n-1]
+		p.mu.Unlock()
+		return repo, nil
+	}
+	p.mu.Unlock()
+
+	return git.OpenRepository(p.path)
+}
+
+func (p *handlePool) put(repo *git.Repository) {
+	p.mu.Lock()
+	p.free = append(p.free, repo)
+	p.mu.Unlock()
 }
 
 func NewAdapter() *Adapter {
-	return &Adapter{}
+	return NewAdapterWithOptions(nil)
+}
+
+// NewAdapterWithOptions creates an adapter that detects the renames and the
+// copies by the options, the defaults are used if the options are nil.
+func NewAdapterWithOptions(opts *engine.DiffOptions) *Adapter {
+	return &Adapter{findOptions: newDiffFindOptions(opts)}
 }
 
 func (adp *Adapter) Open(path string) error {
@@ -46,8 +86,11 @@ func (adp *Adapter) Open(path string) error {
 		if git.IsErrorCode(err, git.ErrNotFound) {
 			return engine.ErrLocalRepoNotExist
 		}
+		return err
 	}
-	return err
+
+	adp.handles = &handlePool{path: path}
+	return nil
 }
 
 func (adp *Adapter) Clone(ctx context.Context, url string, path string, getAuth engine.BasicAuthFunc) error {
@@ -69,6 +112,34 @@ func (adp *Adapter) Clone(ctx context.Context, url string, path string, getAuth
 			Bare:         true,
 		})
 	}
+	if err != nil {
+		return remoteError(err)
+	}
+
+	adp.handles = &handlePool{path: path}
+	return nil
+}
+
+// errorClassHTTP is GIT_ERROR_HTTP of libgit2, git2go does not have a constant for it.
+const errorClassHTTP git.ErrorClass = 34
+
+// remoteError marks the network errors of cloning and fetching as
+// engine.RemoteError. The authentication and the certificate errors are not
+// marked because they are not fixed by retrying.
+func remoteError(err error) error {
+	var gitErr *git.GitError
+	if !errors.As(err, &gitErr) {
+		return err
+	}
+
+	if gitErr.Code == git.ErrAuth || gitErr.Code == git.ErrCertificate {
+		return err
+	}
+
+	switch gitErr.Class {
+	case git.ErrClassNet, git.ErrClassSsh, errorClassHTTP:
+		return &engine.RemoteError{Err: err}
+	}
 
 	return err
 }
@@ -106,7 +177,7 @@ func (adp *Adapter) Branches() (map[string]identifier.Hash, error) {
 }
 
 func (adp *Adapter) Commit(h identifier.Hash) (engine.Commit, error) {
-	return newCommit(adp.git, git.Oid(h)), nil
+	return newCommit(adp.handles, git.Oid(h), adp.findOptions), nil
 }
 
 func (adp *Adapter) Fetch(ctx context.Context, getAuth engine.BasicAuthFunc, remote, url string, branches ...string) error {
@@ -120,7 +191,7 @@ func (adp *Adapter) Fetch(ctx context.Context, getAuth engine.BasicAuthFunc, rem
 		return err
 	}
 
-	return re.Fetch(branches, defaultFetchOptions(ctx, getAuth), "")
+	return remoteError(re.Fetch(branches, defaultFetchOptions(ctx, getAuth), ""))
 }
 
 func defaultFetchOptions(ctx context.Context, getAuth engine.BasicAuthFunc) *git.FetchOptions {
@@ -181,7 +252,13 @@ func (r *Adapter) InducingCommitsOneByOne(id identifier.Hash, path string, chunk
 		MaxLine:            uint32(chunk.End),
 	}
 
-	blame, err := r.git.BlameFile(path, opts)
+	repo, err := r.handles.get()
+	if err != nil {
+		return nil, err
+	}
+	defer r.handles.put(repo)
+
+	blame, err := repo.BlameFile(path, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -199,7 +276,104 @@ func (r *Adapter) InducingCommitsOneByOne(id identifier.Hash, path string, chunk
 	return IDs, err
 }
 
+func (r *Adapter) Tags() ([]*engine.Tag, error) {
+	var tags []*engine.Tag
+	err := r.git.Tags.Foreach(func(name string, id *git.Oid) error {
+		obj, err := r.git.Lookup(id)
+		if err != nil {
+			return translateGitError(err)
+		}
+		defer obj.Free()
+
+		tag := &engine.Tag{Name: strings.TrimPrefix(name, "refs/tags/")}
+		if obj.Type() == git.ObjectTag {
+			annotated, err := obj.AsTag()
+			if err != nil {
+				return err
+			}
+			if tagger := annotated.Tagger(); tagger != nil {
+				tag.Date = tagger.When
+			}
+		}
+
+		peeled, err := obj.Peel(git.ObjectCommit)
+		if err != nil {
+			if git.IsErrorCode(err, git.ErrInvalidSpec) || git.IsErrorCode(err, git.ErrPeel) {
+				// the tags of the other objects are skipped
+				return nil
+			}
+			return err
+		}
+		defer peeled.Free()
+
+		c, err := peeled.AsCommit()
+		if err != nil {
+			return err
+		}
+
+		tag.Commit = identifier.Hash(*c.Id())
+		if tag.Date.IsZero() {
+			tag.Date = c.Committer().When
+		}
+
+		tags = append(tags, tag)
+		return nil
+	})
+
+	return tags, err
+}
+
+func (r *Adapter) ReadFile(id identifier.Hash, path string) ([]byte, error) {
+	repo, err := r.handles.get()
+	if err != nil {
+		return nil, err
+	}
+	defer r.handles.put(repo)
+
+	oid := git.Oid(id)
+	c, err := repo.LookupCommit(&oid)
+	if err != nil {
+		return nil, translateGitError(err)
+	}
+	defer c.Free()
+
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Free()
+
+	entry, err := tree.EntryByPath(path)
+	if err != nil {
+		if git.IsErrorCode(err, git.ErrNotFound) {
+			return nil, engine.ErrFileNotFound
+		}
+		return nil, err
+	}
+
+	blob, err := repo.LookupBlob(entry.Id)
+	if err != nil {
+		return nil, translateGitError(err)
+	}
+	defer blob.Free()
+
+	return blob.Contents(), nil
+}
+
 func (r *Adapter) InducingCommits(ctx context.Context, id identifier.Hash, path string, chunks ...engine.ChunkAddr) (identifier.HashSet, error) {
+	sets, err := r.InducingCommitsByChunk(ctx, id, path, chunks...)
+	if err != nil {
+		return nil, err
+	}
+
+	IDs := identifier.NewHashSet()
+	for _, s := range sets {
+		IDs.Update(s)
+	}
+	return IDs, nil
+}
+
+func (r *Adapter) InducingCommitsByChunk(ctx context.Context, id identifier.Hash, path string, chunks ...engine.ChunkAddr) ([]identifier.HashSet, error) {
 	oid := git.Oid(id)
 
 	opts := &git.BlameOptions{
@@ -211,19 +385,27 @@ func (r *Adapter) InducingCommits(ctx context.Context, id identifier.Hash, path
 		MaxLine:            uint32(chunks[len(chunks)-1].End),
 	}
 
-	blame, err := r.git.BlameFile(path, opts)
+	repo, err := r.handles.get()
+	if err != nil {
+		return nil, err
+	}
+	defer r.handles.put(repo)
+
+	blame, err := repo.BlameFile(path, opts)
 	if err != nil {
 		return nil, err
 	}
 	defer blame.Free()
 
-	IDs := identifier.NewHashSet()
+	sets := make([]identifier.HashSet, len(chunks))
 	for i := range chunks {
 		current := chunks[i]
 		hunk, err := blame.HunkByLine(current.Start)
 		if err != nil {
 			return nil, err
 		}
+		IDs := identifier.NewHashSet()
+		sets[i] = IDs
 		IDs.Add(identifier.Hash(*hunk.OrigCommitId))
 
 		endLine := int(hunk.FinalStartLineNumber + hunk.LinesInHunk - 1)
@@ -244,5 +426,5 @@ func (r *Adapter) InducingCommits(ctx context.Context, id identifier.Hash, path
 		}
 	}
 
-	return IDs, err
+	return sets, err
 }
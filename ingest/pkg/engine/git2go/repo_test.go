@@ -1,11 +1,56 @@
 package git2go
 
 import (
+	"errors"
 	"testing"
 
 	git "github.com/libgit2/git2go/v31"
+	"github.com/repofuel/repofuel/ingest/pkg/engine"
+	"github.com/repofuel/repofuel/ingest/pkg/engine/enginetest"
 )
 
+func TestConformance(t *testing.T) {
+	enginetest.Run(t, func() engine.RepositoryAdapter {
+		return NewAdapter()
+	})
+}
+
+func TestDiffOptions(t *testing.T) {
+	enginetest.RunDiffOptions(t, func(opts *engine.DiffOptions) engine.RepositoryAdapter {
+		return NewAdapterWithOptions(opts)
+	}, true)
+}
+
+func TestConcurrentReads(t *testing.T) {
+	enginetest.RunConcurrentReads(t, func() engine.RepositoryAdapter {
+		return NewAdapter()
+	})
+}
+
+func TestRemoteError(t *testing.T) {
+	cases := []struct {
+		err    *git.GitError
+		remote bool
+	}{
+		{&git.GitError{Class: git.ErrClassNet, Code: git.ErrGeneric}, true},
+		{&git.GitError{Class: git.ErrClassSsh, Code: git.ErrGeneric}, true},
+		{&git.GitError{Class: errorClassHTTP, Code: git.ErrGeneric}, true},
+		{&git.GitError{Class: errorClassHTTP, Code: git.ErrAuth}, false},
+		{&git.GitError{Class: git.ErrClassSSL, Code: git.ErrCertificate}, false},
+		{&git.GitError{Class: git.ErrClassInvalid, Code: git.ErrExists}, false},
+	}
+	for _, c := range cases {
+		var remoteErr *engine.RemoteError
+		if got := errors.As(remoteError(c.err), &remoteErr); got != c.remote {
+			t.Errorf("class %v code %v: expected remote error %v, got %v", c.err.Class, c.err.Code, c.remote, got)
+		}
+	}
+
+	if remoteError(nil) != nil {
+		t.Error("expected no error")
+	}
+}
+
 func TestBlame(t *testing.T) {
 	t.Skip("the test has a known error in libgit2")
 
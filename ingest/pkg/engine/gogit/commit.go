@@ -0,0 +1,376 @@
+package gogit
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/repofuel/repofuel/ingest/pkg/engine"
+	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+)
+
+// newDiffTreeOptions follows the rename detection of libgit2 to produce the
+// same results of the git2go adapter. The copies are not detected because
+// go-git does not support them.
+func newDiffTreeOptions(opts *engine.DiffOptions) *object.DiffTreeOptions {
+	return &object.DiffTreeOptions{
+		DetectRenames: true,
+		RenameScore:   uint(opts.RenameSimilarity()),
+	}
+}
+
+type commit struct {
+	id           plumbing.Hash
+	repo         *git.Repository
+	diffOptions  *object.DiffTreeOptions
+	developer    engine.Developer
+	contributors engine.DeveloperSet
+	bot          bool
+	authorDate   time.Time
+	files        map[string]*engine.FileInfo
+	children     []engine.Commit
+	parents      []engine.Commit
+	branches     engine.StringSet
+}
+
+func newCommit(repo *git.Repository, id plumbing.Hash, diffOptions *object.DiffTreeOptions) engine.Commit {
+	return &commit{
+		id:          id,
+		repo:        repo,
+		diffOptions: diffOptions,
+	}
+}
+
+func (c *commit) Branches() engine.StringSet {
+	return c.branches
+}
+
+func (c *commit) NumBranches() int {
+	return len(c.branches)
+}
+
+func (c *commit) AddBranch(branch string) {
+	if c.branches == nil {
+		c.branches = engine.NewStringSet()
+	}
+	c.branches.Add(branch)
+}
+
+func (c *commit) HasFile(path string) bool {
+	_, ok := c.files[path]
+	return ok
+}
+
+func (c *commit) Files() map[string]*engine.FileInfo {
+	return c.files
+}
+
+func (c *commit) SetFiles(files map[string]*engine.FileInfo) {
+	if len(files) == 0 {
+		return
+	}
+
+	c.files = files
+}
+
+func (c *commit) SetAuthorDate(date time.Time) {
+	c.authorDate = date
+}
+
+func (c *commit) AuthorDate() time.Time {
+	return c.authorDate
+}
+
+func (c *commit) SetDeveloper(dev engine.Developer) {
+	c.developer = dev
+}
+
+func (c *commit) SetContributors(devs engine.DeveloperSet) {
+	c.contributors = devs
+}
+
+func (c *commit) Contributors() engine.DeveloperSet {
+	return c.contributors
+}
+
+func (c *commit) SetBot(bot bool) {
+	c.bot = bot
+}
+
+func (c *commit) Bot() bool {
+	return c.bot
+}
+
+func (c *commit) Developer() engine.Developer {
+	return c.developer
+}
+
+func (c *commit) Object() (engine.CommitObject, error) {
+	obj, err := c.repo.CommitObject(c.id)
+	if err != nil {
+		return nil, translateGitError(err)
+	}
+
+	return &commitObject{
+		repo:        c.repo,
+		commit:      obj,
+		diffOptions: c.diffOptions,
+	}, nil
+}
+
+func (c *commit) Hash() identifier.Hash {
+	return identifier.Hash(c.id)
+}
+
+func (c *commit) String() string {
+	return c.id.String()
+}
+
+func (c *commit) NumChildren() int {
+	return len(c.children)
+}
+
+func (c *commit) IsMerge() bool {
+	return len(c.parents) > 1
+}
+
+func (c *commit) Children() []engine.Commit {
+	return c.children
+}
+
+func (c *commit) Parents() []engine.Commit {
+	return c.parents
+}
+
+func (c *commit) NumParents() int {
+	return len(c.parents)
+}
+
+func (c *commit) AddChild(child engine.Commit) {
+	c.children = append(c.children, child)
+}
+
+func (c *commit) AddParent(parent engine.Commit) {
+	c.parents = append(c.parents, parent)
+}
+
+func (c *commit) SetParents(parents []engine.Commit) {
+	c.parents = parents
+}
+
+func (c *commit) FirstParent() engine.Commit {
+	return c.parents[0]
+}
+
+func (c *commit) HasParent() bool {
+	return len(c.parents) > 0
+}
+
+type commitObject struct {
+	repo        *git.Repository
+	commit      *object.Commit
+	diffOptions *object.DiffTreeOptions
+}
+
+func (c *commitObject) Hash() identifier.Hash {
+	return identifier.Hash(c.commit.Hash)
+}
+
+func (c *commitObject) FirstParentHash() identifier.Hash {
+	return identifier.Hash(c.commit.ParentHashes[0])
+}
+
+func (c *commitObject) Message() string {
+	return c.commit.Message
+}
+
+func (c *commitObject) NumParents() int {
+	return len(c.commit.ParentHashes)
+}
+
+func (c *commitObject) ParentHashes() []identifier.Hash {
+	s := make([]identifier.Hash, len(c.commit.ParentHashes))
+	for i, h := range c.commit.ParentHashes {
+		s[i] = identifier.Hash(h)
+	}
+	return s
+}
+
+func (c *commitObject) AuthorDate() time.Time {
+	return c.commit.Author.When
+}
+
+func (c *commitObject) Author() *engine.Signature {
+	return &engine.Signature{
+		Name:  c.commit.Author.Name,
+		Email: c.commit.Author.Email,
+		When:  c.commit.Author.When,
+	}
+}
+
+func (c *commitObject) Committer() *engine.Signature {
+	return &engine.Signature{
+		Name:  c.commit.Committer.Name,
+		Email: c.commit.Committer.Email,
+		When:  c.commit.Committer.When,
+	}
+}
+
+func (c *commitObject) CommitterDate() time.Time {
+	return c.commit.Committer.When
+}
+
+func (c *commitObject) AuthorEmail() string {
+	return c.commit.Author.Email
+}
+
+func (c *commitObject) AuthorName() string {
+	return c.commit.Author.Name
+}
+
+// changes returns the changes against the first parent sorted by path.
+func (c *commitObject) changes(ctx context.Context) (object.Changes, error) {
+	var oldTree *object.Tree
+	if len(c.commit.ParentHashes) > 0 {
+		p, err := c.repo.CommitObject(c.commit.ParentHashes[0])
+		if err != nil {
+			return nil, translateGitError(err)
+		}
+
+		oldTree, err = commitTree(p)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	newTree, err := commitTree(c.commit)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := object.DiffTreeWithOptions(ctx, oldTree, newTree, c.diffOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Sort(changes)
+
+	return changes, nil
+}
+
+func (c *commitObject) DiffFiles(ctx context.Context, cbFile engine.FileDiffCB) error {
+	changes, err := c.changes(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		delta, err := newDiffDelta(change)
+		if err != nil {
+			return err
+		}
+
+		err = cbFile(delta)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *commitObject) DiffHunks(ctx context.Context, cbFile engine.HunkDiffCB) error {
+	changes, err := c.changes(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		delta, err := newDiffDelta(change)
+		if err != nil {
+			return err
+		}
+
+		file, err := cbFile(delta)
+		if err != nil {
+			return err
+		}
+
+		hunks, err := delta.hunks()
+		if err != nil {
+			return err
+		}
+
+		for _, hunk := range hunks {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			default:
+			}
+
+			err = file.AnalyzeHunk(hunk)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *commitObject) DiffLines(ctx context.Context, cbFile engine.LineDiffCB) error {
+	changes, err := c.changes(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		delta, err := newDiffDelta(change)
+		if err != nil {
+			return err
+		}
+
+		file, err := cbFile(delta)
+		if err != nil {
+			return err
+		}
+
+		hunks, err := delta.lineHunks(0)
+		if err != nil {
+			return err
+		}
+
+		for _, hunk := range hunks {
+			err = file.AnalyzeHunk(hunk.diffHunk)
+			if err != nil {
+				return err
+			}
+
+			for _, line := range hunk.lines {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				default:
+				}
+
+				err = file.AnalyzeLine(line)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *commitObject) Free() {}
@@ -0,0 +1,406 @@
+package gogit
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/go-git/go-git/v5/utils/merkletrie"
+	"github.com/repofuel/repofuel/ingest/pkg/engine"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+type diffDelta struct {
+	change   *object.Change
+	action   engine.DeltaType
+	from, to *object.File
+}
+
+func newDiffDelta(change *object.Change) (*diffDelta, error) {
+	from, to, err := change.Files()
+	if err != nil {
+		return nil, translateGitError(err)
+	}
+
+	action, err := change.Action()
+	if err != nil {
+		return nil, err
+	}
+
+	d := &diffDelta{
+		change: change,
+		from:   from,
+		to:     to,
+	}
+
+	switch action {
+	case merkletrie.Insert:
+		d.action = engine.DeltaAdded
+	case merkletrie.Delete:
+		d.action = engine.DeltaDeleted
+	case merkletrie.Modify:
+		if change.From.Name != change.To.Name {
+			d.action = engine.DeltaRenamed
+		} else {
+			d.action = engine.DeltaModified
+		}
+	default:
+		d.action = engine.DeltaOther
+	}
+
+	return d, nil
+}
+
+func (d *diffDelta) Action() engine.DeltaType {
+	return d.action
+}
+
+// FromPath returns the old path of the file, it is the same as the new path
+// for added files to match the libgit2 deltas.
+func (d *diffDelta) FromPath() string {
+	if d.change.From.Name == "" {
+		return d.change.To.Name
+	}
+	return d.change.From.Name
+}
+
+// ToPath returns the new path of the file, it is the same as the old path
+// for deleted files to match the libgit2 deltas.
+func (d *diffDelta) ToPath() string {
+	if d.change.To.Name == "" {
+		return d.change.From.Name
+	}
+	return d.change.To.Name
+}
+
+func (d *diffDelta) IsBinary() bool {
+	for _, f := range [...]*object.File{d.from, d.to} {
+		if f == nil {
+			continue
+		}
+
+		bin, err := f.IsBinary()
+		if err == nil && bin {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *diffDelta) IsSymlink() bool {
+	return d.change.From.TreeEntry.Mode == filemode.Symlink || d.change.To.TreeEntry.Mode == filemode.Symlink
+}
+
+func (d *diffDelta) OldContent() ([]byte, error) {
+	return content(d.from)
+}
+
+func (d *diffDelta) NewContent() ([]byte, error) {
+	return content(d.to)
+}
+
+func content(f *object.File) ([]byte, error) {
+	if f == nil {
+		return nil, nil
+	}
+
+	s, err := f.Contents()
+	if err != nil {
+		return nil, translateGitError(err)
+	}
+
+	return []byte(s), nil
+}
+
+// hunks computes the hunks of the delta without context lines and ignoring the
+// whitespaces, which are the same diff options used in the git2go adapter.
+func (d *diffDelta) hunks() ([]*diffHunk, error) {
+	lineHunks, err := d.lineHunks(0)
+	if err != nil {
+		return nil, err
+	}
+
+	hunks := make([]*diffHunk, len(lineHunks))
+	for i, h := range lineHunks {
+		hunks[i] = h.diffHunk
+	}
+
+	return hunks, nil
+}
+
+func (d *diffDelta) Patch() (engine.FilePatch, error) {
+	if d.IsBinary() {
+		return engine.NewFilePatch(true, nil), nil
+	}
+
+	hunks, err := d.lineHunks(3)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []engine.DiffLine
+	for _, h := range hunks {
+		for _, l := range h.lines {
+			lines = append(lines, l)
+		}
+	}
+
+	return engine.NewFilePatch(false, lines), nil
+}
+
+// diffSegment is either a run of equal lines, or a change of deleted lines that
+// are followed by added lines. The lines numbers start from one.
+type diffSegment struct {
+	equal              bool
+	oldStart, oldLines int
+	newStart, newLines int
+}
+
+// lineHunks computes the hunks of the delta and their lines ignoring the
+// whitespaces. The hunks have the given number of context lines around the
+// changes, and the changes that are closer than twice the context lines are
+// in the same hunk, as libgit2 does.
+func (d *diffDelta) lineHunks(context int) ([]*lineHunk, error) {
+	if d.IsBinary() {
+		return nil, nil
+	}
+
+	oldContent, err := d.OldContent()
+	if err != nil {
+		return nil, err
+	}
+
+	newContent, err := d.NewContent()
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := diff.Do(ignoreWhitespace(oldContent), ignoreWhitespace(newContent))
+
+	var segments []*diffSegment
+	var current *diffSegment
+	oldLine, newLine := 1, 1
+
+	for _, chunk := range diffs {
+		n := countLines(chunk.Text)
+
+		switch chunk.Type {
+		case diffmatchpatch.DiffEqual:
+			current = nil
+			segments = append(segments, &diffSegment{equal: true, oldStart: oldLine, oldLines: n, newStart: newLine, newLines: n})
+			oldLine += n
+			newLine += n
+			continue
+
+		case diffmatchpatch.DiffDelete:
+			if current == nil {
+				current = &diffSegment{oldStart: oldLine, newStart: newLine}
+				segments = append(segments, current)
+			}
+			current.oldLines += n
+			oldLine += n
+
+		case diffmatchpatch.DiffInsert:
+			if current == nil {
+				current = &diffSegment{oldStart: oldLine, newStart: newLine}
+				segments = append(segments, current)
+			}
+			current.newLines += n
+			newLine += n
+		}
+	}
+
+	f := &segmentLines{old: splitLines(oldContent), new: splitLines(newContent)}
+
+	var hunks []*lineHunk
+	for i := 0; i < len(segments); i++ {
+		if segments[i].equal {
+			continue
+		}
+
+		h := &lineHunk{diffHunk: &diffHunk{oldStart: segments[i].oldStart, newStart: segments[i].newStart}}
+		if i > 0 {
+			// the previous segment is equal
+			f.appendEqual(h, segments[i-1], segments[i-1].oldLines-context, segments[i-1].oldLines)
+		}
+
+		for ; i < len(segments); i++ {
+			seg := segments[i]
+			if !seg.equal {
+				f.appendChange(h, seg)
+				continue
+			}
+
+			if seg.oldLines <= 2*context && i+1 < len(segments) {
+				f.appendEqual(h, seg, 0, seg.oldLines)
+				continue
+			}
+
+			f.appendEqual(h, seg, 0, context)
+			break
+		}
+
+		// follow the unified diff convention, the empty side of a hunk
+		// starts at the line before the change
+		if h.oldLines == 0 {
+			h.oldStart -= 1
+		}
+		if h.newLines == 0 {
+			h.newStart -= 1
+		}
+
+		hunks = append(hunks, h)
+	}
+
+	return hunks, nil
+}
+
+// segmentLines adds the lines of the segments to the hunks.
+type segmentLines struct {
+	old, new []string
+}
+
+// appendEqual adds the lines of an equal segment between the given offsets as
+// context lines, the content is taken from the old file as libgit2 does.
+func (f *segmentLines) appendEqual(h *lineHunk, seg *diffSegment, from, to int) {
+	if from < 0 {
+		from = 0
+	}
+	if to > seg.oldLines {
+		to = seg.oldLines
+	}
+	if from >= to {
+		return
+	}
+
+	if len(h.lines) == 0 {
+		h.oldStart = seg.oldStart + from
+		h.newStart = seg.newStart + from
+	}
+
+	for i := from; i < to; i++ {
+		h.lines = append(h.lines, &diffLine{
+			origin:  engine.LineContext,
+			oldLine: seg.oldStart + i,
+			newLine: seg.newStart + i,
+			content: lineAt(f.old, seg.oldStart+i),
+		})
+	}
+	h.oldLines += to - from
+	h.newLines += to - from
+}
+
+func (f *segmentLines) appendChange(h *lineHunk, seg *diffSegment) {
+	for i := 0; i < seg.oldLines; i++ {
+		h.lines = append(h.lines, &diffLine{
+			origin:  engine.LineDeleted,
+			oldLine: seg.oldStart + i,
+			newLine: -1,
+			content: lineAt(f.old, seg.oldStart+i),
+		})
+	}
+	for i := 0; i < seg.newLines; i++ {
+		h.lines = append(h.lines, &diffLine{
+			origin:  engine.LineAdded,
+			oldLine: -1,
+			newLine: seg.newStart + i,
+			content: lineAt(f.new, seg.newStart+i),
+		})
+	}
+	h.oldLines += seg.oldLines
+	h.newLines += seg.newLines
+}
+
+// splitLines splits the content into lines without their line endings.
+func splitLines(content []byte) []string {
+	lines := strings.SplitAfter(string(content), "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	for i := range lines {
+		lines[i] = strings.TrimSuffix(lines[i], "\n")
+	}
+	return lines
+}
+
+func lineAt(lines []string, n int) string {
+	if n < 1 || n > len(lines) {
+		return ""
+	}
+	return lines[n-1]
+}
+
+// ignoreWhitespace removes the whitespaces from every line and keeps the lines
+// boundaries, so the lines numbers are preserved in the diff.
+func ignoreWhitespace(content []byte) string {
+	return strings.Map(func(r rune) rune {
+		if r != '\n' && unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, string(content))
+}
+
+func countLines(s string) int {
+	n := strings.Count(s, "\n")
+	if len(s) > 0 && !strings.HasSuffix(s, "\n") {
+		n += 1
+	}
+	return n
+}
+
+type diffHunk struct {
+	oldStart, oldLines int
+	newStart, newLines int
+}
+
+func (h *diffHunk) LinesAdded() int {
+	return h.newLines
+}
+
+func (h *diffHunk) LinesDeleted() int {
+	return h.oldLines
+}
+
+func (h *diffHunk) AddressDeleted() engine.ChunkAddr {
+	return engine.ChunkAddr{
+		Start: h.oldStart,
+		End:   h.oldStart + h.oldLines - 1,
+	}
+}
+
+func (h *diffHunk) AddressAdded() engine.ChunkAddr {
+	return engine.ChunkAddr{
+		Start: h.newStart,
+		End:   h.newStart + h.newLines - 1,
+	}
+}
+
+type lineHunk struct {
+	*diffHunk
+	lines []*diffLine
+}
+
+type diffLine struct {
+	origin           engine.LineOrigin
+	oldLine, newLine int
+	content          string
+}
+
+func (l *diffLine) Origin() engine.LineOrigin {
+	return l.origin
+}
+
+func (l *diffLine) OldLineNumber() int {
+	return l.oldLine
+}
+
+func (l *diffLine) NewLineNumber() int {
+	return l.newLine
+}
+
+func (l *diffLine) Content() string {
+	return l.content
+}
@@ -0,0 +1,382 @@
+// Copyright (c) 2019. Suhaib Mujahid. All rights reserved.
+// You cannot use this source code without a permission.
+
+// Package gogit implements the engine adapter on top of go-git, it is a pure
+// Go alternative for the git2go adapter that does not require cgo or libgit2.
+package gogit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/repofuel/repofuel/ingest/pkg/engine"
+	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+	"github.com/rs/zerolog/log"
+)
+
+var insecureSkipTLS bool
+
+func init() {
+	if strings.EqualFold(os.Getenv("CERTIFICATE_CHECK"), "off") {
+		log.Warn().Msg("SSL certificate checking is disabled on git cloning and fetching")
+		insecureSkipTLS = true
+	}
+}
+
+type Adapter struct {
+	git         *git.Repository
+	diffOptions *object.DiffTreeOptions
+}
+
+func NewAdapter() *Adapter {
+	return NewAdapterWithOptions(nil)
+}
+
+// NewAdapterWithOptions creates an adapter that detects the renames by the
+// options, the defaults are used if the options are nil. The copies are not
+// detected by this adapter.
+func NewAdapterWithOptions(opts *engine.DiffOptions) *Adapter {
+	return &Adapter{diffOptions: newDiffTreeOptions(opts)}
+}
+
+func (adp *Adapter) Open(path string) error {
+	var err error
+	adp.git, err = git.PlainOpen(path)
+	if err == git.ErrRepositoryNotExists {
+		return engine.ErrLocalRepoNotExist
+	}
+	return err
+}
+
+func (adp *Adapter) Clone(ctx context.Context, url string, path string, getAuth engine.BasicAuthFunc) error {
+	clone := func(auth transport.AuthMethod) error {
+		var err error
+		adp.git, err = git.PlainCloneContext(ctx, path, true, &git.CloneOptions{
+			URL:             url,
+			Auth:            auth,
+			InsecureSkipTLS: insecureSkipTLS,
+		})
+		return err
+	}
+
+	err := withAuth(ctx, getAuth, clone)
+	if err == git.ErrRepositoryAlreadyExists {
+		err = os.RemoveAll(path)
+		if err != nil {
+			return fmt.Errorf("clean up for fresh clone: %w", err)
+		}
+
+		// try again after delete the directory
+		err = withAuth(ctx, getAuth, clone)
+	}
+
+	return err
+}
+
+// withAuth calls fn without credentials, and retries with the credentials only
+// if the remote asks for them. It is the same behaviour of the credentials
+// callback in libgit2, and it avoids requesting tokens for public repositories.
+func withAuth(ctx context.Context, getAuth engine.BasicAuthFunc, fn func(transport.AuthMethod) error) error {
+	err := fn(nil)
+	if getAuth == nil || (err != transport.ErrAuthenticationRequired && err != transport.ErrAuthorizationFailed) {
+		return err
+	}
+
+	auth, err := getAuth(ctx)
+	if err != nil {
+		return err
+	}
+
+	return fn(&http.BasicAuth{
+		Username: auth.Username,
+		Password: auth.Password,
+	})
+}
+
+const defaultRemotePrefix = "refs/remotes/origin/"
+
+// only origin branches for now
+func (adp *Adapter) Branches() (map[string]identifier.Hash, error) {
+	itr, err := adp.git.References()
+	if err != nil {
+		return nil, err
+	}
+
+	var branches = make(map[string]identifier.Hash)
+	err = itr.ForEach(func(ref *plumbing.Reference) error {
+		name := ref.Name().String()
+		if ref.Type() != plumbing.HashReference || !strings.HasPrefix(name, defaultRemotePrefix) {
+			// ignore
+			return nil
+		}
+
+		name = name[len(defaultRemotePrefix):] // remove the prefix
+		branches[name] = identifier.Hash(ref.Hash())
+		return nil
+	})
+
+	return branches, err
+}
+
+func (adp *Adapter) Commit(h identifier.Hash) (engine.Commit, error) {
+	return newCommit(adp.git, plumbing.Hash(h), adp.diffOptions), nil
+}
+
+func (adp *Adapter) Fetch(ctx context.Context, getAuth engine.BasicAuthFunc, remote, url string, branches ...string) error {
+	re, err := adp.remote(remote, url)
+	if err != nil {
+		return err
+	}
+
+	refSpecs := make([]config.RefSpec, len(branches))
+	for i, b := range branches {
+		refSpecs[i] = branchRefSpec(remote, b)
+	}
+
+	err = withAuth(ctx, getAuth, func(auth transport.AuthMethod) error {
+		err := re.FetchContext(ctx, &git.FetchOptions{
+			RemoteName:      remote,
+			RefSpecs:        refSpecs,
+			Auth:            auth,
+			Tags:            git.TagFollowing,
+			Force:           true,
+			InsecureSkipTLS: insecureSkipTLS,
+		})
+		if err == git.NoErrAlreadyUpToDate {
+			return nil
+		}
+		if err != nil || len(branches) > 0 {
+			return err
+		}
+
+		return adp.prune(re, auth)
+	})
+
+	return err
+}
+
+// remote returns the remote with the given name after setting its URL, the
+// remote will be created if it is not exist.
+func (adp *Adapter) remote(name, url string) (*git.Remote, error) {
+	cfg, err := adp.git.Config()
+	if err != nil {
+		return nil, err
+	}
+
+	rc, ok := cfg.Remotes[name]
+	if !ok {
+		return adp.git.CreateRemote(&config.RemoteConfig{
+			Name:  name,
+			URLs:  []string{url},
+			Fetch: []config.RefSpec{branchRefSpec(name, "*")},
+		})
+	}
+
+	if len(rc.URLs) != 1 || rc.URLs[0] != url {
+		rc.URLs = []string{url}
+		err = adp.git.Storer.SetConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return adp.git.Remote(name)
+}
+
+// prune deletes the remote-tracking branches that are not exist anymore in the
+// remote, go-git does not support pruning while fetching.
+func (adp *Adapter) prune(re *git.Remote, auth transport.AuthMethod) error {
+	refs, err := re.List(&git.ListOptions{
+		Auth:            auth,
+		InsecureSkipTLS: insecureSkipTLS,
+	})
+	if err != nil {
+		return err
+	}
+
+	remoteName := re.Config().Name
+	prefix := "refs/remotes/" + remoteName + "/"
+
+	existing := make(map[string]struct{}, len(refs))
+	for _, ref := range refs {
+		if ref.Name().IsBranch() {
+			existing[prefix+ref.Name().Short()] = struct{}{}
+		}
+	}
+
+	itr, err := adp.git.References()
+	if err != nil {
+		return err
+	}
+
+	var stale []plumbing.ReferenceName
+	err = itr.ForEach(func(ref *plumbing.Reference) error {
+		name := ref.Name().String()
+		if ref.Type() != plumbing.HashReference || !strings.HasPrefix(name, prefix) {
+			return nil
+		}
+
+		if _, ok := existing[name]; !ok {
+			stale = append(stale, ref.Name())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range stale {
+		err = adp.git.Storer.RemoveReference(name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func branchRefSpec(remote, branch string) config.RefSpec {
+	branch = strings.TrimPrefix(branch, "refs/heads/")
+	return config.RefSpec(fmt.Sprintf("+refs/heads/%s:refs/remotes/%s/%s", branch, remote, branch))
+}
+
+func (adp *Adapter) ReadFile(id identifier.Hash, path string) ([]byte, error) {
+	c, err := adp.git.CommitObject(plumbing.Hash(id))
+	if err != nil {
+		return nil, translateGitError(err)
+	}
+
+	f, err := c.File(path)
+	if err != nil {
+		if err == object.ErrFileNotFound {
+			return nil, engine.ErrFileNotFound
+		}
+		return nil, err
+	}
+
+	content, err := f.Contents()
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(content), nil
+}
+
+func (adp *Adapter) Tags() ([]*engine.Tag, error) {
+	itr, err := adp.git.Tags()
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []*engine.Tag
+	err = itr.ForEach(func(ref *plumbing.Reference) error {
+		tag := &engine.Tag{Name: ref.Name().Short()}
+
+		annotated, err := adp.git.TagObject(ref.Hash())
+		switch err {
+		case nil:
+			c, err := annotated.Commit()
+			if err == object.ErrUnsupportedObject {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			tag.Commit = identifier.Hash(c.Hash)
+			tag.Date = annotated.Tagger.When
+
+		case plumbing.ErrObjectNotFound:
+			// a lightweight tag, the objects of other types are not found
+			// as commits
+			c, err := adp.git.CommitObject(ref.Hash())
+			if err == plumbing.ErrObjectNotFound {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			tag.Commit = identifier.Hash(c.Hash)
+			tag.Date = c.Committer.When
+
+		default:
+			return err
+		}
+
+		tags = append(tags, tag)
+		return nil
+	})
+
+	return tags, err
+}
+
+func (adp *Adapter) InducingCommits(ctx context.Context, id identifier.Hash, path string, chunks ...engine.ChunkAddr) (identifier.HashSet, error) {
+	sets, err := adp.InducingCommitsByChunk(ctx, id, path, chunks...)
+	if err != nil {
+		return nil, err
+	}
+
+	IDs := identifier.NewHashSet()
+	for _, s := range sets {
+		IDs.Update(s)
+	}
+	return IDs, nil
+}
+
+func (adp *Adapter) InducingCommitsByChunk(ctx context.Context, id identifier.Hash, path string, chunks ...engine.ChunkAddr) ([]identifier.HashSet, error) {
+	c, err := adp.git.CommitObject(plumbing.Hash(id))
+	if err != nil {
+		return nil, translateGitError(err)
+	}
+
+	blame, err := git.Blame(c, path)
+	if err != nil {
+		return nil, err
+	}
+
+	sets := make([]identifier.HashSet, len(chunks))
+	for i, chunk := range chunks {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+
+		if chunk.Start < 1 || chunk.End > len(blame.Lines) {
+			return nil, errors.New("blamed lines are out of the file range")
+		}
+
+		// the chunk lines are one-based and inclusive
+		IDs := identifier.NewHashSet()
+		for _, line := range blame.Lines[chunk.Start-1 : chunk.End] {
+			IDs.Add(identifier.Hash(line.Hash))
+		}
+		sets[i] = IDs
+	}
+
+	return sets, nil
+}
+
+func translateGitError(err error) error {
+	if err == plumbing.ErrObjectNotFound {
+		return engine.ErrObjectNotFound
+	}
+
+	return err
+}
+
+func commitTree(c *object.Commit) (*object.Tree, error) {
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, translateGitError(err)
+	}
+	return tree, nil
+}
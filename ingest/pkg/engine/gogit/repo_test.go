@@ -0,0 +1,20 @@
+package gogit
+
+import (
+	"testing"
+
+	"github.com/repofuel/repofuel/ingest/pkg/engine"
+	"github.com/repofuel/repofuel/ingest/pkg/engine/enginetest"
+)
+
+func TestConformance(t *testing.T) {
+	enginetest.Run(t, func() engine.RepositoryAdapter {
+		return NewAdapter()
+	})
+}
+
+func TestDiffOptions(t *testing.T) {
+	enginetest.RunDiffOptions(t, func(opts *engine.DiffOptions) engine.RepositoryAdapter {
+		return NewAdapterWithOptions(opts)
+	}, false)
+}
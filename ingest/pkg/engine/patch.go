@@ -0,0 +1,92 @@
+package engine
+
+import "strings"
+
+type LineOrigin int8
+
+//go:generate stringer -type=LineOrigin -trimprefix=Line
+const (
+	LineContext LineOrigin = iota
+	LineAdded
+	LineDeleted
+)
+
+// NewFilePatch creates the patch of a file from its lines, the chunks are
+// grouped from the consecutive lines. Binary files do not have lines.
+func NewFilePatch(binary bool, lines []DiffLine) FilePatch {
+	p := &filePatch{binary: binary, lines: lines}
+
+	var current *chunk
+	var prev DiffLine
+	for _, l := range lines {
+		if current == nil || !isNextLine(prev, l) {
+			current = &chunk{origin: l.Origin()}
+			p.chunks = append(p.chunks, current)
+		}
+		current.lines = append(current.lines, l.Content())
+		prev = l
+	}
+
+	return p
+}
+
+// isNextLine reports whether the line follows the previous one in the same
+// chunk, the lines of different hunks are not consecutive.
+func isNextLine(prev, l DiffLine) bool {
+	if prev.Origin() != l.Origin() {
+		return false
+	}
+	if l.Origin() == LineAdded {
+		return l.NewLineNumber() == prev.NewLineNumber()+1
+	}
+	return l.OldLineNumber() == prev.OldLineNumber()+1
+}
+
+type filePatch struct {
+	binary bool
+	lines  []DiffLine
+	chunks []Chunk
+}
+
+func (p *filePatch) Chunks() []Chunk {
+	return p.chunks
+}
+
+func (p *filePatch) Lines() []DiffLine {
+	return p.lines
+}
+
+func (p *filePatch) IsBinary() bool {
+	return p.binary
+}
+
+type chunk struct {
+	origin LineOrigin
+	lines  []string
+}
+
+// Content returns the lines of the chunk, every line ends with a line feed.
+func (c *chunk) Content() string {
+	var b strings.Builder
+	for _, l := range c.lines {
+		b.WriteString(l)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func (c *chunk) Action() DeltaType {
+	switch c.origin {
+	case LineAdded:
+		return DeltaAdded
+	case LineDeleted:
+		return DeltaDeleted
+	default:
+		return DeltaUnmodified
+	}
+}
+
+// Size returns the number of lines in the chunk.
+func (c *chunk) Size() int {
+	return len(c.lines)
+}
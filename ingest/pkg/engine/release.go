@@ -0,0 +1,61 @@
+package engine
+
+import (
+	"sort"
+	"time"
+
+	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+)
+
+// Tag is a tag of a commit.
+type Tag struct {
+	Name   string
+	Commit identifier.Hash
+	// Date is the date of the annotated tag, or the commit date if the tag
+	// is lightweight.
+	Date time.Time
+}
+
+// SortTags sorts the tags by their dates, the tags of the same date are sorted
+// by their names.
+func SortTags(tags []*Tag) {
+	sort.SliceStable(tags, func(i, j int) bool {
+		if !tags[i].Date.Equal(tags[j].Date) {
+			return tags[i].Date.Before(tags[j].Date)
+		}
+		return tags[i].Name < tags[j].Name
+	})
+}
+
+// FirstReleases maps the commits to the first tag that contains them, where the
+// tags are the releases ordered by their dates. The tags of the commits that
+// are not ingested are skipped, and the commits that are not in any tag are
+// not mapped.
+func (r *Repository) FirstReleases(tags []*Tag) map[identifier.Hash]string {
+	sorted := make([]*Tag, len(tags))
+	copy(sorted, tags)
+	SortTags(sorted)
+
+	releases := make(map[identifier.Hash]string)
+	for _, tag := range sorted {
+		head, ok := r.commits[tag.Commit]
+		if !ok {
+			continue
+		}
+
+		// the ancestors of an assigned commit are assigned to the same or an
+		// earlier release
+		stack := NewCommitStack(head)
+		for !stack.IsEmpty() {
+			c := stack.Pop()
+			if _, ok := releases[c.Hash()]; ok {
+				continue
+			}
+
+			releases[c.Hash()] = tag.Name
+			stack.Push(c.Parents()...)
+		}
+	}
+
+	return releases
+}
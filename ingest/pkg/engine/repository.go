@@ -14,6 +14,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/repofuel/repofuel/ingest/pkg/commitmsg"
 	"github.com/repofuel/repofuel/ingest/pkg/identifier"
 	"github.com/repofuel/repofuel/ingest/pkg/providers"
 	"github.com/repofuel/repofuel/pkg/common"
@@ -26,10 +27,25 @@ var (
 	ErrLocalRepoNotExist = errors.New("local repository path is not exist")
 	ErrCommitNotIngested = errors.New("commit should be ingested")
 	ErrObjectNotFound    = errors.New("object is not founded in the git repository")
+	ErrFileNotFound      = errors.New("file is not founded in the commit tree")
 	ErrBranchNotFound    = errors.New("branch is not founded in the git repository")
 	ErrBranchNotIngested = errors.New("branch is not ingested")
 )
 
+// RemoteError is returned by the adapters if cloning or fetching a repository
+// fails because of the network, so it could succeed if it is retried.
+type RemoteError struct {
+	Err error
+}
+
+func (e *RemoteError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RemoteError) Unwrap() error {
+	return e.Err
+}
+
 type BasicAuthFunc func(context.Context) (*credentials.BasicAuth, error)
 
 type RepositoryAdapter interface {
@@ -39,6 +55,66 @@ type RepositoryAdapter interface {
 	Branches() (map[string]identifier.Hash, error)
 	Commit(id identifier.Hash) (Commit, error)
 	InducingCommits(ctx context.Context, id identifier.Hash, path string, chunks ...ChunkAddr) (identifier.HashSet, error)
+	// InducingCommitsByChunk blames the chunks together and returns the
+	// commits of every chunk separately, in the same order of the chunks.
+	InducingCommitsByChunk(ctx context.Context, id identifier.Hash, path string, chunks ...ChunkAddr) ([]identifier.HashSet, error)
+	// ReadFile returns the content of the file in the tree of the commit, it
+	// returns ErrFileNotFound if the file does not exist in the commit.
+	ReadFile(id identifier.Hash, path string) ([]byte, error)
+	// Tags returns the tags of the commits, the annotated tags are peeled and
+	// the tags of the other objects are skipped.
+	Tags() ([]*Tag, error)
+}
+
+// DiffOptions tune the detection of the renamed and copied files in the diffs
+// of the adapters, the thresholds are similarity percentages.
+type DiffOptions struct {
+	// RenameThreshold is the similarity of a renamed file, it is 50 when it
+	// is zero.
+	RenameThreshold int `yaml:"rename_threshold"`
+	// Copies detects the files that are copied from the modified files of the
+	// same commit, such as splitting a file. It is supported by git2go only.
+	Copies bool `yaml:"copies"`
+	// CopyThreshold is the similarity of a copied file, it is 50 when it is
+	// zero.
+	CopyThreshold int `yaml:"copy_threshold"`
+}
+
+const defaultSimilarityThreshold = 50
+
+// RenameSimilarity returns the rename threshold, or the default one if it is
+// not set.
+func (o *DiffOptions) RenameSimilarity() int {
+	if o == nil || o.RenameThreshold <= 0 {
+		return defaultSimilarityThreshold
+	}
+	return o.RenameThreshold
+}
+
+// CopySimilarity returns the copy threshold, or the default one if it is not
+// set.
+func (o *DiffOptions) CopySimilarity() int {
+	if o == nil || o.CopyThreshold <= 0 {
+		return defaultSimilarityThreshold
+	}
+	return o.CopyThreshold
+}
+
+// DetectCopies reports whether the copies should be detected.
+func (o *DiffOptions) DetectCopies() bool {
+	return o != nil && o.Copies
+}
+
+// Validate checks that the thresholds are percentages, zero is accepted for
+// the default ones.
+func (o *DiffOptions) Validate() error {
+	if o.RenameThreshold < 0 || o.RenameThreshold > 100 {
+		return fmt.Errorf("the rename threshold should be between 1 and 100, got %d", o.RenameThreshold)
+	}
+	if o.CopyThreshold < 0 || o.CopyThreshold > 100 {
+		return fmt.Errorf("the copy threshold should be between 1 and 100, got %d", o.CopyThreshold)
+	}
+	return nil
 }
 
 type Commit interface {
@@ -49,6 +125,12 @@ type Commit interface {
 	Hash() identifier.Hash
 	SetDeveloper(Developer)
 	Developer() Developer
+	// Contributors are the author, the committer, and the co-authors.
+	SetContributors(DeveloperSet)
+	Contributors() DeveloperSet
+	// Bot reports whether the commit is authored by a bot account.
+	SetBot(bool)
+	Bot() bool
 	NumChildren() int
 	Children() []Commit
 	AuthorDate() time.Time
@@ -75,11 +157,14 @@ type CommitObject interface {
 	AuthorDate() time.Time
 	Author() *Signature
 	CommitterDate() time.Time
+	Committer() *Signature
 	AuthorEmail() string
 	AuthorName() string
 	//DiffFiles(ctx context.Context, cbFile FileDiffCB) error
 	DiffHunks(ctx context.Context, cbFile HunkDiffCB) error
-	//DiffLines(ctx context.Context, cbFile AnalyzeFileCB) error
+	// DiffLines diffs the files as DiffHunks does, and it passes the lines of
+	// every hunk after the hunk itself.
+	DiffLines(ctx context.Context, cbFile LineDiffCB) error
 	Free()
 }
 
@@ -93,7 +178,9 @@ type DiffDelta interface {
 	IsSymlink() bool
 	OldContent() ([]byte, error)
 	NewContent() ([]byte, error)
-	//Patch() (FilePatch, error)
+	// Patch returns the changed lines of the file with three context lines
+	// around them, the whitespaces are ignored as in the hunks.
+	Patch() (FilePatch, error)
 }
 
 type DiffFile interface {
@@ -105,13 +192,24 @@ type DiffHunk interface {
 	LinesAdded() int
 	LinesDeleted() int
 	AddressDeleted() ChunkAddr
+	AddressAdded() ChunkAddr
 }
 
+// DiffLine is a line of a diff hunk.
 type DiffLine interface {
+	Origin() LineOrigin
+	// OldLineNumber and NewLineNumber start from one, they are -1 for the
+	// side that does not have the line.
+	OldLineNumber() int
+	NewLineNumber() int
+	// Content is the line without its line ending.
+	Content() string
 }
 
 type FilePatch interface {
+	// Chunks are the consecutive lines that have the same origin.
 	Chunks() []Chunk
+	Lines() []DiffLine
 	IsBinary() bool
 }
 
@@ -137,6 +235,67 @@ type Repository struct {
 	path      string
 	roots     CommitSet
 	commits   map[identifier.Hash]Commit
+	identity  IdentityResolver
+	bots      BotDetector
+	branches  BranchFilter
+}
+
+// IdentityResolver maps the names and emails of the commits to the canonical
+// identities of the developers.
+type IdentityResolver interface {
+	Resolve(name, email string) (string, string)
+}
+
+// SetIdentityResolver sets the resolver of the developer identities, it should
+// be set before ingesting the commits. Without a resolver, the developers are
+// identified by the emails as they are.
+func (r *Repository) SetIdentityResolver(resolver IdentityResolver) {
+	r.identity = resolver
+}
+
+// ResolveIdentity returns the canonical name and email of the developer.
+func (r *Repository) ResolveIdentity(name, email string) (string, string) {
+	if r.identity == nil {
+		return name, email
+	}
+	return r.identity.Resolve(name, email)
+}
+
+// BotDetector detects the commits of the bot accounts.
+type BotDetector interface {
+	IsBot(name, email string) bool
+}
+
+// SetBotDetector sets the detector of the bot commits, it should be set before
+// ingesting the commits. Without a detector, no commit is marked as a bot.
+func (r *Repository) SetBotDetector(detector BotDetector) {
+	r.bots = detector
+}
+
+// BranchFilter selects the branches of the repository that are analyzed.
+type BranchFilter interface {
+	Match(name string) bool
+}
+
+// SetBranchFilter sets the filter of the branches, it should be set before
+// ingesting the commits. Without a filter, all the branches are analyzed.
+func (r *Repository) SetBranchFilter(filter BranchFilter) {
+	r.branches = filter
+}
+
+// IsBranchSelected reports whether the branch is selected by the branch filter.
+func (r *Repository) IsBranchSelected(name string) bool {
+	return r.branches == nil || r.branches.Match(name)
+}
+
+// IsBot reports whether the name and email belong to a bot account.
+func (r *Repository) IsBot(name, email string) bool {
+	return r.bots != nil && r.bots.IsBot(name, email)
+}
+
+func (r *Repository) developer(name, email string) Developer {
+	_, email = r.ResolveIdentity(name, email)
+	return Developer(email)
 }
 
 func (r *Repository) ITS() providers.IssuesIntegration {
@@ -147,8 +306,24 @@ func (r *Repository) SCM() providers.SourceIntegration {
 	return r.scm
 }
 
+// Branches returns the heads of the branches that are selected by the branch
+// filter.
 func (r *Repository) Branches() (map[string]identifier.Hash, error) {
-	return r.adapter.Branches()
+	branches, err := r.adapter.Branches()
+	if err != nil || r.branches == nil {
+		return branches, err
+	}
+
+	for name := range branches {
+		if !r.IsBranchSelected(name) {
+			delete(branches, name)
+		}
+	}
+	return branches, nil
+}
+
+func (r *Repository) Tags() ([]*Tag, error) {
+	return r.adapter.Tags()
 }
 
 func (r *Repository) Commits(all ...identifier.Hash) CommitSet {
@@ -212,6 +387,14 @@ func (r *Repository) InducingCommits(ctx context.Context, id identifier.Hash, pa
 	return r.adapter.InducingCommits(ctx, id, path, chunks...)
 }
 
+func (r *Repository) ReadFile(id identifier.Hash, path string) ([]byte, error) {
+	return r.adapter.ReadFile(id, path)
+}
+
+func (r *Repository) InducingCommitsByChunk(ctx context.Context, id identifier.Hash, path string, chunks ...ChunkAddr) ([]identifier.HashSet, error) {
+	return r.adapter.InducingCommitsByChunk(ctx, id, path, chunks...)
+}
+
 func (r *Repository) Open() error {
 	return r.adapter.Open(r.path)
 }
@@ -274,8 +457,14 @@ func DeletedBranches(analyzed, current Branches) []string {
 	return deleted
 }
 
+// TagBranchesOnCommits tags the commits of the branches until the given commits,
+// the branches that are not selected by the branch filter are skipped.
 func (r *Repository) TagBranchesOnCommits(b Branches, until CommitSet) error {
 	for name, head := range b {
+		if !r.IsBranchSelected(name) {
+			continue
+		}
+
 		c, ok := r.commits[head]
 		if !ok {
 			return ErrCommitNotIngested
@@ -550,7 +739,9 @@ func (r *Repository) IngestHead(ctx context.Context, head identifier.Hash) error
 			return err
 		}
 		c.SetAuthorDate(obj.AuthorDate())
-		c.SetDeveloper(Developer(obj.AuthorEmail())) // todo: should be based on developer aggregation
+		c.SetDeveloper(r.developer(obj.AuthorName(), obj.AuthorEmail()))
+		c.SetContributors(r.commitContributors(obj))
+		c.SetBot(r.IsBot(obj.AuthorName(), obj.AuthorEmail()))
 
 		parents := obj.ParentHashes()
 		if len(parents) == 0 {
@@ -586,6 +777,14 @@ type ChunkAddr struct {
 	Start, End int
 }
 
+// Len returns the number of lines in the chunk, it is zero for the empty chunks.
+func (c ChunkAddr) Len() int {
+	if c.End < c.Start {
+		return 0
+	}
+	return c.End - c.Start + 1
+}
+
 type DeltaType int8
 
 var _StageEnumToStageValue = make(map[string]DeltaType, len(_DeltaTypeNameToValue))
@@ -694,6 +893,27 @@ func FindCommonFirstParent(commits []Commit) (Commit, error) {
 	return nil, fmt.Errorf("cannot find common first parent")
 }
 
+// webCommitters are the committers of the commits that are created from the
+// web interfaces of the providers, they are not developers.
+var webCommitters = map[string]bool{
+	"noreply@github.com": true,
+}
+
+func (r *Repository) commitContributors(obj CommitObject) DeveloperSet {
+	devs := NewDeveloperSet()
+	devs.Add(r.developer(obj.AuthorName(), obj.AuthorEmail()))
+
+	if committer := obj.Committer(); committer != nil && !webCommitters[committer.Email] {
+		devs.Add(r.developer(committer.Name, committer.Email))
+	}
+
+	for _, co := range commitmsg.CoAuthors(obj.Message()) {
+		devs.Add(r.developer(co.Name, co.Email))
+	}
+
+	return devs
+}
+
 type Signature struct {
 	Name  string    `json:"name"  bson:"name"`
 	Email string    `json:"email" bson:"email"`
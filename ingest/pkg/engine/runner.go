@@ -7,6 +7,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 )
 
 type Analyzer interface {
@@ -14,8 +15,16 @@ type Analyzer interface {
 	Finish(context.Context) error
 }
 
+// RunForwardAnalysis analyzes the roots and all of their descendants, a commit
+// is analyzed after all of its parents that are analyzed in the same run.
 func RunForwardAnalysis(ctx context.Context, analyzer Analyzer, roots []Commit) (err error) {
-	stack := NewCommitStack(roots...)
+	waiting := waitingParents(roots)
+	stack := NewCommitStack()
+	for _, c := range roots {
+		if waiting[c] == 0 {
+			stack.Push(c)
+		}
+	}
 	seen := NewCommitSet()
 
 	defer func() {
@@ -36,11 +45,144 @@ func RunForwardAnalysis(ctx context.Context, analyzer Analyzer, roots []Commit)
 		}
 
 		seen.Add(c)
-		stack.Push(c.Children()...)
+		for _, child := range c.Children() {
+			waiting[child] -= 1
+			if waiting[child] == 0 {
+				stack.Push(child)
+			}
+		}
+	}
+	return analyzer.Finish(ctx)
+}
+
+// RunParallelForwardAnalysis analyzes the commits as RunForwardAnalysis does, but
+// it analyzes up to the given number of commits concurrently, so the analyzer
+// should be safe for concurrent use. A commit is still analyzed after all of its
+// parents that are analyzed in the same run, and the analysis stops after the
+// first error.
+func RunParallelForwardAnalysis(ctx context.Context, analyzer Analyzer, roots []Commit, parallelism int) error {
+	if parallelism <= 1 {
+		return RunForwardAnalysis(ctx, analyzer, roots)
+	}
+
+	workCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		commit Commit
+		err    error
+	}
+
+	commits := make(chan Commit)
+	results := make(chan result)
+
+	var wg sync.WaitGroup
+	wg.Add(parallelism)
+	for i := 0; i < parallelism; i++ {
+		go func() {
+			defer wg.Done()
+			for c := range commits {
+				results <- result{commit: c, err: analyzeCommit(workCtx, analyzer, c)}
+			}
+		}()
+	}
+
+	waiting := waitingParents(roots)
+	ready := NewCommitStack()
+	seen := NewCommitSet()
+	for _, c := range roots {
+		if waiting[c] == 0 && !seen.Has(c) {
+			seen.Add(c)
+			ready.Push(c)
+		}
+	}
+
+	var err error
+	var running int
+	for {
+		if err == nil {
+			err = ctx.Err()
+		}
+		if running == 0 && (err != nil || ready.IsEmpty()) {
+			break
+		}
+
+		// the next commit is only sent when there is no error
+		var next Commit
+		var send chan<- Commit
+		if err == nil && !ready.IsEmpty() {
+			next = ready[len(ready)-1]
+			send = commits
+		}
+
+		select {
+		case send <- next:
+			ready.Pop()
+			running += 1
+
+		case r := <-results:
+			running -= 1
+			if r.err != nil {
+				if err == nil {
+					err = r.err
+					cancel()
+				}
+				continue
+			}
+
+			for _, child := range r.commit.Children() {
+				waiting[child] -= 1
+				if waiting[child] == 0 {
+					ready.Push(child)
+				}
+			}
+		}
+	}
+
+	close(commits)
+	wg.Wait()
+
+	if err != nil {
+		return err
 	}
 	return analyzer.Finish(ctx)
 }
 
+func analyzeCommit(ctx context.Context, analyzer Analyzer, c Commit) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("analysis panic: %+v", r)
+		}
+	}()
+
+	return analyzer.AnalyzeCommit(ctx, c)
+}
+
+// waitingParents counts the parents of the roots descendants that are also
+// descendants of the roots, the roots themselves are included.
+func waitingParents(roots []Commit) map[Commit]int {
+	waiting := make(map[Commit]int)
+	stack := NewCommitStack(roots...)
+	for !stack.IsEmpty() {
+		c := stack.Pop()
+		if _, ok := waiting[c]; ok {
+			continue
+		}
+		waiting[c] = 0
+		stack.Push(c.Children()...)
+	}
+
+	for c := range waiting {
+		for _, p := range c.Parents() {
+			if _, ok := waiting[p]; ok {
+				waiting[c] += 1
+			}
+		}
+	}
+
+	return waiting
+}
+
 func LastFileChange(ctx context.Context, c Commit, path string) (Commit, error) {
 	seen := NewCommitSet()         // only for the commits that have ore than one child
 	commitsStack := CommitsStack{} //todo: maybe a queue will find last change faster
@@ -87,4 +229,7 @@ type FileInfo struct {
 	Subsystem string    `bson:"subsystem"`
 	Fix       bool      `bson:"fix"`
 	Action    DeltaType `bson:"action"`
+	// Functions are the keys of the functions that are changed in the file,
+	// which are their names followed by their signatures.
+	Functions []string `bson:"functions,omitempty"`
 }
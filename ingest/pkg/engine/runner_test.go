@@ -0,0 +1,143 @@
+package engine_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/repofuel/repofuel/ingest/pkg/engine"
+	"github.com/repofuel/repofuel/ingest/pkg/engine/enginetest"
+	"github.com/repofuel/repofuel/ingest/pkg/engine/gogit"
+	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+)
+
+// orderAnalyzer fails if a commit is analyzed before its parents or more than
+// once, it is safe for concurrent use.
+type orderAnalyzer struct {
+	analyzed engine.CommitSet
+	fail     engine.Commit
+	panic    engine.Commit
+	finished bool
+	mu       sync.Mutex
+}
+
+func (a *orderAnalyzer) AnalyzeCommit(ctx context.Context, c engine.Commit) error {
+	a.mu.Lock()
+	if a.analyzed.Has(c) {
+		a.mu.Unlock()
+		return errors.New("analyzed twice: " + c.Hash().String())
+	}
+	for _, p := range c.Parents() {
+		if !a.analyzed.Has(p) {
+			a.mu.Unlock()
+			return errors.New("analyzed before its parent: " + c.Hash().String())
+		}
+	}
+	a.mu.Unlock()
+
+	// give the other workers a chance to pick the independent commits
+	time.Sleep(time.Millisecond)
+
+	if c == a.fail {
+		return errors.New("failed commit")
+	}
+	if c == a.panic {
+		panic("panicked commit")
+	}
+
+	a.mu.Lock()
+	a.analyzed.Add(c)
+	a.mu.Unlock()
+	return nil
+}
+
+func (a *orderAnalyzer) Finish(context.Context) error {
+	a.finished = true
+	return nil
+}
+
+func runnerRepository(t *testing.T) (*engine.Repository, *enginetest.Fixture) {
+	ctx := context.Background()
+	f := enginetest.NewFixture(t)
+
+	f.Write("a.txt", "a\n")
+	f.Commit("A")
+	f.Checkout("feature")
+	f.Write("b.txt", "b\n")
+	f.Commit("B")
+	f.Write("b.txt", "b\nb\n")
+	f.Commit("C")
+	f.Checkout("master")
+	f.Write("a.txt", "a\na\n")
+	f.Commit("D")
+	f.Merge("M", "feature")
+	f.Write("a.txt", "a\na\na\n")
+	f.Commit("E")
+
+	adp := enginetest.Clone(t, func() engine.RepositoryAdapter { return gogit.NewAdapter() }, f)
+	repo := engine.NewRepository(identifier.RepositoryID{}, "", &engine.RepositoryOpts{Adapter: adp})
+	if err := repo.IngestHead(ctx, f.Hash("E")); err != nil {
+		t.Fatal(err)
+	}
+
+	return repo, f
+}
+
+func TestRunParallelForwardAnalysis(t *testing.T) {
+	repo, _ := runnerRepository(t)
+
+	for _, parallelism := range []int{1, 2, 4} {
+		a := &orderAnalyzer{analyzed: engine.NewCommitSet()}
+		err := engine.RunParallelForwardAnalysis(context.Background(), a, repo.Roots().Slice(), parallelism)
+		if err != nil {
+			t.Fatalf("parallelism %d: %v", parallelism, err)
+		}
+
+		if len(a.analyzed) != repo.CommitsCount() {
+			t.Errorf("parallelism %d: expected to analyze %d commits, analyzed %d", parallelism, repo.CommitsCount(), len(a.analyzed))
+		}
+		if !a.finished {
+			t.Errorf("parallelism %d: expected the analyzer to finish", parallelism)
+		}
+	}
+}
+
+func TestRunParallelForwardAnalysis_Error(t *testing.T) {
+	repo, f := runnerRepository(t)
+	d, _ := repo.Commit(f.Hash("D"))
+	m, _ := repo.Commit(f.Hash("M"))
+
+	a := &orderAnalyzer{analyzed: engine.NewCommitSet(), fail: d}
+	err := engine.RunParallelForwardAnalysis(context.Background(), a, repo.Roots().Slice(), 4)
+	if err == nil || err.Error() != "failed commit" {
+		t.Errorf("expected the error of the failed commit, got %v", err)
+	}
+	if a.analyzed.Has(m) || a.finished {
+		t.Error("expected to stop the analysis after the error")
+	}
+
+	a = &orderAnalyzer{analyzed: engine.NewCommitSet(), panic: d}
+	err = engine.RunParallelForwardAnalysis(context.Background(), a, repo.Roots().Slice(), 4)
+	if err == nil || !strings.HasPrefix(err.Error(), "analysis panic") {
+		t.Errorf("expected the panic to be recovered, got %v", err)
+	}
+}
+
+func TestRunParallelForwardAnalysis_Canceled(t *testing.T) {
+	repo, _ := runnerRepository(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	a := &orderAnalyzer{analyzed: engine.NewCommitSet()}
+	err := engine.RunParallelForwardAnalysis(ctx, a, repo.Roots().Slice(), 4)
+	if err != context.Canceled {
+		t.Errorf("expected the context error, got %v", err)
+	}
+	if a.finished {
+		t.Error("expected the canceled analysis not to finish")
+	}
+}
@@ -0,0 +1,93 @@
+package identity
+
+import (
+	"regexp"
+	"strings"
+)
+
+// BotConfig lists the accounts of a repository that the bot detection
+// does not recognize by itself, a nil config keeps the default detection.
+type BotConfig struct {
+	// Names and Emails are additional bot accounts.
+	Names  []string `json:"names,omitempty"   bson:"names,omitempty"`
+	Emails []string `json:"emails,omitempty"  bson:"emails,omitempty"`
+	// Humans are the emails of the developers that are detected as bots by
+	// mistake.
+	Humans []string `json:"humans,omitempty"  bson:"humans,omitempty"`
+}
+
+// botNamePatterns match the names of the common dependency and automation
+// bots, such as Dependabot, Renovate, and the GitHub Actions.
+var botNamePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\[bot\]$`),
+	regexp.MustCompile(`(?i)[-_ ]bot$`),
+	regexp.MustCompile(`(?i)^(dependabot|renovate|greenkeeper|snyk|github-actions|pre-commit-ci)\b`),
+}
+
+// botEmails are the emails of the bots that commit with non-bot names.
+var botEmails = map[string]bool{
+	"support@dependabot.com": true,
+	"bot@renovateapp.com":    true,
+	"action@github.com":      true,
+	"support@greenkeeper.io": true,
+	"actions@github.com":     true,
+	"noreply@snyk.io":        true,
+}
+
+// BotDetector detects the commits of the bot accounts by the name patterns, the
+// emails of the known bots, the `[bot]` logins of the GitHub noreply emails,
+// and the lists of the repository config.
+type BotDetector struct {
+	names  map[string]bool
+	emails map[string]bool
+	humans map[string]bool
+}
+
+func NewBotDetector(cfg *BotConfig) *BotDetector {
+	d := &BotDetector{
+		names:  make(map[string]bool),
+		emails: make(map[string]bool),
+		humans: make(map[string]bool),
+	}
+
+	if cfg == nil {
+		return d
+	}
+
+	for _, name := range cfg.Names {
+		d.names[strings.ToLower(name)] = true
+	}
+	for _, email := range cfg.Emails {
+		d.emails[strings.ToLower(email)] = true
+	}
+	for _, email := range cfg.Humans {
+		d.humans[strings.ToLower(email)] = true
+	}
+
+	return d
+}
+
+// IsBot reports whether the name and email belong to a bot account.
+func (d *BotDetector) IsBot(name, email string) bool {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if d.humans[email] {
+		return false
+	}
+
+	if d.emails[email] || d.names[strings.ToLower(name)] || botEmails[email] {
+		return true
+	}
+
+	// GitHub suffixes the logins of the bot accounts with `[bot]`
+	if login, ok := GitHubLogin(email); ok && strings.HasSuffix(login, "[bot]") {
+		return true
+	}
+
+	for _, p := range botNamePatterns {
+		if p.MatchString(name) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,101 @@
+// Package identity resolves the names and emails that a developer uses in the
+// commits to a single identity, so the developer metrics credit one person.
+package identity
+
+import (
+	"strings"
+)
+
+// Identity is the resolved name and email of a developer.
+type Identity struct {
+	Name  string `json:"name"   bson:"name"`
+	Email string `json:"email"  bson:"email"`
+}
+
+// Alias is a group of emails and provider accounts that belong to the same
+// developer, it is defined by the repository admins.
+type Alias struct {
+	// Name and Email are the canonical identity of the developer, the name is
+	// optional.
+	Name  string `json:"name,omitempty"    bson:"name,omitempty"`
+	Email string `json:"email"             bson:"email"`
+	// Emails are the other emails of the developer.
+	Emails []string `json:"emails,omitempty"  bson:"emails,omitempty"`
+	// Logins are the provider accounts of the developer, they match the
+	// noreply emails that the provider uses for the web commits.
+	Logins []string `json:"logins,omitempty"  bson:"logins,omitempty"`
+}
+
+// Resolver resolves the identities by applying the mailmap, then the alias
+// groups, and then the provider accounts.
+type Resolver struct {
+	mailmap *Mailmap
+	emails  map[string]*Alias
+	logins  map[string]*Alias
+}
+
+func NewResolver(mailmap *Mailmap, aliases []*Alias) *Resolver {
+	r := &Resolver{
+		mailmap: mailmap,
+		emails:  make(map[string]*Alias),
+		logins:  make(map[string]*Alias),
+	}
+
+	for _, a := range aliases {
+		r.emails[strings.ToLower(a.Email)] = a
+		for _, email := range a.Emails {
+			r.emails[strings.ToLower(email)] = a
+		}
+		for _, login := range a.Logins {
+			r.logins[strings.ToLower(login)] = a
+		}
+	}
+
+	return r
+}
+
+// Resolve returns the canonical name and email of the developer, the email is
+// in lower case because the emails are case insensitive in practice.
+func (r *Resolver) Resolve(name, email string) (string, string) {
+	name, email = r.mailmap.Map(name, email)
+	email = strings.ToLower(strings.TrimSpace(email))
+
+	if a, ok := r.emails[email]; ok {
+		return aliasIdentity(a, name)
+	}
+
+	if login, ok := GitHubLogin(email); ok {
+		if a, ok := r.logins[login]; ok {
+			return aliasIdentity(a, name)
+		}
+		// unify the noreply emails with and without the account ID
+		email = login + gitHubNoreplySuffix
+	}
+
+	return name, email
+}
+
+func aliasIdentity(a *Alias, name string) (string, string) {
+	if a.Name != "" {
+		name = a.Name
+	}
+	return name, strings.ToLower(a.Email)
+}
+
+const gitHubNoreplySuffix = "@users.noreply.github.com"
+
+// GitHubLogin returns the login of the GitHub noreply emails, which are in the
+// forms `ID+login@users.noreply.github.com` and `login@users.noreply.github.com`.
+func GitHubLogin(email string) (string, bool) {
+	email = strings.ToLower(email)
+	if !strings.HasSuffix(email, gitHubNoreplySuffix) {
+		return "", false
+	}
+
+	login := strings.TrimSuffix(email, gitHubNoreplySuffix)
+	if i := strings.IndexByte(login, '+'); i >= 0 {
+		login = login[i+1:]
+	}
+
+	return login, login != ""
+}
@@ -0,0 +1,112 @@
+package identity
+
+import (
+	"strings"
+	"testing"
+)
+
+const mailmap = `# comment
+Jane Doe <jane@example.com>
+<jane@example.com> <jane@old.example.com>
+Jane Doe <jane@example.com> <jane.doe@work.example.com>
+John Roe <john@example.com> jr <shared@example.com>
+`
+
+func TestMailmap_Map(t *testing.T) {
+	m, err := ParseMailmap(strings.NewReader(mailmap))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name, email         string
+		wantName, wantEmail string
+	}{
+		{"jane", "jane@example.com", "Jane Doe", "jane@example.com"},
+		{"jane", "JANE@old.example.com", "jane", "jane@example.com"},
+		{"jd", "jane.doe@work.example.com", "Jane Doe", "jane@example.com"},
+		{"jr", "shared@example.com", "John Roe", "john@example.com"},
+		{"someone", "shared@example.com", "someone", "shared@example.com"},
+		{"other", "other@example.com", "other", "other@example.com"},
+	}
+
+	for _, tt := range tests {
+		name, email := m.Map(tt.name, tt.email)
+		if name != tt.wantName || email != tt.wantEmail {
+			t.Errorf("Map(%q, %q) = %q, %q, want %q, %q", tt.name, tt.email, name, email, tt.wantName, tt.wantEmail)
+		}
+	}
+
+	if _, err := ParseMailmap(strings.NewReader("Jane <jane@example.com")); err != ErrInvalidMailmapLine {
+		t.Errorf("expected an invalid line error, got %v", err)
+	}
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	m, err := ParseMailmap(strings.NewReader(mailmap))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewResolver(m, []*Alias{{
+		Name:   "Jane Doe",
+		Email:  "jane@example.com",
+		Emails: []string{"jane@home.example.com"},
+		Logins: []string{"janedoe"},
+	}})
+
+	tests := []struct {
+		name, email string
+		want        string
+	}{
+		{"jane", "jane@old.example.com", "jane@example.com"},
+		{"jane", "Jane@Home.example.com", "jane@example.com"},
+		{"jane", "123+janedoe@users.noreply.github.com", "jane@example.com"},
+		{"bob", "456+Bob@users.noreply.github.com", "bob@users.noreply.github.com"},
+		{"bob", "bob@users.noreply.github.com", "bob@users.noreply.github.com"},
+		{"other", "Other@example.com", "other@example.com"},
+	}
+
+	for _, tt := range tests {
+		if _, email := r.Resolve(tt.name, tt.email); email != tt.want {
+			t.Errorf("Resolve(%q, %q) = %q, want %q", tt.name, tt.email, email, tt.want)
+		}
+	}
+
+	if name, _ := r.Resolve("jane", "jane@home.example.com"); name != "Jane Doe" {
+		t.Errorf("expected the alias name, got %q", name)
+	}
+}
+
+func TestBotDetector_IsBot(t *testing.T) {
+	d := NewBotDetector(&BotConfig{
+		Names:  []string{"Release Tool"},
+		Emails: []string{"ci@example.com"},
+		Humans: []string{"abbot@example.com"},
+	})
+
+	tests := []struct {
+		name, email string
+		want        bool
+	}{
+		{"dependabot[bot]", "49699333+dependabot[bot]@users.noreply.github.com", true},
+		{"Renovate Bot", "bot@renovateapp.com", true},
+		{"github-actions", "41898282+github-actions[bot]@users.noreply.github.com", true},
+		{"Automation", "123+automation[bot]@users.noreply.github.com", true},
+		{"release tool", "release@example.com", true},
+		{"CI", "CI@example.com", true},
+		{"Abbot-bot", "abbot@example.com", false},
+		{"Jane Doe", "jane@example.com", false},
+		{"Talbot", "talbot@example.com", false},
+	}
+
+	for _, tt := range tests {
+		if got := d.IsBot(tt.name, tt.email); got != tt.want {
+			t.Errorf("IsBot(%q, %q) = %v, want %v", tt.name, tt.email, got, tt.want)
+		}
+	}
+
+	if !NewBotDetector(nil).IsBot("dependabot[bot]", "support@github.com") {
+		t.Error("expected the default detection without a config")
+	}
+}
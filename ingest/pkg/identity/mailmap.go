@@ -0,0 +1,125 @@
+package identity
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+)
+
+var ErrInvalidMailmapLine = errors.New("invalid mailmap line")
+
+// Mailmap maps the names and emails of the commits to the proper ones, it
+// follows the format of the git `.mailmap` file.
+type Mailmap struct {
+	entries []*mailmapEntry
+}
+
+type mailmapEntry struct {
+	properName  string
+	properEmail string
+	commitName  string
+	commitEmail string
+}
+
+// ParseMailmap parses a `.mailmap` file, the supported forms of the lines are:
+//
+//	Proper Name <commit@email>
+//	<proper@email> <commit@email>
+//	Proper Name <proper@email> <commit@email>
+//	Proper Name <proper@email> Commit Name <commit@email>
+func ParseMailmap(r io.Reader) (*Mailmap, error) {
+	var m Mailmap
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		entry, err := parseMailmapLine(line)
+		if err != nil {
+			return nil, err
+		}
+		m.entries = append(m.entries, entry)
+	}
+
+	return &m, scanner.Err()
+}
+
+func parseMailmapLine(line string) (*mailmapEntry, error) {
+	var names, emails []string
+	for line != "" {
+		start := strings.IndexByte(line, '<')
+		end := strings.IndexByte(line, '>')
+		if start < 0 || end < start {
+			return nil, ErrInvalidMailmapLine
+		}
+
+		names = append(names, strings.TrimSpace(line[:start]))
+		emails = append(emails, strings.TrimSpace(line[start+1:end]))
+		line = strings.TrimSpace(line[end+1:])
+	}
+
+	switch len(emails) {
+	case 1:
+		return &mailmapEntry{
+			properName:  names[0],
+			commitEmail: emails[0],
+		}, nil
+	case 2:
+		return &mailmapEntry{
+			properName:  names[0],
+			properEmail: emails[0],
+			commitName:  names[1],
+			commitEmail: emails[1],
+		}, nil
+	default:
+		return nil, ErrInvalidMailmapLine
+	}
+}
+
+// Map returns the proper name and email of the commit name and email, the
+// entries with a commit name take precedence over the ones without it.
+func (m *Mailmap) Map(name, email string) (string, string) {
+	if m == nil {
+		return name, email
+	}
+
+	var match *mailmapEntry
+	for _, e := range m.entries {
+		if !strings.EqualFold(e.commitEmail, email) {
+			continue
+		}
+
+		if e.commitName != "" {
+			if e.commitName == name {
+				match = e
+				break
+			}
+			continue
+		}
+
+		if match == nil {
+			match = e
+		}
+	}
+
+	if match == nil {
+		return name, email
+	}
+
+	if match.properName != "" {
+		name = match.properName
+	}
+	if match.properEmail != "" {
+		email = match.properEmail
+	}
+	return name, email
+}
@@ -27,6 +27,7 @@ const (
 	ActionPullRequestCheck
 
 	ActionMonitorRepository // Monitor repository
+	ActionSubsystemsUpdate  // Subsystems update
 )
 
 var _ActionEnumToActionValue = make(map[string]Action, len(_ActionValueToName))
@@ -22,6 +22,7 @@ var (
 		"ActionPushCheck":               ActionPushCheck,
 		"ActionPullRequestCheck":        ActionPullRequestCheck,
 		"ActionMonitorRepository":       ActionMonitorRepository,
+		"ActionSubsystemsUpdate":        ActionSubsystemsUpdate,
 	}
 
 	_ActionValueToName = map[Action]string{
@@ -38,6 +39,7 @@ var (
 		ActionPushCheck:               "ActionPushCheck",
 		ActionPullRequestCheck:        "ActionPullRequestCheck",
 		ActionMonitorRepository:       "ActionMonitorRepository",
+		ActionSubsystemsUpdate:        "ActionSubsystemsUpdate",
 	}
 )
 
@@ -58,6 +60,7 @@ func init() {
 			interface{}(ActionPushCheck).(fmt.Stringer).String():               ActionPushCheck,
 			interface{}(ActionPullRequestCheck).(fmt.Stringer).String():        ActionPullRequestCheck,
 			interface{}(ActionMonitorRepository).(fmt.Stringer).String():       ActionMonitorRepository,
+			interface{}(ActionSubsystemsUpdate).(fmt.Stringer).String():        ActionSubsystemsUpdate,
 		}
 	}
 }
@@ -21,11 +21,12 @@ func _() {
 	_ = x[ActionPushCheck-11]
 	_ = x[ActionPullRequestCheck-12]
 	_ = x[ActionMonitorRepository-13]
+	_ = x[ActionSubsystemsUpdate-14]
 }
 
-const _Action_name = "Repository addRepository pushRepository recoverRepository admin triggerRepository refreshPull request addPull request updatePull request recoverPull request admin triggerPull request refreshPushCheckPullRequestCheckMonitor repository"
+const _Action_name = "Repository addRepository pushRepository recoverRepository admin triggerRepository refreshPull request addPull request updatePull request recoverPull request admin triggerPull request refreshPushCheckPullRequestCheckMonitor repositorySubsystems update"
 
-var _Action_index = [...]uint8{0, 14, 29, 47, 71, 89, 105, 124, 144, 170, 190, 199, 215, 233}
+var _Action_index = [...]uint8{0, 14, 29, 47, 71, 89, 105, 124, 144, 170, 190, 199, 215, 233, 250}
 
 func (i Action) String() string {
 	i -= 1
@@ -5,6 +5,7 @@ import (
 
 	"github.com/repofuel/repofuel/ingest/pkg/identifier"
 	"github.com/repofuel/repofuel/ingest/pkg/invoke"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 const RepoEntity = "repo_entity"
@@ -47,30 +48,31 @@ type JobInfo struct {
 	Action  invoke.Action
 	Details Store
 	Cache   Store
-	IsEqual func(*JobInfo) bool
-	next    *JobInfo
-}
-
-func (info *JobInfo) CancelNext() {
-	info.next = nil
-}
-
-func (info *JobInfo) Next() *JobInfo {
-	return info.next
-}
-
-func (info *JobInfo) HasNext() bool {
-	return info.next != nil
 }
 
 func (info *JobInfo) ObservableNodeID() string {
-	if id, ok := info.Details[PullRequestID].(identifier.PullRequestID); ok {
+	if id, ok := GetPullRequestID(info.Details); ok {
 		return id.NodeID()
 	}
 
 	return info.RepoID.NodeID()
 }
 
+// GetPullRequestID returns the pull request of the job, the ID is decoded as an
+// ObjectID if the details are loaded from the database.
+func GetPullRequestID(details Store) (identifier.PullRequestID, bool) {
+	switch id := details[PullRequestID].(type) {
+	case identifier.PullRequestID:
+		return id, true
+
+	case primitive.ObjectID:
+		return identifier.PullRequestID(id), true
+
+	default:
+		return identifier.PullRequestID{}, false
+	}
+}
+
 func DefaultEqualFunc(info1, info2 *JobInfo) bool {
 	if info1.Action != info2.Action {
 		return false
@@ -84,12 +86,12 @@ func DefaultEqualFunc(info1, info2 *JobInfo) bool {
 }
 
 func isSamePullRequest(info1, info2 *JobInfo) bool {
-	id1, ok := info1.Details[PullRequestID].(identifier.PullRequestID)
+	id1, ok := GetPullRequestID(info1.Details)
 	if !ok {
 		return false
 	}
 
-	id2, ok := info2.Details[PullRequestID].(identifier.PullRequestID)
+	id2, ok := GetPullRequestID(info2.Details)
 	if !ok {
 		return false
 	}
@@ -102,20 +104,3 @@ func (info *JobInfo) IsPullRequest() bool {
 	_, ok := info.Details[PullRequestID]
 	return ok
 }
-
-// IMPOTENT: should be called with the manager mutex locked
-func (info *JobInfo) Append(newInfo *JobInfo) bool {
-	oldInfo := info
-
-	for oldInfo.next != nil {
-		oldInfo = oldInfo.next
-
-		if newInfo.IsEqual != nil && newInfo.IsEqual(oldInfo) {
-			//todo: could update the cache
-			return false
-		}
-	}
-
-	oldInfo.next = newInfo
-	return true
-}
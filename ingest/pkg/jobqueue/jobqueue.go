@@ -0,0 +1,133 @@
+// Package jobqueue keeps the jobs of the repositories that are waiting to be
+// processed, so several nodes can share them. A node leases a repository from
+// the queue and processes its jobs in order, the lease is renewed while the node
+// is alive, and a repository whose lease expires is picked up by another node.
+package jobqueue
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+	"github.com/repofuel/repofuel/ingest/pkg/invoke"
+)
+
+var (
+	// ErrEmpty is returned if there are no jobs to lease.
+	ErrEmpty = errors.New("no jobs to lease")
+	// ErrLeaseLost is returned if the lease of the repository is expired and
+	// claimed by another owner, or the repository is removed from the queue.
+	ErrLeaseLost = errors.New("the repository lease is lost")
+	// ErrNotPaused is returned if a repository is resumed but it is not paused.
+	ErrNotPaused = errors.New("the repository is not paused")
+)
+
+// Entry is a queued job.
+type Entry struct {
+	Job  identifier.JobID        `bson:"_id"`
+	Repo identifier.RepositoryID `bson:"repo"`
+	// Org is the organization of the repository, the organizations share the
+	// workers fairly.
+	Org identifier.OrganizationID `bson:"org"`
+	// Queue is the queue that limits the concurrent jobs of its kind.
+	Queue uint8 `bson:"queue"`
+	// Priority orders the jobs of the different queues, the higher is leased
+	// first.
+	Priority uint8                  `bson:"priority"`
+	Action   invoke.Action          `bson:"action"`
+	Details  map[string]interface{} `bson:"details,omitempty"`
+	// StartedAt is set when the job is leased to be processed.
+	StartedAt *time.Time `bson:"started_at,omitempty"`
+}
+
+// Filter selects the jobs that a node can lease.
+type Filter struct {
+	// Queues are the queues that have free workers.
+	Queues []uint8
+	// OrgLimit returns the maximum number of the repositories of an
+	// organization that are leased at the same time by all the owners, zero
+	// is no limit. There is no limit if it is nil.
+	OrgLimit func(identifier.OrganizationID) int
+}
+
+// Stats describe the jobs of a queue.
+type Stats struct {
+	Queue uint8
+	// Waiting is the number of the jobs that are not started.
+	Waiting int
+	// Running is the number of the started jobs whose leases are not expired,
+	// the started jobs of the dead owners are waiting.
+	Running int
+	// OldestWait and AverageWait are the times since the waiting jobs are
+	// queued, they are zeros if there are no waiting jobs.
+	OldestWait  time.Duration
+	AverageWait time.Duration
+}
+
+// Queue is a durable queue of jobs, where a repository is leased by one owner at
+// a time or it is paused. The jobs are ordered by their priorities then their
+// IDs, and the jobs of a repository are processed in order.
+type Queue interface {
+	// Push adds the job to the end of the queue.
+	Push(ctx context.Context, e *Entry) error
+	// Lease claims a repository whose first job is selected by the filter and
+	// is not leased, and returns the job. The organizations with fewer leased
+	// repositories are preferred among the jobs of the same priority. It
+	// returns ErrEmpty if there is no such repository.
+	Lease(ctx context.Context, owner string, ttl time.Duration, filter *Filter) (*Entry, error)
+	// Next removes the done job and returns the next job of its repository.
+	// The lease is renewed, or it is released and ErrEmpty is returned if there
+	// are no more jobs.
+	Next(ctx context.Context, owner string, ttl time.Duration, done *Entry) (*Entry, error)
+	// Extend renews the leases of the owner on the repositories, it returns the
+	// repositories whose leases are lost.
+	Extend(ctx context.Context, owner string, ttl time.Duration, repos ...identifier.RepositoryID) ([]identifier.RepositoryID, error)
+	// Remove deletes the jobs of the repository and its lease, so the owner
+	// that processes the repository loses it. A paused repository is resumed.
+	Remove(ctx context.Context, repo identifier.RepositoryID) error
+	// Pause holds the jobs of the repository until it is resumed, the jobs that
+	// are pushed meanwhile are held too. The owner that processes the
+	// repository loses its lease, and its started job waits to be leased again.
+	Pause(ctx context.Context, repo identifier.RepositoryID) error
+	// Resume lets the jobs of the paused repository be leased again, it returns
+	// ErrNotPaused if the repository is not paused.
+	Resume(ctx context.Context, repo identifier.RepositoryID) error
+	// Paused reports whether the repository is paused.
+	Paused(ctx context.Context, repo identifier.RepositoryID) (bool, error)
+	// Has reports whether the repository has queued jobs.
+	Has(ctx context.Context, repo identifier.RepositoryID) (bool, error)
+	// Stats returns the statistics of the queues that have jobs.
+	Stats(ctx context.Context) ([]*Stats, error)
+}
+
+// SortCandidates orders the candidate jobs to lease, where the candidates are
+// the first jobs of the organizations in every priority, and leased is the
+// number of the leased repositories of every organization. The candidates of
+// the organizations that reached their limits are dropped.
+func SortCandidates(candidates []*Entry, leased map[identifier.OrganizationID]int, filter *Filter) []*Entry {
+	allowed := candidates[:0]
+	for _, e := range candidates {
+		if filter.OrgLimit != nil {
+			if limit := filter.OrgLimit(e.Org); limit > 0 && leased[e.Org] >= limit {
+				continue
+			}
+		}
+		allowed = append(allowed, e)
+	}
+
+	sort.SliceStable(allowed, func(i, j int) bool {
+		a, b := allowed[i], allowed[j]
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		if leased[a.Org] != leased[b.Org] {
+			return leased[a.Org] < leased[b.Org]
+		}
+		return bytes.Compare(a.Job[:], b.Job[:]) < 0
+	})
+
+	return allowed
+}
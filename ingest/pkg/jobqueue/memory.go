@@ -0,0 +1,275 @@
+package jobqueue
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+)
+
+// MemoryQueue keeps the jobs in memory, it is shared by the owners of the same
+// process only, such as in the tests.
+type MemoryQueue struct {
+	entries []*Entry
+	leases  map[identifier.RepositoryID]*lease
+	now     func() time.Time
+
+	mu sync.Mutex
+}
+
+type lease struct {
+	owner   string
+	org     identifier.OrganizationID
+	expires time.Time
+	// paused holds the repository, it does not have an owner and it does not
+	// expire.
+	paused bool
+}
+
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{
+		leases: make(map[identifier.RepositoryID]*lease),
+		now:    time.Now,
+	}
+}
+
+func (q *MemoryQueue) Push(_ context.Context, e *Entry) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.entries = append(q.entries, e)
+	return nil
+}
+
+func (q *MemoryQueue) Lease(_ context.Context, owner string, ttl time.Duration, filter *Filter) (*Entry, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	leased := make(map[identifier.OrganizationID]int)
+	for _, l := range q.leases {
+		if !l.paused && l.expires.After(now) {
+			leased[l.org]++
+		}
+	}
+
+	type group struct {
+		org      identifier.OrganizationID
+		priority uint8
+	}
+	seen := make(map[group]bool)
+	seenRepos := make(map[identifier.RepositoryID]bool)
+
+	var candidates []*Entry
+	for _, e := range q.entries {
+		if seenRepos[e.Repo] {
+			// only the first job of a repository could be leased
+			continue
+		}
+		seenRepos[e.Repo] = true
+
+		g := group{org: e.Org, priority: e.Priority}
+		if seen[g] || !hasQueue(filter.Queues, e.Queue) || q.isLeased(e.Repo, now) {
+			continue
+		}
+		seen[g] = true
+		candidates = append(candidates, e)
+	}
+
+	candidates = SortCandidates(candidates, leased, filter)
+	if len(candidates) == 0 {
+		return nil, ErrEmpty
+	}
+
+	e := candidates[0]
+	q.leases[e.Repo] = &lease{owner: owner, org: e.Org, expires: now.Add(ttl)}
+	return q.start(e.Repo, now), nil
+}
+
+func (q *MemoryQueue) Next(_ context.Context, owner string, ttl time.Duration, done *Entry) (*Entry, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.remove(func(e *Entry) bool {
+		return e.Job == done.Job
+	})
+
+	now := q.now()
+	l, ok := q.leases[done.Repo]
+	if !ok || l.owner != owner || !l.expires.After(now) {
+		return nil, ErrLeaseLost
+	}
+
+	next := q.start(done.Repo, now)
+	if next == nil {
+		delete(q.leases, done.Repo)
+		return nil, ErrEmpty
+	}
+
+	l.expires = now.Add(ttl)
+	return next, nil
+}
+
+func (q *MemoryQueue) Extend(_ context.Context, owner string, ttl time.Duration, repos ...identifier.RepositoryID) ([]identifier.RepositoryID, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var lost []identifier.RepositoryID
+	now := q.now()
+	for _, repo := range repos {
+		l, ok := q.leases[repo]
+		if !ok || l.owner != owner || !l.expires.After(now) {
+			lost = append(lost, repo)
+			continue
+		}
+		l.expires = now.Add(ttl)
+	}
+
+	return lost, nil
+}
+
+func (q *MemoryQueue) Remove(_ context.Context, repo identifier.RepositoryID) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.remove(func(e *Entry) bool {
+		return e.Repo == repo
+	})
+	delete(q.leases, repo)
+
+	return nil
+}
+
+func (q *MemoryQueue) Pause(_ context.Context, repo identifier.RepositoryID) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, e := range q.entries {
+		if e.Repo == repo {
+			e.StartedAt = nil
+		}
+	}
+	q.leases[repo] = &lease{paused: true}
+
+	return nil
+}
+
+func (q *MemoryQueue) Resume(_ context.Context, repo identifier.RepositoryID) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	l, ok := q.leases[repo]
+	if !ok || !l.paused {
+		return ErrNotPaused
+	}
+	delete(q.leases, repo)
+
+	return nil
+}
+
+func (q *MemoryQueue) Paused(_ context.Context, repo identifier.RepositoryID) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	l, ok := q.leases[repo]
+	return ok && l.paused, nil
+}
+
+func (q *MemoryQueue) Has(_ context.Context, repo identifier.RepositoryID) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.first(repo) != nil, nil
+}
+
+func (q *MemoryQueue) Stats(context.Context) ([]*Stats, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	byQueue := make(map[uint8]*Stats)
+	var res []*Stats
+	for _, e := range q.entries {
+		st, ok := byQueue[e.Queue]
+		if !ok {
+			st = &Stats{Queue: e.Queue}
+			byQueue[e.Queue] = st
+			res = append(res, st)
+		}
+
+		if e.StartedAt != nil && q.isLeased(e.Repo, now) {
+			st.Running++
+			continue
+		}
+
+		// the started jobs of the expired leases wait to be leased again
+
+		wait := now.Sub(e.Job.Timestamp())
+		if wait > st.OldestWait {
+			st.OldestWait = wait
+		}
+		st.AverageWait += wait
+		st.Waiting++
+	}
+
+	for _, st := range res {
+		if st.Waiting > 0 {
+			st.AverageWait /= time.Duration(st.Waiting)
+		}
+	}
+
+	return res, nil
+}
+
+// start marks the first job of the repository as started and returns it.
+//
+// IMPOTENT: should be called with the queue mutex locked
+func (q *MemoryQueue) start(repo identifier.RepositoryID, now time.Time) *Entry {
+	e := q.first(repo)
+	if e != nil {
+		e.StartedAt = &now
+	}
+	return e
+}
+
+// IMPOTENT: should be called with the queue mutex locked
+func (q *MemoryQueue) isLeased(repo identifier.RepositoryID, now time.Time) bool {
+	l, ok := q.leases[repo]
+	return ok && (l.paused || l.expires.After(now))
+}
+
+// IMPOTENT: should be called with the queue mutex locked
+func (q *MemoryQueue) first(repo identifier.RepositoryID) *Entry {
+	for _, e := range q.entries {
+		if e.Repo == repo {
+			return e
+		}
+	}
+	return nil
+}
+
+// IMPOTENT: should be called with the queue mutex locked
+func (q *MemoryQueue) remove(match func(*Entry) bool) {
+	entries := q.entries[:0]
+	for _, e := range q.entries {
+		if !match(e) {
+			entries = append(entries, e)
+		}
+	}
+
+	// free up the memory of the removed entries
+	for i := len(entries); i < len(q.entries); i++ {
+		q.entries[i] = nil
+	}
+	q.entries = entries
+}
+
+func hasQueue(queues []uint8, queue uint8) bool {
+	for _, q := range queues {
+		if q == queue {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,290 @@
+package jobqueue
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const ttl = time.Minute
+
+func newEntry(repo byte, queue uint8) *Entry {
+	return &Entry{
+		Job:   identifier.JobID(primitive.NewObjectID()),
+		Repo:  identifier.RepositoryID{repo},
+		Queue: queue,
+	}
+}
+
+func inQueues(queues ...uint8) *Filter {
+	return &Filter{Queues: queues}
+}
+
+func TestMemoryQueue_Lease(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue()
+
+	a1, b1, a2 := newEntry(1, 1), newEntry(2, 1), newEntry(1, 2)
+	for _, e := range []*Entry{a1, b1, a2} {
+		if err := q.Push(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	e, err := q.Lease(ctx, "node1", ttl, inQueues(1, 2))
+	if err != nil || e != a1 {
+		t.Fatalf("expected the first job, got %v, %v", e, err)
+	}
+
+	// the leased repository is skipped
+	e, err = q.Lease(ctx, "node2", ttl, inQueues(1, 2))
+	if err != nil || e != b1 {
+		t.Fatalf("expected the job of the other repository, got %v, %v", e, err)
+	}
+
+	if _, err = q.Lease(ctx, "node2", ttl, inQueues(1, 2)); err != ErrEmpty {
+		t.Fatalf("expected no jobs to lease, got %v", err)
+	}
+
+	e, err = q.Next(ctx, "node1", ttl, a1)
+	if err != nil || e != a2 {
+		t.Fatalf("expected the next job of the repository, got %v, %v", e, err)
+	}
+
+	if _, err = q.Next(ctx, "node1", ttl, a2); err != ErrEmpty {
+		t.Fatalf("expected no more jobs, got %v", err)
+	}
+
+	if ok, _ := q.Has(ctx, a1.Repo); ok {
+		t.Error("expected the jobs of the repository to be removed")
+	}
+}
+
+func TestMemoryQueue_LeaseFirstJob(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue()
+
+	first, second := newEntry(1, 1), newEntry(1, 2)
+	q.Push(ctx, first)
+	q.Push(ctx, second)
+
+	// the first job of the repository is in a queue without free workers
+	if _, err := q.Lease(ctx, "node1", ttl, inQueues(2)); err != ErrEmpty {
+		t.Fatalf("expected no jobs to lease, got %v", err)
+	}
+
+	e, err := q.Lease(ctx, "node1", ttl, inQueues(1))
+	if err != nil || e != first {
+		t.Fatalf("expected the first job of the repository, got %v, %v", e, err)
+	}
+}
+
+func TestMemoryQueue_ExpiredLease(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	q := NewMemoryQueue()
+	q.now = func() time.Time { return now }
+
+	e := newEntry(1, 1)
+	q.Push(ctx, e)
+
+	if _, err := q.Lease(ctx, "node1", ttl, inQueues(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	now = now.Add(ttl / 2)
+	lost, err := q.Extend(ctx, "node1", ttl, e.Repo)
+	if err != nil || len(lost) != 0 {
+		t.Fatalf("expected the lease to be extended, got %v, %v", lost, err)
+	}
+
+	now = now.Add(ttl / 2)
+	if _, err := q.Lease(ctx, "node2", ttl, inQueues(1)); err != ErrEmpty {
+		t.Fatalf("expected the extended lease to be kept, got %v", err)
+	}
+
+	// the first node stops heartbeating
+	now = now.Add(ttl)
+	got, err := q.Lease(ctx, "node2", ttl, inQueues(1))
+	if err != nil || got != e {
+		t.Fatalf("expected the expired lease to be claimed, got %v, %v", got, err)
+	}
+
+	lost, _ = q.Extend(ctx, "node1", ttl, e.Repo)
+	if len(lost) != 1 {
+		t.Error("expected the first node to lose the lease")
+	}
+	if _, err = q.Next(ctx, "node1", ttl, e); err != ErrLeaseLost {
+		t.Errorf("expected the lease to be lost, got %v", err)
+	}
+}
+
+func TestMemoryQueue_Remove(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue()
+
+	e := newEntry(1, 1)
+	q.Push(ctx, e)
+	q.Push(ctx, newEntry(1, 1))
+
+	if _, err := q.Lease(ctx, "node1", ttl, inQueues(1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Remove(ctx, e.Repo); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := q.Next(ctx, "node1", ttl, e); err != ErrLeaseLost {
+		t.Errorf("expected the lease to be lost, got %v", err)
+	}
+	if ok, _ := q.Has(ctx, e.Repo); ok {
+		t.Error("expected the jobs to be removed")
+	}
+}
+
+func TestMemoryQueue_Priority(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue()
+
+	low, high := newEntry(1, 1), newEntry(2, 2)
+	high.Priority = 1
+	q.Push(ctx, low)
+	q.Push(ctx, high)
+
+	e, err := q.Lease(ctx, "node1", ttl, inQueues(1, 2))
+	if err != nil || e != high {
+		t.Fatalf("expected the job of the higher priority, got %v, %v", e, err)
+	}
+}
+
+func TestMemoryQueue_OrganizationsFairShare(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue()
+
+	busy := identifier.OrganizationID{1}
+	other := identifier.OrganizationID{2}
+
+	entries := []*Entry{newEntry(1, 1), newEntry(2, 1), newEntry(3, 1), newEntry(4, 1)}
+	for i, e := range entries {
+		e.Org = busy
+		if i == len(entries)-1 {
+			e.Org = other
+		}
+		q.Push(ctx, e)
+	}
+
+	if e, _ := q.Lease(ctx, "node1", ttl, inQueues(1)); e != entries[0] {
+		t.Fatalf("expected the first job, got %v", e)
+	}
+
+	// the other organization has no leased repositories, so its job is
+	// leased before the earlier jobs of the busy organization
+	if e, _ := q.Lease(ctx, "node1", ttl, inQueues(1)); e != entries[3] {
+		t.Fatalf("expected the job of the other organization, got %v", e)
+	}
+
+	filter := &Filter{
+		Queues: []uint8{1},
+		OrgLimit: func(org identifier.OrganizationID) int {
+			if org == busy {
+				return 1
+			}
+			return 0
+		},
+	}
+	if _, err := q.Lease(ctx, "node1", ttl, filter); err != ErrEmpty {
+		t.Fatalf("expected the organization limit to be reached, got %v", err)
+	}
+}
+
+func TestMemoryQueue_Stats(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	q := NewMemoryQueue()
+	q.now = func() time.Time { return now }
+
+	for i := byte(1); i <= 3; i++ {
+		q.Push(ctx, newEntry(i, 1))
+	}
+	q.Push(ctx, newEntry(1, 2))
+
+	if _, err := q.Lease(ctx, "node1", ttl, inQueues(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	now = now.Add(ttl / 2)
+	stats, err := q.Stats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stats) != 2 {
+		t.Fatalf("expected the stats of two queues, got %d", len(stats))
+	}
+
+	st := stats[0]
+	if st.Queue != 1 || st.Running != 1 || st.Waiting != 2 {
+		t.Errorf("unexpected stats %+v", st)
+	}
+
+	// the node stops heartbeating, so its job waits again
+	now = now.Add(time.Hour)
+	stats, err = q.Stats(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st = stats[0]
+	if st.Running != 0 || st.Waiting != 3 {
+		t.Errorf("unexpected stats of the expired lease %+v", st)
+	}
+	if st.OldestWait < time.Hour || st.AverageWait < time.Hour || st.AverageWait > st.OldestWait {
+		t.Errorf("unexpected wait times %+v", st)
+	}
+}
+
+func TestMemoryQueue_Pause(t *testing.T) {
+	ctx := context.Background()
+	q := NewMemoryQueue()
+
+	e := newEntry(1, 1)
+	q.Push(ctx, e)
+
+	if _, err := q.Lease(ctx, "node1", ttl, inQueues(1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Pause(ctx, e.Repo); err != nil {
+		t.Fatal(err)
+	}
+
+	if lost, _ := q.Extend(ctx, "node1", ttl, e.Repo); len(lost) != 1 {
+		t.Error("expected the lease to be lost when the repository is paused")
+	}
+	if e.StartedAt != nil {
+		t.Error("expected the started job to wait again")
+	}
+
+	// the jobs that are pushed after pausing are held too
+	q.Push(ctx, newEntry(1, 1))
+	if _, err := q.Lease(ctx, "node2", ttl, inQueues(1)); err != ErrEmpty {
+		t.Fatalf("expected the paused repository to not be leased, got %v", err)
+	}
+	if ok, _ := q.Paused(ctx, e.Repo); !ok {
+		t.Error("expected the repository to be paused")
+	}
+
+	if err := q.Resume(ctx, e.Repo); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Resume(ctx, e.Repo); err != ErrNotPaused {
+		t.Errorf("expected the repository to be resumed, got %v", err)
+	}
+
+	got, err := q.Lease(ctx, "node2", ttl, inQueues(1))
+	if err != nil || got != e {
+		t.Fatalf("expected the paused job to be leased again, got %v, %v", got, err)
+	}
+}
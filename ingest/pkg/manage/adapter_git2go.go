@@ -0,0 +1,15 @@
+//go:build cgo && !nogit2go
+// +build cgo,!nogit2go
+
+package manage
+
+import (
+	"github.com/repofuel/repofuel/ingest/pkg/engine"
+	"github.com/repofuel/repofuel/ingest/pkg/engine/git2go"
+)
+
+func init() {
+	adapterFactories[AdapterGit2Go] = func(opts *engine.DiffOptions) engine.RepositoryAdapter {
+		return git2go.NewAdapterWithOptions(opts)
+	}
+}
@@ -0,0 +1,88 @@
+package manage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/repofuel/repofuel/ingest/internal/entity"
+	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+)
+
+const (
+	checkpointBatchSize = 500
+	checkpointInterval  = 30 * time.Second
+)
+
+// analysisCheckpoint saves the analyzed commits of a repository in batches,
+// so a paused or interrupted analysis loses the commits of one batch at most.
+type analysisCheckpoint struct {
+	db   entity.CheckpointDataSource
+	repo identifier.RepositoryID
+	job  identifier.JobID
+	// analyzed are the commits that are checkpointed before the analysis
+	// started, they are not changed during the analysis.
+	analyzed identifier.HashSet
+
+	mu      sync.Mutex
+	pending []identifier.Hash
+	saved   time.Time
+	now     func() time.Time
+}
+
+func newAnalysisCheckpoint(db entity.CheckpointDataSource, repo identifier.RepositoryID, job identifier.JobID, analyzed identifier.HashSet) *analysisCheckpoint {
+	if analyzed == nil {
+		analyzed = identifier.NewHashSet()
+	}
+
+	return &analysisCheckpoint{
+		db:       db,
+		repo:     repo,
+		job:      job,
+		analyzed: analyzed,
+		saved:    time.Now(),
+		now:      time.Now,
+	}
+}
+
+func (c *analysisCheckpoint) Has(h identifier.Hash) bool {
+	return c.analyzed.Has(h)
+}
+
+// Add saves the pending commits if they fill a batch, or if the last save is
+// older than the checkpoint interval.
+func (c *analysisCheckpoint) Add(ctx context.Context, h identifier.Hash) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.pending = append(c.pending, h)
+	if len(c.pending) < checkpointBatchSize && c.now().Sub(c.saved) < checkpointInterval {
+		return nil
+	}
+
+	return c.save(ctx)
+}
+
+// Flush saves the pending commits, it is called when the analysis stops.
+func (c *analysisCheckpoint) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.save(ctx)
+}
+
+// IMPOTENT: should be called with the checkpoint mutex locked
+func (c *analysisCheckpoint) save(ctx context.Context) error {
+	c.saved = c.now()
+	if len(c.pending) == 0 {
+		return nil
+	}
+
+	err := c.db.SaveAnalyzedCommits(ctx, c.repo, c.job, c.pending)
+	if err != nil {
+		return err
+	}
+
+	c.pending = nil
+	return nil
+}
@@ -0,0 +1,64 @@
+package manage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/repofuel/repofuel/ingest/internal/entity"
+	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+)
+
+type checkpointRecorder struct {
+	entity.CheckpointDataSource
+	batches [][]identifier.Hash
+}
+
+func (r *checkpointRecorder) SaveAnalyzedCommits(_ context.Context, _ identifier.RepositoryID, _ identifier.JobID, commits []identifier.Hash) error {
+	r.batches = append(r.batches, commits)
+	return nil
+}
+
+func TestAnalysisCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	db := &checkpointRecorder{}
+
+	analyzed := identifier.NewHashSet()
+	analyzed.Add(identifier.Hash{1})
+	checkpoint := newAnalysisCheckpoint(db, identifier.RepositoryID{}, identifier.JobID{}, analyzed)
+	if !checkpoint.Has(identifier.Hash{1}) || checkpoint.Has(identifier.Hash{2}) {
+		t.Error("expected the checkpoint to have the analyzed commits only")
+	}
+
+	now := time.Now()
+	checkpoint.now = func() time.Time { return now }
+	for i := 0; i < checkpointBatchSize+1; i++ {
+		if err := checkpoint.Add(ctx, identifier.Hash{byte(i), byte(i >> 8)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(db.batches) != 1 || len(db.batches[0]) != checkpointBatchSize {
+		t.Fatalf("expected a full batch to be saved, got %d batches", len(db.batches))
+	}
+
+	now = now.Add(checkpointInterval)
+	if err := checkpoint.Add(ctx, identifier.Hash{3}); err != nil {
+		t.Fatal(err)
+	}
+	if len(db.batches) != 2 || len(db.batches[1]) != 2 {
+		t.Fatalf("expected the pending commits to be saved after the interval, got %d batches", len(db.batches))
+	}
+
+	if err := checkpoint.Add(ctx, identifier.Hash{4}); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkpoint.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkpoint.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(db.batches) != 3 || len(db.batches[2]) != 1 {
+		t.Errorf("expected the flush to save the pending commit once, got %d batches", len(db.batches))
+	}
+}
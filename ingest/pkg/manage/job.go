@@ -4,6 +4,7 @@
 package manage
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -15,12 +16,16 @@ import (
 	"github.com/repofuel/repofuel/ingest/internal/entity"
 	"github.com/repofuel/repofuel/ingest/pkg/analysis"
 	"github.com/repofuel/repofuel/ingest/pkg/brancher"
+	"github.com/repofuel/repofuel/ingest/pkg/branchfilter"
+	"github.com/repofuel/repofuel/ingest/pkg/classify"
 	"github.com/repofuel/repofuel/ingest/pkg/engine"
-	"github.com/repofuel/repofuel/ingest/pkg/engine/git2go"
 	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+	"github.com/repofuel/repofuel/ingest/pkg/identity"
 	"github.com/repofuel/repofuel/ingest/pkg/invoke"
 	"github.com/repofuel/repofuel/ingest/pkg/jobinfo"
+	"github.com/repofuel/repofuel/ingest/pkg/repoconfig"
 	"github.com/repofuel/repofuel/ingest/pkg/status"
+	"github.com/repofuel/repofuel/ingest/pkg/subsystem"
 	"github.com/repofuel/repofuel/pkg/repofuel"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -36,6 +41,12 @@ type process struct {
 	includeAfter identifier.JobID // include commits analyzed after the specified job in the prediction
 	//deprecated
 	startPoints engine.CommitSet
+	// predictAll predicts all the commits of the repository, such as when they
+	// are not predicted before or their metrics are recomputed.
+	predictAll bool
+	// configProblems are the problems of the config file of the repository,
+	// they are reported in the check runs.
+	configProblems []string
 
 	mu      sync.Mutex
 	logger  zerolog.Logger
@@ -60,9 +71,8 @@ func RecoverFromLastPredicting(ctx context.Context, p *process) error {
 		p.includeAfter = lastPredictedJob.ID
 
 	} else if err == entity.ErrJobNotExist && lastJob != nil {
-		// in such case we will include all commits. Instead of getting the id of the first job,
-		// we will use the repo id which is always older than the first job id.
-		p.includeAfter = identifier.JobID(p.RepoID)
+		// in such case we will include all commits
+		p.predictAll = true
 	}
 
 	return nil
@@ -101,7 +111,7 @@ func (p *process) pullRequestEntity(ctx context.Context) (*entity.PullRequest, e
 		return pull, nil
 	}
 
-	id, ok := p.JobInfo.Details[jobinfo.PullRequestID].(identifier.PullRequestID)
+	id, ok := jobinfo.GetPullRequestID(p.JobInfo.Details)
 	if !ok {
 		return nil, errors.New("messing a valid pull request ID")
 	}
@@ -135,11 +145,26 @@ func (p *process) AddCache(key string, value interface{}) {
 		return
 	}
 
-	p.Cache[key] = jobinfo.Store{
+	p.Cache = jobinfo.Store{
 		key: value,
 	}
 }
 
+func (p *process) repositoryEntity(ctx context.Context) (*entity.Repository, error) {
+	repoEntity, ok := p.Cache[jobinfo.RepoEntity].(*entity.Repository)
+	if ok {
+		return repoEntity, nil
+	}
+
+	repoEntity, err := p.mgr.srv.Repo.FindByID(ctx, p.RepoID)
+	if err != nil {
+		return nil, err
+	}
+	p.AddCache(jobinfo.RepoEntity, repoEntity)
+
+	return repoEntity, nil
+}
+
 func (p *process) RepoEngine(ctx context.Context) (*engine.Repository, error) {
 	if p.repoEngine != nil {
 		return p.repoEngine, nil
@@ -152,14 +177,9 @@ func (p *process) RepoEngine(ctx context.Context) (*engine.Repository, error) {
 		return p.repoEngine, nil
 	}
 
-	repoEntity, ok := p.Cache[jobinfo.RepoEntity].(*entity.Repository)
-	if !ok {
-		var err error
-		repoEntity, err = p.mgr.srv.Repo.FindByID(ctx, p.RepoID)
-		if err != nil {
-			return nil, err
-		}
-		p.AddCache(jobinfo.RepoEntity, repoEntity)
+	repoEntity, err := p.repositoryEntity(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	source, issues, err := p.mgr.Integrations.RepositoryIntegrations(ctx, repoEntity)
@@ -168,7 +188,7 @@ func (p *process) RepoEngine(ctx context.Context) (*engine.Repository, error) {
 	}
 
 	repoEngine := engine.NewRepository(p.RepoID, repoEntity.Path(), &engine.RepositoryOpts{
-		Adapter:   git2go.NewAdapter(),
+		Adapter:   p.mgr.newAdapter(),
 		Issues:    issues,
 		Source:    source,
 		OriginURL: repoEntity.Source.CloneURL,
@@ -250,7 +270,13 @@ func ReportPushCheckResult(ctx context.Context, p *process) error {
 		return err
 	}
 
-	summary := NewPushSummery(commits)
+	repoEntity, err := p.repositoryEntity(ctx)
+	if err != nil {
+		return err
+	}
+
+	summary := NewPushSummery(commits, repoEntity.RiskThresholds())
+	summary.ConfigProblems = p.configProblems
 
 	return repoEngine.SCM().FinishCheckRun(ctx, p.Details, p.tracker.Status(), summary)
 }
@@ -265,7 +291,13 @@ func ReportPullRequestCheckResult(ctx context.Context, p *process) error {
 		pulls = []*entity.PullRequest{pull}
 	}
 
-	summary := NewPullRequestSummary(len(pulls))
+	repoEntity, err := p.repositoryEntity(ctx)
+	if err != nil {
+		return err
+	}
+
+	summary := NewPullRequestSummary(len(pulls), repoEntity.RiskThresholds())
+	summary.ConfigProblems = p.configProblems
 	for _, pull := range pulls {
 		commitsItr, err := p.mgr.srv.Commit.FindPullRequestCommits(ctx, p.RepoID, pull.ID)
 		if err != nil {
@@ -337,13 +369,13 @@ func routeCheckPipelines(ctx context.Context, p *process) error {
 		return errors.New("unsupported action for checks")
 
 	case invoke.ActionPushCheck:
-		return p.runPipeline(ctx, pipelineUpdateRepository)
+		return p.runPipeline(ctx, p.mgr.pipelines[PipelineUpdateRepository])
 
 	case invoke.ActionPullRequestCheck:
 	}
 
 	if _, ok := p.Details[jobinfo.PullRequestID]; ok {
-		return p.runPipeline(ctx, pipelineUpdatePullRequest)
+		return p.runPipeline(ctx, p.mgr.pipelines[PipelinePullRequest])
 	}
 
 	pulls, err := p.pullRequestEntities(ctx)
@@ -355,7 +387,7 @@ func routeCheckPipelines(ctx context.Context, p *process) error {
 		p.Details[jobinfo.PullRequestID] = pull.ID
 		p.AddCache(jobinfo.PullRequestEntity, pull)
 
-		err = p.runPipeline(ctx, pipelineUpdatePullRequest)
+		err = p.runPipeline(ctx, p.mgr.pipelines[PipelinePullRequest])
 		if err != nil {
 			return err
 		}
@@ -386,23 +418,182 @@ func PrepareGitRepository(ctx context.Context, p *process) error {
 
 	err = repoEngine.Open()
 	if err != nil {
-		if err == engine.ErrLocalRepoNotExist {
-			err = p.saveStatus(ctx, status.Cloning)
-			if err != nil {
+		if err != engine.ErrLocalRepoNotExist {
+			return err
+		}
+
+		err = p.saveStatus(ctx, status.Cloning)
+		if err != nil {
+			return err
+		}
+
+		err = repoEngine.Clone(ctx)
+	} else {
+		err = p.saveStatus(ctx, status.Fetching)
+		if err != nil {
+			return err
+		}
+
+		err = repoEngine.FetchOrigin(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = p.loadFileConfig(ctx, repoEngine)
+	if err != nil {
+		return err
+	}
+
+	err = p.setupBranchFilter(ctx, repoEngine)
+	if err != nil {
+		return err
+	}
+
+	return p.setupIdentityResolver(ctx, repoEngine)
+}
+
+// setupBranchFilter limits the ingested branches to the selected ones, the
+// analyzed branches that are not selected anymore are removed as the deleted
+// branches.
+func (p *process) setupBranchFilter(ctx context.Context, repoEngine *engine.Repository) error {
+	repoEntity, err := p.repositoryEntity(ctx)
+	if err != nil {
+		return err
+	}
+
+	filter, err := branchfilter.New(repoEntity.EffectiveBranchConfig(), repoEntity.Source.DefaultBranch)
+	if err != nil {
+		return err
+	}
+
+	if filter != nil {
+		repoEngine.SetBranchFilter(filter)
+	}
+	return nil
+}
+
+// loadFileConfig reads the config file of the default branch, which overrides
+// the stored configs of the repository. The problems of an invalid file are
+// reported in the job and the check runs, and the stored configs are used.
+func (p *process) loadFileConfig(ctx context.Context, repoEngine *engine.Repository) error {
+	repoEntity, err := p.repositoryEntity(ctx)
+	if err != nil {
+		return err
+	}
+
+	content, err := readDefaultBranchFile(repoEngine, repoEntity.Source.DefaultBranch, repoconfig.FileName)
+	if err != nil {
+		// keep the last config if the file cannot be read
+		p.logger.Warn().Err(err).Msg("read the config file")
+		return nil
+	}
+
+	var cfg *repoconfig.Config
+	if content != nil {
+		cfg, err = repoconfig.Parse(content)
+		if err != nil {
+			var cfgErr *repoconfig.Error
+			if !errors.As(err, &cfgErr) {
 				return err
 			}
 
-			return repoEngine.Clone(ctx)
+			// keep the last config if the file is invalid
+			p.logger.Warn().Err(err).Msg("invalid config file")
+			p.configProblems = cfgErr.Problems
+			return p.mgr.srv.Job.ReportWarnings(ctx, p.JobID, cfgErr.Problems...)
 		}
+	}
+
+	if IsSameStored(cfg, repoEntity.FileConfig) {
+		return nil
+	}
+
+	oldSubsystems := repoEntity.EffectiveSubsystemConfig()
+	err = p.mgr.srv.Repo.SaveFileConfig(ctx, p.RepoID, cfg)
+	if err != nil {
 		return err
 	}
+	repoEntity.FileConfig = cfg
+
+	if IsSameStored(oldSubsystems, repoEntity.EffectiveSubsystemConfig()) {
+		return nil
+	}
 
-	err = p.saveStatus(ctx, status.Fetching)
+	// the new repositories and the subsystem updates analyze all the commits
+	// with the new subsystems
+	switch p.Action {
+	case invoke.ActionRepositoryAdded, invoke.ActionSubsystemsUpdate:
+		return nil
+	}
+
+	return p.mgr.ProcessRepository(&jobinfo.JobInfo{
+		Action: invoke.ActionSubsystemsUpdate,
+		RepoID: p.RepoID,
+		Cache: jobinfo.Store{
+			jobinfo.RepoEntity: repoEntity,
+		},
+	})
+}
+
+// IsSameStored compares the values as they are stored, the empty lists equal the
+// missing ones as they are omitted.
+func IsSameStored(a, b interface{}) bool {
+	rawA, errA := bson.Marshal(bson.M{"v": a})
+	rawB, errB := bson.Marshal(bson.M{"v": b})
+	return errA == nil && errB == nil && bytes.Equal(rawA, rawB)
+}
+
+// setupIdentityResolver resolves the developer identities using the mailmap of
+// the default branch and the aliases of the repository, and detects the bots.
+func (p *process) setupIdentityResolver(ctx context.Context, repoEngine *engine.Repository) error {
+	repoEntity, err := p.repositoryEntity(ctx)
 	if err != nil {
 		return err
 	}
 
-	return repoEngine.FetchOrigin(ctx)
+	mailmap, err := readMailmap(repoEngine, repoEntity.Source.DefaultBranch)
+	if err != nil {
+		// the developers are still identified without the mailmap
+		p.logger.Warn().Err(err).Msg("read the mailmap")
+	}
+
+	repoEngine.SetIdentityResolver(identity.NewResolver(mailmap, repoEntity.DeveloperAliases))
+	repoEngine.SetBotDetector(identity.NewBotDetector(repoEntity.EffectiveBotConfig()))
+	return nil
+}
+
+func readMailmap(repo *engine.Repository, branch string) (*identity.Mailmap, error) {
+	content, err := readDefaultBranchFile(repo, branch, ".mailmap")
+	if content == nil || err != nil {
+		return nil, err
+	}
+
+	return identity.ParseMailmap(bytes.NewReader(content))
+}
+
+// readDefaultBranchFile reads a file from the head of the branch, it returns a
+// nil content if the branch or the file does not exist.
+func readDefaultBranchFile(repo *engine.Repository, branch string, path string) ([]byte, error) {
+	branches, err := repo.Branches()
+	if err != nil {
+		return nil, err
+	}
+
+	head, ok := branches[branch]
+	if !ok {
+		return nil, nil
+	}
+
+	content, err := repo.ReadFile(head, path)
+	if err != nil {
+		if err == engine.ErrFileNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return content, nil
 }
 
 func PreparePullRequest(ctx context.Context, p *process) error {
@@ -472,38 +663,12 @@ func (p *process) run(baseCtx context.Context) {
 			p.logger.Err(err).Msg("error while reporting an error")
 		}
 
-		select {
-		case <-ctx.Done():
-			p.CancelNext()
-		default:
-			p.cancel()
-		}
+		p.cancel()
 
 		p.mgr.done <- p
 	}()
 
-	var pipe Pipeline
-	switch p.Action {
-	case invoke.ActionRepositoryAdded:
-		pipe = pipelineNewRepository
-
-	case invoke.ActionMonitorRepository:
-		pipe = pipelineNewPublicRepository
-
-	case invoke.ActionPullRequestAdded, invoke.ActionPullRequestUpdate:
-		pipe = pipelineUpdatePullRequest
-
-	case invoke.ActionPushCheck:
-		pipe = pipelineProcessPushCheck
-
-	case invoke.ActionPullRequestCheck:
-		pipe = pipelineProcessPullRequestCheck
-
-	default:
-		pipe = pipelineUpdateRepository
-	}
-
-	err = p.runPipeline(ctx, pipe)
+	err = p.runPipeline(ctx, p.mgr.pipelines[pipelineKind(p.Action)])
 }
 
 func errorFromRecovery(r interface{}) error {
@@ -521,6 +686,14 @@ func (p *process) checkErrors(ctx context.Context, err error) error {
 	}
 
 	if err == context.Canceled {
+		// the job of a paused repository is kept in the queue to be resumed
+		paused, pauseErr := p.mgr.jobs.Paused(ctx, p.RepoID)
+		if pauseErr != nil {
+			return pauseErr
+		}
+		if paused {
+			return p.saveStatus(ctx, status.Paused)
+		}
 		return p.saveStatus(ctx, status.Canceled)
 	}
 
@@ -720,11 +893,47 @@ func analyze(ctx context.Context, p *process) error {
 
 	p.logger.Info().Int("start_points_count", len(p.startPoints)).Msg("start analyzing")
 
+	repoEntity, err := p.repositoryEntity(ctx)
+	if err != nil {
+		return err
+	}
+
+	subsystems, err := newSubsystemMapper(p.repoEngine, repoEntity)
+	if err != nil {
+		return err
+	}
+
+	paths, err := classify.NewPathClassifier(repoEntity.EffectivePathConfig())
+	if err != nil {
+		return err
+	}
+
+	analyzed, err := p.checkpointedCommits(ctx)
+	if err != nil {
+		return err
+	}
+	checkpoint := newAnalysisCheckpoint(p.mgr.srv.Checkpoint, p.RepoID, p.JobID, analyzed)
+
 	p.tracker.SetStageTotal(p.repoEngine.CommitsCount())
-	analyzer := analysis.NewRepositoryAnalysis(p.repoEngine, p.JobID, p.mgr.srv.Commit, p.tracker)
+	analyzer, err := analysis.NewRepositoryAnalysis(p.repoEngine, p.JobID, p.mgr.srv.Commit, p.mgr.srv.BugLink, p.tracker, &analysis.Options{
+		SZZ:         repoEntity.SZZConfig,
+		Metrics:     repoEntity.MetricsConfig,
+		Subsystems:  subsystems,
+		Paths:       paths,
+		Parallelism: p.mgr.parallelism,
+		Checkpoint:  checkpoint,
+	})
+	if err != nil {
+		return err
+	}
 
 	err = analyzer.Run(ctx, p.startPoints)
 	if err != nil {
+		// the checkpoint is saved even if the job is paused or canceled, so
+		// the next job resumes the analysis
+		if err := checkpoint.Flush(p.mgr.ctx); err != nil {
+			p.logger.Err(err).Msg("cannot save the analysis checkpoint")
+		}
 		return err
 	}
 
@@ -737,6 +946,12 @@ func analyze(ctx context.Context, p *process) error {
 		return err
 	}
 
+	// the analyzed branches are saved, so the checkpoint is not needed anymore
+	err = p.mgr.srv.Checkpoint.DeleteRepoCheckpoint(ctx, p.RepoID)
+	if err != nil {
+		return err
+	}
+
 	count, err := p.mgr.srv.Commit.BugInducingCount(ctx, p.RepoID)
 	if err != nil {
 		return err
@@ -745,6 +960,140 @@ func analyze(ctx context.Context, p *process) error {
 	return p.mgr.srv.Repo.SaveBuggyCount(ctx, p.RepoID, int(count))
 }
 
+// checkpointedCommits returns the commits that are analyzed by an interrupted
+// analysis of the repository and still stored, and it loads their files to
+// carry their histories. The commits that are deleted after the interruption,
+// such as by pruning the branches, are analyzed again.
+func (p *process) checkpointedCommits(ctx context.Context) (identifier.HashSet, error) {
+	checkpointed, err := p.mgr.srv.Checkpoint.AnalyzedCommits(ctx, p.RepoID)
+	if err != nil || checkpointed.Count() == 0 {
+		return nil, err
+	}
+
+	itr, err := p.mgr.srv.Commit.RepositoryEngineFiles(ctx, p.RepoID)
+	if err != nil {
+		return nil, err
+	}
+
+	analyzed := identifier.NewHashSet()
+	err = itr.ForEach(ctx, func(hash identifier.Hash, files map[string]*engine.FileInfo) error {
+		if !checkpointed.Has(hash) {
+			return nil
+		}
+		if c, ok := p.repoEngine.Commit(hash); ok {
+			c.SetFiles(files)
+			analyzed.Add(hash)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p.logger.Info().
+		Int("analyzed_commits", analyzed.Count()).
+		Msg("resume the interrupted analysis")
+
+	return analyzed, nil
+}
+
+// RecomputeSubsystems recomputes the subsystems of all the analyzed commits and
+// their subsystem metrics, then all the commits are predicted again.
+func RecomputeSubsystems(ctx context.Context, p *process) error {
+	err := p.saveStatus(ctx, status.Analyzing)
+	if err != nil {
+		return err
+	}
+
+	repoEntity, err := p.repositoryEntity(ctx)
+	if err != nil {
+		return err
+	}
+
+	subsystems, err := newSubsystemMapper(p.repoEngine, repoEntity)
+	if err != nil {
+		return err
+	}
+
+	// the files are loaded again because the analysis loads them only if
+	// there are new commits, and the new commits are included
+	itr, err := p.mgr.srv.Commit.RepositoryEngineFiles(ctx, p.RepoID)
+	if err != nil {
+		return err
+	}
+
+	err = itr.ForEach(ctx, func(hash identifier.Hash, files map[string]*engine.FileInfo) error {
+		if c, ok := p.repoEngine.Commit(hash); ok {
+			c.SetFiles(files)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	p.tracker.SetStageTotal(p.repoEngine.CommitsCount())
+	err = analysis.NewSubsystemsRecomputation(p.repoEngine, p.mgr.srv.Commit, p.tracker, &analysis.Options{
+		Metrics:    repoEntity.MetricsConfig,
+		Subsystems: subsystems,
+	}).Run(ctx)
+	if err != nil {
+		return err
+	}
+
+	// all the commits are predicted with the new metrics
+	p.predictAll = true
+	return nil
+}
+
+// UpdateReleases stores the tags of the ingested commits as the releases of
+// the repository, and assigns every commit to the first release that contains
+// it.
+func UpdateReleases(ctx context.Context, p *process) error {
+	tags, err := p.repoEngine.Tags()
+	if err != nil {
+		return err
+	}
+
+	releases := make([]*entity.Release, 0, len(tags))
+	for _, tag := range tags {
+		if _, ok := p.repoEngine.Commit(tag.Commit); !ok {
+			continue
+		}
+
+		releases = append(releases, &entity.Release{
+			Repo:   p.RepoID,
+			Tag:    tag.Name,
+			Commit: tag.Commit,
+			Date:   tag.Date,
+		})
+	}
+
+	err = p.mgr.srv.Release.ReplaceRepoReleases(ctx, p.RepoID, releases)
+	if err != nil {
+		return err
+	}
+
+	return p.mgr.srv.Commit.SaveReleases(ctx, p.RepoID, p.repoEngine.FirstReleases(tags))
+}
+
+// newSubsystemMapper creates the subsystem mapper of the repository, the
+// CODEOWNERS file and the manifests are read from the default branch.
+func newSubsystemMapper(repo *engine.Repository, repoEntity *entity.Repository) (*subsystem.Mapper, error) {
+	branches, err := repo.Branches()
+	if err != nil {
+		return nil, err
+	}
+
+	head, ok := branches[repoEntity.Source.DefaultBranch]
+	return subsystem.New(repoEntity.EffectiveSubsystemConfig(), func(path string) ([]byte, error) {
+		if !ok {
+			return nil, engine.ErrFileNotFound
+		}
+		return repo.ReadFile(head, path)
+	})
+}
+
 func FinalizePullRequestAnalysis(ctx context.Context, p *process) error {
 	if len(p.startPoints) == 0 {
 		return nil
@@ -782,7 +1131,7 @@ func MarkPullRequestCommits(ctx context.Context, commitsDB entity.CommitDataSour
 }
 
 func Predict(ctx context.Context, p *process) error {
-	if len(p.startPoints) == 0 && p.includeAfter.IsZero() {
+	if len(p.startPoints) == 0 && p.includeAfter.IsZero() && !p.predictAll {
 		p.logger.Info().Msg("skip predicting")
 		return nil
 	}
@@ -792,17 +1141,28 @@ func Predict(ctx context.Context, p *process) error {
 		return err
 	}
 
-	var oldestJob string
-	if !p.includeAfter.IsZero() {
-		oldestJob = p.includeAfter.Hex()
+	var res *repofuel.PredictionResult
+	if p.predictAll {
+		res, _, err = p.mgr.srv.Repofuel.ML.PredictAllByJob(ctx, p.RepoID.Hex(), p.JobID.Hex())
+	} else {
+		var oldestJob string
+		if !p.includeAfter.IsZero() {
+			oldestJob = p.includeAfter.Hex()
+		}
+
+		res, _, err = p.mgr.srv.Repofuel.ML.PredictByJob(ctx, p.RepoID.Hex(), p.JobID.Hex(), oldestJob)
+	}
+	if err != nil {
+		return err
 	}
 
-	res, _, err := p.mgr.srv.Repofuel.ML.PredictByJob(ctx, p.RepoID.Hex(), p.JobID.Hex(), oldestJob)
+	repoEntity, err := p.repositoryEntity(ctx)
 	if err != nil {
 		return err
 	}
 
-	ba, err := generateCommitAnalyses(ctx, p.mgr.srv.Commit, res.Predictions, res.Quantiles)
+	skipBots := repoEntity.MetricsConfig != nil && repoEntity.MetricsConfig.SkipBots
+	ba, err := generateCommitAnalyses(ctx, p.mgr.srv.Commit, res.Predictions, res.Quantiles, skipBots)
 	if err != nil {
 		return err
 	}
@@ -819,7 +1179,7 @@ func Predict(ctx context.Context, p *process) error {
 	return p.mgr.srv.Repo.SaveQuality(ctx, p.RepoID, res.Status)
 }
 
-func generateCommitAnalyses(ctx context.Context, commitDB entity.CommitDataSource, predictions []repofuel.Prediction, quantiles *metrics.Quantiles) ([]*entity.CommitAnalysisHolder, error) {
+func generateCommitAnalyses(ctx context.Context, commitDB entity.CommitDataSource, predictions []repofuel.Prediction, quantiles *metrics.Quantiles, skipBots bool) ([]*entity.CommitAnalysisHolder, error) {
 	if len(predictions) == 0 {
 		return nil, nil
 	}
@@ -829,9 +1189,9 @@ func generateCommitAnalyses(ctx context.Context, commitDB entity.CommitDataSourc
 		return nil, err
 	}
 
-	res := make([]*entity.CommitAnalysisHolder, len(predictions))
+	res := make([]*entity.CommitAnalysisHolder, 0, len(predictions))
 
-	for i, p := range predictions {
+	for _, p := range predictions {
 		id, err := identifier.CommitIDFromStr(p.CommitID, '_')
 		if err != nil {
 			return nil, err
@@ -842,7 +1202,11 @@ func generateCommitAnalyses(ctx context.Context, commitDB entity.CommitDataSourc
 			return nil, err
 		}
 
-		res[i] = &entity.CommitAnalysisHolder{
+		if skipBots && c.HasTag(classify.Bot) {
+			continue
+		}
+
+		res = append(res, &entity.CommitAnalysisHolder{
 			ID: id,
 			Analysis: entity.CommitAnalysis{
 				BugPotential: p.Score,
@@ -855,7 +1219,7 @@ func generateCommitAnalyses(ctx context.Context, commitDB entity.CommitDataSourc
 				Insights: gen.CommitInsights(c),
 			},
 			FileInsights: gen.FileInsights(c),
-		}
+		})
 	}
 
 	return res, nil
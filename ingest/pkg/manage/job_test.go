@@ -9,14 +9,28 @@ import (
 	mock_entity "github.com/repofuel/repofuel/ingest/internal/mock/entity"
 	mock_providers "github.com/repofuel/repofuel/ingest/internal/mock/providers"
 	"github.com/repofuel/repofuel/ingest/pkg/engine"
-	"github.com/repofuel/repofuel/ingest/pkg/engine/git2go"
+	"github.com/repofuel/repofuel/ingest/pkg/engine/enginetest"
+	"github.com/repofuel/repofuel/ingest/pkg/engine/gogit"
 	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+	"github.com/repofuel/repofuel/ingest/pkg/invoke"
+	"github.com/repofuel/repofuel/ingest/pkg/jobinfo"
+	"github.com/repofuel/repofuel/ingest/pkg/repoconfig"
 	"github.com/repofuel/repofuel/pkg/common"
+	"github.com/rs/zerolog"
 )
 
 func TestMarkPullRequestCommits(t *testing.T) {
 	t.Skip("skip to avoid git cloning")
 
+	// the test runs for every adapter that is built in the binary
+	for name, newAdapter := range adapterFactories {
+		t.Run(name, func(t *testing.T) {
+			testMarkPullRequestCommits(t, newAdapter)
+		})
+	}
+}
+
+func testMarkPullRequestCommits(t *testing.T, newAdapter func(*engine.DiffOptions) engine.RepositoryAdapter) {
 	ctx := context.Background()
 	repoID := identifier.RepositoryID{}
 
@@ -25,7 +39,7 @@ func TestMarkPullRequestCommits(t *testing.T) {
 
 	repo := engine.NewRepository(repoID, "../../repos/tests/fastapi", &engine.RepositoryOpts{
 		OriginURL: "https://github.com/emadshihab/fastapi",
-		Adapter:   git2go.NewAdapter(),
+		Adapter:   newAdapter(&engine.DiffOptions{}),
 		Source:    mock_providers.NewMockSourceIntegration(mockCtrl),
 	})
 
@@ -68,3 +82,55 @@ func TestMarkPullRequestCommits(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+type warningsRecorder struct {
+	entity.JobDataSource
+	warnings []string
+}
+
+func (r *warningsRecorder) ReportWarnings(_ context.Context, _ identifier.JobID, warnings ...string) error {
+	r.warnings = append(r.warnings, warnings...)
+	return nil
+}
+
+func TestLoadFileConfig_Invalid(t *testing.T) {
+	f := enginetest.NewFixture(t)
+	f.Write(repoconfig.FileName, "version: 1\nsubsystems: [\n")
+	f.Commit("A")
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	repoEntity := &entity.Repository{
+		Source:     common.Repository{DefaultBranch: "master"},
+		FileConfig: &repoconfig.Config{Version: repoconfig.Version},
+	}
+	jobs := &warningsRecorder{}
+	p := &process{
+		JobInfo: &jobinfo.JobInfo{
+			Action: invoke.ActionRepositoryPush,
+			Cache:  jobinfo.Store{jobinfo.RepoEntity: repoEntity},
+		},
+		logger: zerolog.Nop(),
+		mgr: &Manager{srv: ManagerServices{
+			Job: jobs,
+			// the mock fails the test if the stored config is replaced
+			Repo: mock_entity.NewMockRepositoryDataSource(mockCtrl),
+		}},
+	}
+
+	adp := enginetest.Clone(t, func() engine.RepositoryAdapter { return gogit.NewAdapter() }, f)
+	repo := engine.NewRepository(identifier.RepositoryID{}, "", &engine.RepositoryOpts{Adapter: adp})
+
+	err := p.loadFileConfig(context.Background(), repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(jobs.warnings) == 0 || len(p.configProblems) == 0 {
+		t.Error("expected the problems of the config file to be reported")
+	}
+	if repoEntity.FileConfig == nil {
+		t.Error("expected the last valid config to be kept")
+	}
+}
@@ -7,20 +7,23 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
-	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/repofuel/repofuel/accounts/pkg/jwtauth"
 	"github.com/repofuel/repofuel/ingest/internal/entity"
 	"github.com/repofuel/repofuel/ingest/pkg/identifier"
 	"github.com/repofuel/repofuel/ingest/pkg/invoke"
 	"github.com/repofuel/repofuel/ingest/pkg/jobinfo"
+	"github.com/repofuel/repofuel/ingest/pkg/jobqueue"
 	"github.com/repofuel/repofuel/ingest/pkg/status"
 	"github.com/repofuel/repofuel/pkg/repofuel"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Manager struct {
@@ -30,11 +33,21 @@ type Manager struct {
 	numWorkers   int
 	srv          ManagerServices
 	done         chan *process
+	wake         chan struct{}
 	Integrations *IntegrationManager
 	queues       map[QueueID]*Queue
+	queueWorkers map[QueueID]int
+	orgWorkers   int
+	orgLimits    map[identifier.OrganizationID]int
+	jobs         jobqueue.Queue
+	owner        string
+	leaseTTL     time.Duration
+	pollInterval time.Duration
+	pipelines    map[string]Pipeline
 	observables  *ProgressObservableRegistry
+	newAdapter   adapterFactory
+	parallelism  int
 	processes    map[identifier.RepositoryID]*process
-	queued       map[identifier.RepositoryID]*jobinfo.JobInfo
 	mu           sync.Mutex
 }
 
@@ -42,41 +55,97 @@ type Manager struct {
 type ManagerServices struct {
 	Provider     entity.ProviderDataSource
 	Commit       entity.CommitDataSource
+	BugLink      entity.BugLinkDataSource
+	Release      entity.ReleaseDataSource
+	Checkpoint   entity.CheckpointDataSource
 	Repo         entity.RepositoryDataSource
 	Job          entity.JobDataSource
 	PullRequest  entity.PullRequestDataSource
 	Organization entity.OrganizationDataSource
 	Verification entity.VerificationDataSource
 	Monitor      entity.MonitorDataSource
+	// Queue is shared by the nodes, so a repository is processed by one node
+	// at a time.
+	Queue jobqueue.Queue
 	//deprecated: should be moved
 	Repofuel *repofuel.Client
 }
 
-func NewManager(ctx context.Context, services ManagerServices, authCheck *jwtauth.AuthCheck) *Manager {
+func NewManager(ctx context.Context, services ManagerServices, authCheck *jwtauth.AuthCheck, opts *Options) (*Manager, error) {
+	newAdapter, err := newAdapterFactory(&opts.Engine)
+	if err != nil {
+		return nil, err
+	}
+
+	queueWorkers, err := opts.Queue.queueWorkers()
+	if err != nil {
+		return nil, err
+	}
+
+	orgLimits, err := opts.Queue.organizationLimits()
+	if err != nil {
+		return nil, err
+	}
+
+	pipelines, err := buildPipelines(opts.Pipelines)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := newOwnerID()
+	if err != nil {
+		return nil, err
+	}
+
 	mgr := &Manager{
 		ctx:          ctx,
 		logger:       log.Ctx(ctx),
-		numWorkers:   DefaultManagerWorkersCount,
+		numWorkers:   opts.Queue.workers(),
 		srv:          services,
 		done:         make(chan *process),
+		wake:         make(chan struct{}, 1),
 		Integrations: nil,
 		queues:       make(map[QueueID]*Queue),
+		queueWorkers: queueWorkers,
+		orgWorkers:   opts.Queue.OrganizationWorkers,
+		orgLimits:    orgLimits,
+		jobs:         services.Queue,
+		owner:        owner,
+		leaseTTL:     opts.Queue.leaseTTL(),
+		pollInterval: opts.Queue.pollInterval(),
+		pipelines:    pipelines,
 		observables:  nil,
+		newAdapter:   newAdapter,
+		parallelism:  analysisParallelism(&opts.Engine),
 		processes:    make(map[identifier.RepositoryID]*process),
-		queued:       make(map[identifier.RepositoryID]*jobinfo.JobInfo),
 		mu:           sync.Mutex{},
 	}
 	mgr.Integrations = NewIntegrationManager(mgr, services.Provider, services.Organization, services.Verification, authCheck, services.Repofuel)
 	mgr.observables = newProgressObservableRegistry(mgr)
 
-	return mgr
+	return mgr, nil
+}
+
+// newOwnerID identifies the manager in the leases of the queue, it is unique
+// even if the nodes share the host name.
+func newOwnerID() (string, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "", err
+	}
+
+	return hostname + "-" + primitive.NewObjectID().Hex(), nil
 }
 
 // IMPOTENT: should be called with the manager mutex locked
 func (mgr *Manager) getOrCreateQueue(id QueueID) *Queue {
 	q, ok := mgr.queues[id]
 	if !ok {
-		q = &Queue{NumWorkers: DefaultQueueWorkersCount}
+		n, ok := mgr.queueWorkers[id]
+		if !ok {
+			n = DefaultQueueWorkersCount
+		}
+		q = &Queue{NumWorkers: n}
 		mgr.queues[id] = q
 	}
 	return q
@@ -88,12 +157,12 @@ func (mgr *Manager) ProgressObservableRegistry() *ProgressObservableRegistry {
 
 func (mgr *Manager) Run() {
 	mgr.mu.Lock()
-
 	mgr.run = true
-	mgr.processQueuedRepositories()
-
 	mgr.mu.Unlock()
 
+	go mgr.dispatch()
+	go mgr.heartbeat()
+
 	for p := range mgr.done {
 		mgr.runNext(p)
 	}
@@ -112,32 +181,123 @@ func FindQueueID(info *jobinfo.JobInfo) QueueID {
 	case invoke.ActionRepositoryAdded, invoke.ActionMonitorRepository:
 		return QueueNewRepos
 
+	case invoke.ActionPullRequestCheck, invoke.ActionPullRequestAdded, invoke.ActionPullRequestUpdate:
+		return QueuePullRequests
+
 	default:
 		return QueueNewCommits
 
 	}
 }
 
-func (mgr *Manager) runNext(p *process) {
-	mgr.mu.Lock()
-	defer mgr.mu.Unlock()
+// wakeUp asks the dispatcher to lease jobs from the queue, it does not block if
+// the dispatcher is already asked.
+func (mgr *Manager) wakeUp() {
+	select {
+	case mgr.wake <- struct{}{}:
+	default:
+	}
+}
+
+// dispatch leases jobs whenever it is woken up, and polls the queue for the
+// jobs that are pushed by the other nodes and the expired leases.
+func (mgr *Manager) dispatch() {
+	ticker := time.NewTicker(mgr.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		mgr.processQueuedRepositories()
+
+		select {
+		case <-mgr.wake:
+		case <-ticker.C:
+		case <-mgr.ctx.Done():
+			return
+		}
+	}
+}
+
+// heartbeat renews the leases of the repositories that are processed by this
+// node, and stops processing the repositories whose leases are lost.
+func (mgr *Manager) heartbeat() {
+	ticker := time.NewTicker(mgr.leaseTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-mgr.ctx.Done():
+			return
+		}
+
+		mgr.mu.Lock()
+		repos := make([]identifier.RepositoryID, 0, len(mgr.processes))
+		for id := range mgr.processes {
+			repos = append(repos, id)
+		}
+		mgr.mu.Unlock()
+
+		if len(repos) == 0 {
+			continue
+		}
+
+		lost, err := mgr.jobs.Extend(mgr.ctx, mgr.owner, mgr.leaseTTL, repos...)
+		if err != nil {
+			mgr.logger.Err(err).Msg("cannot extend the repository leases")
+			continue
+		}
 
-	defer mgr.processQueuedRepositories()
+		for _, id := range lost {
+			mgr.logger.Warn().
+				Hex("repo", id[:]).
+				Msg("the repository lease is lost")
+			mgr.stopProcess(id)
+		}
+	}
+}
 
+func (mgr *Manager) runNext(p *process) {
+	defer mgr.wakeUp()
+
+	mgr.mu.Lock()
 	queue, ok := mgr.queues[FindQueueID(p.JobInfo)]
 	if !ok {
+		mgr.mu.Unlock()
 		mgr.logger.Error().Msg("missed queue entry")
 		return
 	}
 	queue.processDone()
 
-	if !p.HasNext() {
-		mgr.deleteProcess(p.RepoID)
-		mgr.processJobsFromQueue(queue)
+	if mgr.processes[p.RepoID] != p {
+		// the process is stopped, its jobs are removed or processed by
+		// another node
+		mgr.mu.Unlock()
 		return
 	}
 
-	p.JobInfo = p.Next()
+	// the process is deleted before the queue is updated, so if the lease is
+	// released the repository is processed again when it is leased
+	mgr.deleteProcess(p.RepoID)
+	mgr.mu.Unlock()
+
+	next, err := mgr.jobs.Next(mgr.ctx, mgr.owner, mgr.leaseTTL, &jobqueue.Entry{
+		Job:  p.JobID,
+		Repo: p.RepoID,
+	})
+	if err != nil {
+		if err != jobqueue.ErrEmpty {
+			mgr.logger.Err(err).
+				Hex("repo", p.RepoID[:]).
+				Msg("cannot get the next job of the repository")
+		}
+		return
+	}
+
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+
+	p.JobInfo = newJobInfo(next)
+	mgr.processes[p.RepoID] = p
 	queue = mgr.getOrCreateQueue(FindQueueID(p.JobInfo))
 	go p.run(mgr.ctx)
 	queue.processStarted()
@@ -148,16 +308,24 @@ var notWorkingStages = []status.Stage{
 	status.Failed,
 	status.Canceled,
 	status.Watched,
+	status.Paused,
 }
 
+// Recover queues the repositories that are left in a working stage without
+// queued jobs. The repositories with queued jobs are continued by the queue,
+// including the jobs of the nodes that are stopped while processing them.
 func (mgr *Manager) Recover(ctx context.Context) error {
-	// fixme: the recover process does not scale to multiple nodes
 	itr, err := mgr.srv.Repo.FindWhereStatusNot(ctx, notWorkingStages...)
 	if err != nil {
 		return err
 	}
 
 	return itr.ForEach(ctx, func(repo *entity.Repository) error {
+		queued, err := mgr.jobs.Has(ctx, repo.ID)
+		if err != nil || queued {
+			return err
+		}
+
 		return mgr.ProcessRepository(&jobinfo.JobInfo{
 			Action: invoke.ActionRepositoryRecovering,
 			RepoID: repo.ID,
@@ -168,39 +336,75 @@ func (mgr *Manager) Recover(ctx context.Context) error {
 	})
 }
 
-// IMPOTENT: should be called with the manager mutex locked
+// processQueuedRepositories leases jobs from the queues that have free workers,
+// until the workers of the manager are busy or there are no jobs to lease.
 func (mgr *Manager) processQueuedRepositories() {
-	if !mgr.HasFreeWorkers() {
-		return
-	}
+	for {
+		mgr.mu.Lock()
+		free := mgr.freeQueues()
+		mgr.mu.Unlock()
 
-	seen := NewQueueSet()
+		if len(free) == 0 {
+			return
+		}
 
-	for _, info := range mgr.queued {
-		queue := mgr.getOrCreateQueue(FindQueueID(info))
-		if seen.Has(queue) {
-			continue
+		e, err := mgr.jobs.Lease(mgr.ctx, mgr.owner, mgr.leaseTTL, &jobqueue.Filter{
+			Queues:   free,
+			OrgLimit: mgr.orgLimit,
+		})
+		if err != nil {
+			if err != jobqueue.ErrEmpty {
+				mgr.logger.Err(err).Msg("cannot lease a job from the queue")
+			}
+			return
 		}
 
-		mgr.processJobsFromQueue(queue)
-		seen.Add(queue)
+		mgr.mu.Lock()
+		mgr.startProcess(newJobInfo(e))
+		mgr.mu.Unlock()
 	}
 }
 
 // IMPOTENT: should be called with the manager mutex locked
-func (mgr *Manager) processJobsFromQueue(queue *Queue) {
-	for queue.HasFreeWorkers() && queue.NumWaiting() > 0 && mgr.HasFreeWorkers() {
-		repoId := queue.pup()
-		process, err := mgr.addProcess(repoId)
-		if err != nil {
-			mgr.logger.Err(err).
-				Hex("repo", repoId[:]).
-				Msg("cannot add the process")
-			continue
+func (mgr *Manager) freeQueues() []uint8 {
+	if !mgr.HasFreeWorkers() {
+		return nil
+	}
+
+	free := make([]uint8, 0, len(queues))
+	for id := range queues {
+		if mgr.getOrCreateQueue(id).HasFreeWorkers() {
+			free = append(free, uint8(id))
 		}
-		go process.run(mgr.ctx)
-		queue.processStarted()
 	}
+	return free
+}
+
+// orgLimit returns the concurrent jobs limit of the organization on all the
+// nodes, the repositories without organizations share the same limit.
+func (mgr *Manager) orgLimit(org identifier.OrganizationID) int {
+	if n, ok := mgr.orgLimits[org]; ok {
+		return n
+	}
+	return mgr.orgWorkers
+}
+
+// IMPOTENT: should be called with the manager mutex locked
+func (mgr *Manager) startProcess(info *jobinfo.JobInfo) {
+	if _, ok := mgr.processes[info.RepoID]; ok {
+		// the lease of a running process was expired and claimed again, the
+		// running process continues with the job
+		return
+	}
+
+	p := &process{
+		JobInfo: info,
+		mgr:     mgr,
+	}
+	mgr.processes[info.RepoID] = p
+
+	go p.run(mgr.ctx)
+	mgr.getOrCreateQueue(FindQueueID(info)).processStarted()
 }
 
 func (mgr *Manager) HasFreeWorkers() bool {
@@ -228,82 +432,176 @@ func (mgr *Manager) deleteProcess(id identifier.RepositoryID) {
 	delete(mgr.processes, id)
 }
 
-// IMPOTENT: should be called with the manager mutex locked
-func (mgr *Manager) addProcess(repoID identifier.RepositoryID) (*process, error) {
-	info, ok := mgr.queued[repoID]
-	if !ok {
-		return nil, errors.New("the repository should be queued")
+// ProcessRepository pushes the job to the queue. The cache of the job is not
+// kept in the queue, the node that processes the job loads the entities again.
+func (mgr *Manager) ProcessRepository(info *jobinfo.JobInfo) error {
+	if info.JobID.IsZero() {
+		jobID, err := mgr.srv.Job.CreateJob(mgr.ctx, info.RepoID, info.Action, info.Details)
+		if err != nil {
+			return err
+		}
+		info.JobID = jobID
+	}
+
+	org, err := mgr.repositoryOrganization(info)
+	if err != nil {
+		return err
+	}
+
+	queue := FindQueueID(info)
+	err = mgr.jobs.Push(mgr.ctx, &jobqueue.Entry{
+		Job:      info.JobID,
+		Repo:     info.RepoID,
+		Org:      org,
+		Queue:    uint8(queue),
+		Priority: queue.Priority(),
+		Action:   info.Action,
+		Details:  info.Details,
+	})
+	if err != nil {
+		return err
+	}
+
+	mgr.mu.Lock()
+	run := mgr.run
+	mgr.mu.Unlock()
+
+	if run {
+		mgr.wakeUp()
 	}
-	delete(mgr.queued, repoID)
 
-	runningProcess, ok := mgr.processes[repoID]
+	return nil
+}
+
+// repositoryOrganization returns the organization of the job repository.
+func (mgr *Manager) repositoryOrganization(info *jobinfo.JobInfo) (identifier.OrganizationID, error) {
+	repo, ok := info.Cache[jobinfo.RepoEntity].(*entity.Repository)
 	if ok {
-		runningProcess.Append(info)
-		return nil, errors.New("if the repository has a process, the job should be appended and not queued")
+		return repo.Organization, nil
 	}
 
-	p := &process{
-		JobInfo: info,
-		mgr:     mgr,
+	repo, err := mgr.srv.Repo.FindByID(mgr.ctx, info.RepoID)
+	if err != nil {
+		return identifier.OrganizationID{}, err
 	}
-	mgr.processes[repoID] = p
 
-	return p, nil
+	return repo.Organization, nil
 }
 
-func (mgr *Manager) ProcessRepository(info *jobinfo.JobInfo) error {
-	mgr.mu.Lock()
-	defer mgr.mu.Unlock()
+// QueueStats describe the jobs of a queue on all the nodes.
+type QueueStats struct {
+	ID QueueID
+	jobqueue.Stats
+}
 
-	if info.JobID.IsZero() {
-		jobID, err := mgr.srv.Job.CreateJob(mgr.ctx, info.RepoID, info.Action, info.Details)
-		if err != nil {
-			return err
-		}
-		info.JobID = jobID
+// QueueStats returns the statistics of all the queues, ordered by their
+// priorities.
+func (mgr *Manager) QueueStats(ctx context.Context) ([]*QueueStats, error) {
+	stats, err := mgr.jobs.Stats(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	if job, ok := mgr.processes[info.RepoID]; ok {
-		isAppended := job.Append(info)
-		if !isAppended {
-			return mgr.srv.Job.SaveStatus(mgr.ctx, info.JobID, status.Ignored)
-		}
-		return nil
+	byQueue := make(map[QueueID]*jobqueue.Stats, len(stats))
+	for _, st := range stats {
+		byQueue[QueueID(st.Queue)] = st
 	}
 
-	if job, ok := mgr.queued[info.RepoID]; ok {
-		isAppended := job.Append(info)
-		if !isAppended {
-			return mgr.srv.Job.SaveStatus(mgr.ctx, info.JobID, status.Ignored)
+	res := make([]*QueueStats, 0, len(queues))
+	for id := range queues {
+		st := &QueueStats{ID: id}
+		if s, ok := byQueue[id]; ok {
+			st.Stats = *s
 		}
-		return nil
+		res = append(res, st)
 	}
 
-	// queue the job
-	queue := mgr.getOrCreateQueue(FindQueueID(info))
-	queue.push(info.RepoID)
-	mgr.queued[info.RepoID] = info
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].ID.Priority() > res[j].ID.Priority()
+	})
+
+	return res, nil
+}
 
-	if mgr.run {
-		mgr.processJobsFromQueue(queue)
+// newJobInfo creates the info of a leased job.
+func newJobInfo(e *jobqueue.Entry) *jobinfo.JobInfo {
+	details := jobinfo.Store(e.Details)
+	if details == nil {
+		details = make(jobinfo.Store)
 	}
 
-	return nil
+	return &jobinfo.JobInfo{
+		JobID:   e.Job,
+		RepoID:  e.Repo,
+		Action:  e.Action,
+		Details: details,
+		Cache:   make(jobinfo.Store),
+	}
 }
 
+// StopRepository removes the queued jobs of the repository, and stops its
+// process. The node that processes the repository loses its lease, and stops
+// the process at the next heartbeat.
 func (mgr *Manager) StopRepository(id identifier.RepositoryID) {
-	mgr.mu.Lock()
-	defer mgr.mu.Unlock()
+	err := mgr.jobs.Remove(mgr.ctx, id)
+	if err != nil {
+		mgr.logger.Err(err).
+			Hex("repo", id[:]).
+			Msg("cannot remove the repository from the queue")
+	}
 
-	if _, ok := mgr.queued[id]; ok {
-		delete(mgr.queued, id)
+	mgr.stopProcess(id)
+}
 
-		// remove the repository from all queues
-		for _, q := range mgr.queues {
-			q.removeRepository(id)
-		}
+// PauseRepository holds the queued jobs of the repository until it is resumed,
+// and stops its process. The analysis of the stopped job is checkpointed, so it
+// continues from the analyzed commits when the job is resumed.
+func (mgr *Manager) PauseRepository(ctx context.Context, id identifier.RepositoryID) error {
+	err := mgr.jobs.Pause(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// the process on this node is stopped now, and the processes on the other
+	// nodes are stopped when their leases are lost
+	mgr.stopProcess(id)
+
+	return mgr.srv.Repo.SaveStatus(ctx, id, status.Paused)
+}
+
+// ResumeRepository lets the queued jobs of the paused repository continue, the
+// repository is processed again if it does not have queued jobs.
+func (mgr *Manager) ResumeRepository(ctx context.Context, id identifier.RepositoryID) error {
+	err := mgr.jobs.Resume(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	queued, err := mgr.jobs.Has(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if !queued {
+		return mgr.ProcessRepository(&jobinfo.JobInfo{
+			Action: invoke.ActionRepositoryRecovering,
+			RepoID: id,
+		})
+	}
+
+	err = mgr.srv.Repo.SaveStatus(ctx, id, status.Queued)
+	if err != nil {
+		return err
 	}
 
+	mgr.wakeUp()
+	return nil
+}
+
+func (mgr *Manager) stopProcess(id identifier.RepositoryID) {
+	mgr.mu.Lock()
+	defer mgr.mu.Unlock()
+
 	if p, ok := mgr.processes[id]; ok {
 		delete(mgr.processes, id)
 		p.Stop()
@@ -319,6 +617,21 @@ func (mgr *Manager) DeleteRepository(ctx context.Context, repo *entity.Repositor
 		return err
 	}
 
+	err = mgr.srv.BugLink.DeleteRepoLinks(ctx, repo.ID)
+	if err != nil {
+		return err
+	}
+
+	err = mgr.srv.Release.DeleteRepoReleases(ctx, repo.ID)
+	if err != nil {
+		return err
+	}
+
+	err = mgr.srv.Checkpoint.DeleteRepoCheckpoint(ctx, repo.ID)
+	if err != nil {
+		return err
+	}
+
 	// delete the git assets
 	err = os.RemoveAll(repo.Path())
 	if err != nil {
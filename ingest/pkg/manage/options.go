@@ -0,0 +1,173 @@
+package manage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/repofuel/repofuel/ingest/pkg/engine"
+	"github.com/repofuel/repofuel/ingest/pkg/engine/gogit"
+	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Options are the manager configurations that are loaded with the service configurations.
+type Options struct {
+	Engine EngineOptions `yaml:"engine"`
+	Queue  QueueOptions  `yaml:"queue"`
+	// Pipelines replace the default pipelines of some kinds, such as
+	// update_repository or pull_request, to add optional stages like webhooks.
+	Pipelines map[string]PipelineConfig `yaml:"pipelines"`
+}
+
+type EngineOptions struct {
+	// Adapter is the git implementation used by the engine, git2go is used when it is omitted
+	// and the binary is built with cgo, otherwise gogit is used.
+	Adapter string `yaml:"adapter"`
+	// Parallelism is the number of commits of a repository that are analyzed concurrently,
+	// they are analyzed one by one when it is omitted. It is ignored by gogit because it
+	// does not support concurrent reads.
+	Parallelism int `yaml:"parallelism"`
+	// Diff tunes the detection of the renamed and copied files.
+	Diff engine.DiffOptions `yaml:"diff"`
+}
+
+// QueueOptions tune the leases of the repositories in the job queue.
+type QueueOptions struct {
+	// LeaseTTL is how long a node keeps a repository without renewing its lease,
+	// the lease is renewed every third of it. It is one minute when it is omitted.
+	LeaseTTL time.Duration `yaml:"lease_ttl"`
+	// PollInterval is how often the queue is checked for the jobs that are
+	// pushed by the other nodes and the expired leases. It is ten seconds when
+	// it is omitted.
+	PollInterval time.Duration `yaml:"poll_interval"`
+	// Workers is the number of the concurrent jobs of a node, it is 8 when it
+	// is omitted.
+	Workers int `yaml:"workers"`
+	// QueueWorkers limits the concurrent jobs of the queues on a node by their
+	// keys: pull_requests, new_commits, new_repositories, and recovered. The
+	// omitted queues are limited to 4.
+	QueueWorkers map[string]int `yaml:"queue_workers"`
+	// OrganizationWorkers limits the concurrent jobs of an organization on all
+	// the nodes, so an organization cannot take all the workers. There is no
+	// limit when it is omitted.
+	OrganizationWorkers int `yaml:"organization_workers"`
+	// OrganizationLimits overrides OrganizationWorkers for some organizations
+	// by their IDs.
+	OrganizationLimits map[string]int `yaml:"organization_limits"`
+}
+
+const (
+	DefaultLeaseTTL     = time.Minute
+	DefaultPollInterval = 10 * time.Second
+)
+
+func (opts *QueueOptions) leaseTTL() time.Duration {
+	if opts.LeaseTTL <= 0 {
+		return DefaultLeaseTTL
+	}
+	return opts.LeaseTTL
+}
+
+func (opts *QueueOptions) pollInterval() time.Duration {
+	if opts.PollInterval <= 0 {
+		return DefaultPollInterval
+	}
+	return opts.PollInterval
+}
+
+func (opts *QueueOptions) workers() int {
+	if opts.Workers <= 0 {
+		return DefaultManagerWorkersCount
+	}
+	return opts.Workers
+}
+
+// queueWorkers returns the workers of every queue.
+func (opts *QueueOptions) queueWorkers() (map[QueueID]int, error) {
+	workers := make(map[QueueID]int, len(queues))
+	for id := range queues {
+		workers[id] = DefaultQueueWorkersCount
+	}
+
+	for key, n := range opts.QueueWorkers {
+		id, ok := queueByKey(key)
+		if !ok {
+			return nil, fmt.Errorf("unknown job queue: %q", key)
+		}
+		if n < 1 {
+			return nil, fmt.Errorf("the workers of the %q queue should be positive", key)
+		}
+		workers[id] = n
+	}
+
+	return workers, nil
+}
+
+func (opts *QueueOptions) organizationLimits() (map[identifier.OrganizationID]int, error) {
+	limits := make(map[identifier.OrganizationID]int, len(opts.OrganizationLimits))
+	for hex, n := range opts.OrganizationLimits {
+		id, err := primitive.ObjectIDFromHex(hex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid organization ID %q in the organization limits", hex)
+		}
+		limits[identifier.OrganizationID(id)] = n
+	}
+
+	return limits, nil
+}
+
+const (
+	AdapterGit2Go = "git2go"
+	AdapterGoGit  = "gogit"
+)
+
+type adapterFactory func() engine.RepositoryAdapter
+
+// adapterFactories are the git adapters that are built in the binary by their names,
+// git2go registers itself only when the binary is built with cgo.
+var adapterFactories = map[string]func(*engine.DiffOptions) engine.RepositoryAdapter{
+	AdapterGoGit: func(opts *engine.DiffOptions) engine.RepositoryAdapter {
+		return gogit.NewAdapterWithOptions(opts)
+	},
+}
+
+// adapterName returns the configured adapter, or the default one if it is omitted.
+func (opts *EngineOptions) adapterName() string {
+	if opts.Adapter != "" {
+		return opts.Adapter
+	}
+	if _, ok := adapterFactories[AdapterGit2Go]; ok {
+		return AdapterGit2Go
+	}
+	return AdapterGoGit
+}
+
+// analysisParallelism returns the number of commits that the adapter can analyze concurrently.
+func analysisParallelism(opts *EngineOptions) int {
+	if opts.adapterName() == AdapterGoGit || opts.Parallelism < 1 {
+		return 1
+	}
+	return opts.Parallelism
+}
+
+func newAdapterFactory(opts *EngineOptions) (adapterFactory, error) {
+	name := opts.adapterName()
+	newAdapter, ok := adapterFactories[name]
+	if !ok {
+		if name == AdapterGit2Go {
+			return nil, fmt.Errorf("the %q git adapter is not available, the binary is built without cgo", name)
+		}
+		return nil, fmt.Errorf("unsupported git adapter: %q", name)
+	}
+
+	if err := opts.Diff.Validate(); err != nil {
+		return nil, err
+	}
+	if name == AdapterGoGit && opts.Diff.Copies {
+		return nil, fmt.Errorf("the %q git adapter does not detect the copied files", name)
+	}
+
+	return func() engine.RepositoryAdapter {
+		return newAdapter(&opts.Diff)
+	}, nil
+}
@@ -0,0 +1,31 @@
+package manage
+
+import (
+	"testing"
+
+	"github.com/repofuel/repofuel/ingest/pkg/engine"
+)
+
+func TestNewAdapterFactory(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    EngineOptions
+		wantErr bool
+	}{
+		{name: "default thresholds", opts: EngineOptions{Adapter: AdapterGoGit}},
+		{name: "valid thresholds", opts: EngineOptions{Adapter: AdapterGoGit, Diff: engine.DiffOptions{RenameThreshold: 100, CopyThreshold: 1}}},
+		{name: "rename threshold above 100", opts: EngineOptions{Adapter: AdapterGoGit, Diff: engine.DiffOptions{RenameThreshold: 101}}, wantErr: true},
+		{name: "negative copy threshold", opts: EngineOptions{Adapter: AdapterGoGit, Diff: engine.DiffOptions{CopyThreshold: -1}}, wantErr: true},
+		{name: "copies with gogit", opts: EngineOptions{Adapter: AdapterGoGit, Diff: engine.DiffOptions{Copies: true}}, wantErr: true},
+		{name: "unknown adapter", opts: EngineOptions{Adapter: "svn"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newAdapterFactory(&tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("newAdapterFactory() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -5,8 +5,6 @@ package manage
 
 import (
 	"sync"
-
-	"github.com/repofuel/repofuel/ingest/pkg/identifier"
 )
 
 const (
@@ -24,72 +22,60 @@ const (
 	QueueRecovered
 )
 
-var queues = map[QueueID]string{
-	QueueNewRepos:     "New Repositories Queue",
-	QueuePullRequests: "Fetch Requests Queue",
-	QueueNewCommits:   "New Commits Queue",
-	QueueRecovered:    "Recovered Repositories",
+type queueInfo struct {
+	name string
+	// key is the name of the queue in the options.
+	key string
+	// priority orders the jobs of the queues, the jobs of a higher priority
+	// are leased first, and the workers of each queue are limited so the
+	// lower priorities are not starved.
+	priority uint8
 }
 
-func (id QueueID) String() string {
-	return queues[id]
+var queues = map[QueueID]queueInfo{
+	QueuePullRequests: {name: "Pull Requests Queue", key: "pull_requests", priority: 3},
+	QueueNewCommits:   {name: "New Commits Queue", key: "new_commits", priority: 2},
+	QueueNewRepos:     {name: "New Repositories Queue", key: "new_repositories", priority: 1},
+	QueueRecovered:    {name: "Recovered Repositories", key: "recovered", priority: 0},
 }
 
-type Queue struct {
-	NumWorkers int
-	processing int
-	items      []identifier.RepositoryID
-
-	mu sync.Mutex
+func (id QueueID) String() string {
+	return queues[id].name
 }
 
-type QueueRegistry struct {
-	items map[QueueID]*Queue
-	mu    sync.Mutex
+func (id QueueID) Priority() uint8 {
+	return queues[id].priority
 }
 
-func newQueueRegistry() *QueueRegistry {
-	return &QueueRegistry{
-		items: make(map[QueueID]*Queue),
+func queueByKey(key string) (QueueID, bool) {
+	for id, q := range queues {
+		if q.key == key {
+			return id, true
+		}
 	}
+	return QueueNotSpecify, false
 }
 
-func (set *QueueRegistry) GetOrCreate(id QueueID) *Queue {
-	set.mu.Lock()
-	defer set.mu.Unlock()
+// Queue limits the concurrent jobs of its kind on this node, the waiting jobs
+// are kept in the shared job queue.
+type Queue struct {
+	NumWorkers int
+	processing int
 
-	item, ok := set.items[id]
-	if !ok {
-		item = &Queue{NumWorkers: DefaultQueueWorkersCount}
-		set.items[id] = item
-	}
-	return item
+	mu sync.Mutex
 }
 
-//var ErrQueueIsEmpty = errors.New("cannot pup from an empty queue")
-
-func (q *Queue) pup() identifier.RepositoryID {
+func (q *Queue) NumProcessing() int {
 	q.mu.Lock()
 	defer q.mu.Unlock()
 
-	if len(q.items) == 0 {
-		return identifier.RepositoryID{}
-	}
-
-	i := q.items[0]
-	q.items = q.items[1:]
-	return i
-}
-
-func (q *Queue) NumWaiting() int {
-	return len(q.items)
-}
-
-func (q *Queue) NumProcessing() int {
 	return q.processing
 }
 
 func (q *Queue) HasFreeWorkers() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
 	return q.NumWorkers > q.processing
 }
 
@@ -106,25 +92,3 @@ func (q *Queue) processDone() {
 
 	q.processing--
 }
-
-func (q *Queue) push(i identifier.RepositoryID) {
-	q.mu.Lock()
-	defer q.mu.Unlock()
-
-	q.items = append(q.items, i)
-}
-
-func (q *Queue) removeRepository(id identifier.RepositoryID) {
-	q.mu.Lock()
-	defer q.mu.Unlock()
-
-	for i := range q.items {
-		if q.items[i] == id {
-			// remove and free up the memory
-			if i < len(q.items)-1 {
-				copy(q.items[i:], q.items[i+1:])
-			}
-			q.items = q.items[:len(q.items)-1]
-		}
-	}
-}
@@ -0,0 +1,176 @@
+package manage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/repofuel/repofuel/ingest/pkg/engine"
+	"github.com/repofuel/repofuel/pkg/repofuel"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// RetryPolicy describes how a failed task is retried, the waits between the
+// attempts grow exponentially from the initial backoff up to the max backoff.
+type RetryPolicy struct {
+	// MaxAttempts is the number of the attempts including the first one.
+	MaxAttempts    int           `yaml:"max_attempts"`
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"`
+	Multiplier     float64       `yaml:"multiplier"`
+	// Jitter is the fraction of the backoff that is randomized, so the tasks
+	// that failed together are not retried together.
+	Jitter float64 `yaml:"jitter"`
+}
+
+var (
+	// networkRetry is the policy of the tasks that clone or fetch the
+	// repositories from the remotes.
+	networkRetry = &RetryPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: 15 * time.Second,
+		MaxBackoff:     5 * time.Minute,
+		Multiplier:     2,
+		Jitter:         .2,
+	}
+
+	// serviceRetry is the policy of the tasks that call the other services,
+	// they could be unavailable for longer while they are redeployed.
+	serviceRetry = &RetryPolicy{
+		MaxAttempts:    6,
+		InitialBackoff: 30 * time.Second,
+		MaxBackoff:     10 * time.Minute,
+		Multiplier:     2,
+		Jitter:         .2,
+	}
+)
+
+// withDefaults returns a copy of the policy where its zero fields are taken
+// from the defaults, or from the network policy if the defaults are nil.
+func (r *RetryPolicy) withDefaults(defaults *RetryPolicy) *RetryPolicy {
+	if defaults == nil {
+		defaults = networkRetry
+	}
+
+	policy := *r
+	if policy.MaxAttempts == 0 {
+		policy.MaxAttempts = defaults.MaxAttempts
+	}
+	if policy.InitialBackoff == 0 {
+		policy.InitialBackoff = defaults.InitialBackoff
+	}
+	if policy.MaxBackoff == 0 {
+		policy.MaxBackoff = defaults.MaxBackoff
+	}
+	if policy.Multiplier == 0 {
+		policy.Multiplier = defaults.Multiplier
+	}
+	if policy.Jitter == 0 {
+		policy.Jitter = defaults.Jitter
+	}
+
+	return &policy
+}
+
+func (r *RetryPolicy) Validate() error {
+	switch {
+	case r.MaxAttempts < 1:
+		return errors.New("max_attempts should be positive")
+	case r.InitialBackoff < 0 || r.MaxBackoff < 0:
+		return errors.New("the backoffs should not be negative")
+	case r.Multiplier < 1:
+		return errors.New("multiplier should be at least 1")
+	case r.Jitter < 0 || r.Jitter > 1:
+		return errors.New("jitter should be between 0 and 1")
+	}
+	return nil
+}
+
+// Backoff returns the wait before the next attempt, where attempt is the
+// number of the failed attempts.
+func (r *RetryPolicy) Backoff(attempt int) time.Duration {
+	d := float64(r.InitialBackoff) * math.Pow(r.Multiplier, float64(attempt-1))
+	if max := float64(r.MaxBackoff); r.MaxBackoff > 0 && d > max {
+		d = max
+	}
+
+	if r.Jitter > 0 {
+		d += d * r.Jitter * (2*rand.Float64() - 1)
+	}
+
+	return time.Duration(d)
+}
+
+// IsRetryable reports whether the error is transient, such as the network
+// errors and the unavailable services. The unknown errors are permanent.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var resErr *repofuel.ErrorResponse
+	if errors.As(err, &resErr) {
+		code := resErr.Response.StatusCode
+		return code >= http.StatusInternalServerError || code == http.StatusTooManyRequests
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var remoteErr *engine.RemoteError
+	if errors.As(err, &remoteErr) {
+		return true
+	}
+
+	return errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		mongo.IsNetworkError(err) ||
+		mongo.IsTimeout(err)
+}
+
+// withRetry wraps the task to retry it by the policy if it fails with a
+// retryable error. The failed attempts are logged in the job status log, and
+// the task fails after its last attempt.
+func withRetry(name string, policy *RetryPolicy, task Task) Task {
+	return func(ctx context.Context, p *process) error {
+		for attempt := 1; ; attempt++ {
+			err := task(ctx, p)
+			if err == nil || ctx.Err() != nil || !IsRetryable(err) {
+				return err
+			}
+
+			if attempt >= policy.MaxAttempts {
+				return fmt.Errorf("%s failed after %d attempts: %w", name, attempt, err)
+			}
+
+			wait := policy.Backoff(attempt)
+			p.logger.Warn().
+				Err(err).
+				Str("task", name).
+				Int("attempt", attempt).
+				Dur("backoff", wait).
+				Msg("retry a failed task")
+
+			err = p.mgr.srv.Job.ReportAttempt(ctx, p.JobID, name, attempt, err)
+			if err != nil {
+				return err
+			}
+
+			timer := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+	}
+}
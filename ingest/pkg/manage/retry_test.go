@@ -0,0 +1,151 @@
+package manage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/repofuel/repofuel/ingest/internal/entity"
+	"github.com/repofuel/repofuel/ingest/pkg/engine"
+	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+	"github.com/repofuel/repofuel/ingest/pkg/jobinfo"
+	"github.com/repofuel/repofuel/pkg/repofuel"
+	"github.com/rs/zerolog"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := &RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: time.Second,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+	}
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}
+	for i, d := range want {
+		if got := policy.Backoff(i + 1); got != d {
+			t.Errorf("attempt %d: expected %v backoff, got %v", i+1, d, got)
+		}
+	}
+
+	policy.Jitter = .5
+	for i := 0; i < 100; i++ {
+		if got := policy.Backoff(1); got < time.Second/2 || got > time.Second*3/2 {
+			t.Fatalf("expected the jitter within the half of the backoff, got %v", got)
+		}
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	serverErr := &repofuel.ErrorResponse{Response: &http.Response{StatusCode: http.StatusServiceUnavailable}}
+	clientErr := &repofuel.ErrorResponse{Response: &http.Response{StatusCode: http.StatusBadRequest}}
+	netErr := &net.OpError{Op: "dial", Err: errors.New("connection refused")}
+	remoteErr := &engine.RemoteError{Err: errors.New("failed to resolve address")}
+
+	cases := map[error]bool{
+		nil:                                   false,
+		errors.New("unknown"):                 false,
+		context.Canceled:                      false,
+		io.ErrUnexpectedEOF:                   true,
+		netErr:                                true,
+		fmt.Errorf("fetch: %w", netErr):       true,
+		remoteErr:                             true,
+		fmt.Errorf("clone: %w", remoteErr):    true,
+		serverErr:                             true,
+		clientErr:                             false,
+		fmt.Errorf("predict: %w", serverErr):  true,
+		fmt.Errorf("predict: %w", clientErr):  false,
+		fmt.Errorf("%w", context.Canceled):    false,
+		fmt.Errorf("%w", io.ErrUnexpectedEOF): true,
+	}
+	for err, want := range cases {
+		if got := IsRetryable(err); got != want {
+			t.Errorf("%v: expected retryable %v, got %v", err, want, got)
+		}
+	}
+}
+
+type attemptsRecorder struct {
+	entity.JobDataSource
+	attempts []int
+}
+
+func (r *attemptsRecorder) ReportAttempt(_ context.Context, _ identifier.JobID, _ string, attempt int, _ error) error {
+	r.attempts = append(r.attempts, attempt)
+	return nil
+}
+
+func newRetryProcess(jobs entity.JobDataSource) *process {
+	return &process{
+		JobInfo: &jobinfo.JobInfo{},
+		logger:  zerolog.Nop(),
+		mgr:     &Manager{srv: ManagerServices{Job: jobs}},
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	ctx := context.Background()
+	policy := &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, Multiplier: 2}
+
+	jobs := &attemptsRecorder{}
+	calls := 0
+	task := withRetry("task", policy, func(context.Context, *process) error {
+		calls++
+		if calls < 3 {
+			return io.ErrUnexpectedEOF
+		}
+		return nil
+	})
+	if err := task(ctx, newRetryProcess(jobs)); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 || len(jobs.attempts) != 2 {
+		t.Errorf("expected the task to succeed on the third attempt, got %d calls and %v attempts", calls, jobs.attempts)
+	}
+
+	jobs = &attemptsRecorder{}
+	task = withRetry("task", policy, func(context.Context, *process) error {
+		return io.ErrUnexpectedEOF
+	})
+	err := task(ctx, newRetryProcess(jobs))
+	if !errors.Is(err, io.ErrUnexpectedEOF) || len(jobs.attempts) != 2 {
+		t.Errorf("expected the task to fail after the retries, got %v and %v attempts", err, jobs.attempts)
+	}
+
+	jobs = &attemptsRecorder{}
+	calls = 0
+	task = withRetry("task", policy, func(context.Context, *process) error {
+		calls++
+		return errors.New("invalid repository")
+	})
+	if err = task(ctx, newRetryProcess(jobs)); err == nil || calls != 1 {
+		t.Errorf("expected the permanent error to not be retried, got %v after %d calls", err, calls)
+	}
+}
+
+func TestWithRetry_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := &RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Hour}
+
+	task := withRetry("task", policy, func(context.Context, *process) error {
+		cancel()
+		return io.ErrUnexpectedEOF
+	})
+	if err := task(ctx, newRetryProcess(&attemptsRecorder{})); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected the canceled task to not be retried, got %v", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	task = withRetry("task", policy, func(context.Context, *process) error {
+		time.AfterFunc(time.Millisecond, cancel)
+		return io.ErrUnexpectedEOF
+	})
+	if err := task(ctx, newRetryProcess(&attemptsRecorder{})); err != context.Canceled {
+		t.Errorf("expected the backoff to be interrupted, got %v", err)
+	}
+}
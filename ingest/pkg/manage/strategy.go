@@ -2,9 +2,17 @@ package manage
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
 	"sync"
+
+	"github.com/repofuel/repofuel/ingest/pkg/invoke"
 )
 
+// Task is a step of a pipeline, it is registered by its name in the task
+// registry to be used in the configured pipelines.
 type Task func(context.Context, *process) error
 
 type Pipeline [][]Task
@@ -15,70 +23,257 @@ const (
 	NotifyPlatform
 )
 
-var (
-	pipelineUpdateRepository        Pipeline
-	pipelineUpdatePullRequest       Pipeline
-	pipelineNewRepository           Pipeline
-	pipelineNewPublicRepository     Pipeline
-	pipelineProcessPushCheck        Pipeline
-	pipelineProcessPullRequestCheck Pipeline
+// The kinds of the pipelines, the actions of a kind run the same pipeline.
+const (
+	PipelineUpdateRepository  = "update_repository"
+	PipelinePullRequest       = "pull_request"
+	PipelineNewRepository     = "new_repository"
+	PipelineMonitorRepository = "monitor_repository"
+	PipelineSubsystemsUpdate  = "subsystems_update"
+	PipelinePushCheck         = "push_check"
+	PipelinePullRequestCheck  = "pull_request_check"
 )
 
-func init() {
-	pipelineUpdateRepository = Pipeline{
-		{StatusInProgress},
-		{PrepareGitRepository, RecoverFromLastPredicting},
-		{ingestLocalBranchesTask},
-		{analyze},
-		{Predict},
-		{StatusIsReady},
+func pipelineKind(a invoke.Action) string {
+	switch a {
+	case invoke.ActionRepositoryAdded:
+		return PipelineNewRepository
+
+	case invoke.ActionMonitorRepository:
+		return PipelineMonitorRepository
+
+	case invoke.ActionPullRequestAdded, invoke.ActionPullRequestUpdate:
+		return PipelinePullRequest
+
+	case invoke.ActionPushCheck:
+		return PipelinePushCheck
+
+	case invoke.ActionPullRequestCheck:
+		return PipelinePullRequestCheck
+
+	case invoke.ActionSubsystemsUpdate:
+		return PipelineSubsystemsUpdate
+
+	default:
+		return PipelineUpdateRepository
 	}
+}
+
+// taskSpec is a task that can be named in the pipelines.
+type taskSpec struct {
+	task Task
+	// build creates the task from its parameters, it is set instead of the
+	// task for the tasks that need parameters.
+	build func(params map[string]string) (Task, error)
+	// retry is the default retry policy of the task, it is not retried if it
+	// is nil.
+	retry *RetryPolicy
+}
+
+var taskRegistry = map[string]*taskSpec{
+	"status_in_progress":               {task: StatusInProgress},
+	"status_is_ready":                  {task: StatusIsReady},
+	"repo_status_in_progress":          {task: RepoStatusInProgress},
+	"repo_status_is_ready":             {task: RepoStatusIsReady},
+	"prepare_git_repository":           {task: PrepareGitRepository, retry: networkRetry},
+	"prepare_pull_request":             {task: PreparePullRequest, retry: networkRetry},
+	"recover_from_last_predicting":     {task: RecoverFromLastPredicting},
+	"update_collaborators":             {task: UpdateCollaborators},
+	"add_pull_requests":                {task: AddPullRequests},
+	"ingest_local_branches":            {task: ingestLocalBranchesTask},
+	"ingest_pull_request":              {task: ingestPullRequestTask},
+	"analyze":                          {task: analyze},
+	"finalize_pull_request_analysis":   {task: FinalizePullRequestAnalysis},
+	"recompute_subsystems":             {task: RecomputeSubsystems},
+	"predict":                          {task: Predict, retry: serviceRetry},
+	"update_releases":                  {task: UpdateReleases},
+	"process_check_run":                {task: ProcessCheckRun},
+	"report_push_check_result":         {task: ReportPushCheckResult},
+	"report_pull_request_check_result": {task: ReportPullRequestCheckResult},
+	"webhook":                          {build: newWebhookTask, retry: serviceRetry},
+}
+
+// PipelineConfig is the stages of a pipeline, the tasks of a stage run
+// concurrently and the stages run in order.
+type PipelineConfig [][]TaskConfig
 
-	pipelineUpdatePullRequest = Pipeline{
-		{StatusInProgress},
-		{PrepareGitRepository},
-		{PreparePullRequest},
-		{ingestPullRequestTask},
-		{analyze},
-		{FinalizePullRequestAnalysis},
-		{Predict},
-		{StatusIsReady},
+// TaskConfig is a task in a pipeline, it is written by its name only if it
+// does not have parameters.
+type TaskConfig struct {
+	Name   string            `yaml:"task"`
+	Params map[string]string `yaml:"params"`
+	// Retry overrides the fields of the default retry policy of the task.
+	Retry *RetryPolicy `yaml:"retry"`
+}
+
+func (c *TaskConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&c.Name); err == nil {
+		return nil
 	}
 
-	pipelineNewRepository = Pipeline{
-		{StatusInProgress},
-		{PrepareGitRepository, UpdateCollaborators},
-		{ingestLocalBranchesTask, AddPullRequests},
-		{analyze},
-		{Predict},
-		{StatusIsReady},
+	type plain TaskConfig
+	return unmarshal((*plain)(c))
+}
+
+func stage(names ...string) []TaskConfig {
+	s := make([]TaskConfig, len(names))
+	for i, name := range names {
+		s[i].Name = name
 	}
+	return s
+}
+
+// DefaultPipelines are the pipelines of the kinds that are not configured.
+var DefaultPipelines = map[string]PipelineConfig{
+	PipelineUpdateRepository: {
+		stage("status_in_progress"),
+		stage("prepare_git_repository", "recover_from_last_predicting"),
+		stage("ingest_local_branches"),
+		stage("analyze"),
+		stage("predict"),
+		stage("update_releases"),
+		stage("status_is_ready"),
+	},
+	PipelinePullRequest: {
+		stage("status_in_progress"),
+		stage("prepare_git_repository"),
+		stage("prepare_pull_request"),
+		stage("ingest_pull_request"),
+		stage("analyze"),
+		stage("finalize_pull_request_analysis"),
+		stage("predict"),
+		stage("status_is_ready"),
+	},
+	PipelineNewRepository: {
+		stage("status_in_progress"),
+		stage("prepare_git_repository", "update_collaborators"),
+		stage("ingest_local_branches", "add_pull_requests"),
+		stage("analyze"),
+		stage("predict"),
+		stage("update_releases"),
+		stage("status_is_ready"),
+	},
+	PipelineMonitorRepository: {
+		stage("status_in_progress"),
+		stage("prepare_git_repository"),
+		stage("ingest_local_branches", "add_pull_requests"),
+		stage("analyze"),
+		stage("predict"),
+		stage("update_releases"),
+		stage("status_is_ready"),
+	},
+	PipelineSubsystemsUpdate: {
+		stage("status_in_progress"),
+		stage("prepare_git_repository"),
+		stage("ingest_local_branches"),
+		stage("analyze"),
+		stage("recompute_subsystems"),
+		stage("predict"),
+		stage("status_is_ready"),
+	},
+	PipelinePushCheck: {
+		stage("process_check_run"),
+		stage("report_push_check_result"),
+	},
+	PipelinePullRequestCheck: {
+		stage("repo_status_in_progress"),
+		stage("process_check_run"),
+		stage("report_pull_request_check_result"),
+		stage("repo_status_is_ready"),
+	},
+}
 
-	pipelineNewPublicRepository = Pipeline{
-		{StatusInProgress},
-		{PrepareGitRepository},
-		{ingestLocalBranchesTask, AddPullRequests},
-		{analyze},
-		{Predict},
-		{StatusIsReady},
+// buildPipelines creates the pipelines of all the kinds, where the configured
+// pipelines replace the defaults. It returns an error that lists all the
+// problems of the configured pipelines, such as the unknown tasks.
+func buildPipelines(configured map[string]PipelineConfig) (map[string]Pipeline, error) {
+	var problems []string
+	for kind := range configured {
+		if _, ok := DefaultPipelines[kind]; !ok {
+			problems = append(problems, fmt.Sprintf("unknown pipeline %q", kind))
+		}
 	}
 
-	pipelineProcessPushCheck = Pipeline{
-		{ProcessCheckRun},
-		{ReportPushCheckResult},
+	pipelines := make(map[string]Pipeline, len(DefaultPipelines))
+	for kind, cfg := range DefaultPipelines {
+		if c, ok := configured[kind]; ok {
+			cfg = c
+		}
+
+		pip, errs := buildPipeline(cfg)
+		for _, err := range errs {
+			problems = append(problems, fmt.Sprintf("%s: %v", kind, err))
+		}
+		pipelines[kind] = pip
 	}
 
-	pipelineProcessPullRequestCheck = Pipeline{
-		{RepoStatusInProgress},
-		{ProcessCheckRun},
-		{ReportPullRequestCheckResult},
-		{RepoStatusIsReady},
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, fmt.Errorf("invalid pipelines: %s", strings.Join(problems, "; "))
 	}
+	return pipelines, nil
+}
+
+func buildPipeline(cfg PipelineConfig) (Pipeline, []error) {
+	if len(cfg) == 0 {
+		return nil, []error{errors.New("should have at least one stage")}
+	}
+
+	var errs []error
+	pip := make(Pipeline, len(cfg))
+	for i, stageCfg := range cfg {
+		if len(stageCfg) == 0 {
+			errs = append(errs, fmt.Errorf("stage %d should have at least one task", i+1))
+			continue
+		}
+
+		pip[i] = make([]Task, len(stageCfg))
+		for j := range stageCfg {
+			task, err := buildTask(&stageCfg[j])
+			if err != nil {
+				errs = append(errs, fmt.Errorf("stage %d: %w", i+1, err))
+				continue
+			}
+			pip[i][j] = task
+		}
+	}
+
+	return pip, errs
+}
+
+func buildTask(cfg *TaskConfig) (Task, error) {
+	spec, ok := taskRegistry[cfg.Name]
+	if !ok {
+		return nil, fmt.Errorf("unknown task %q", cfg.Name)
+	}
+
+	task := spec.task
+	if spec.build != nil {
+		var err error
+		task, err = spec.build(cfg.Params)
+		if err != nil {
+			return nil, fmt.Errorf("task %q: %w", cfg.Name, err)
+		}
+	} else if len(cfg.Params) > 0 {
+		return nil, fmt.Errorf("task %q does not have parameters", cfg.Name)
+	}
+
+	policy := spec.retry
+	if cfg.Retry != nil {
+		policy = cfg.Retry.withDefaults(spec.retry)
+		if err := policy.Validate(); err != nil {
+			return nil, fmt.Errorf("task %q: retry: %w", cfg.Name, err)
+		}
+	}
+
+	if policy == nil || policy.MaxAttempts <= 1 {
+		return task, nil
+	}
+	return withRetry(cfg.Name, policy, task), nil
 }
 
 func (p *process) runPipeline(ctx context.Context, pip Pipeline) error {
 	var wg sync.WaitGroup
-	var err error
 
 	p.tracker = p.mgr.observables.GetOrCreate(p.ObservableNodeID())
 
@@ -90,34 +285,40 @@ func (p *process) runPipeline(ctx context.Context, pip Pipeline) error {
 		}
 
 		if numTasks == 1 {
-			err = stage[0](ctx, p)
-			if err != nil {
+			if err := stage[0](ctx, p); err != nil {
 				return err
 			}
 			continue
 		}
 
+		// the first failed task cancels the others of the stage
+		var once sync.Once
+		var stageErr error
+		fail := func(err error) {
+			once.Do(func() {
+				p.cancel()
+				stageErr = err
+			})
+		}
+
 		wg.Add(numTasks)
 		for i := range stage {
 			go func(task Task) {
-				var localErr error
+				defer wg.Done()
 				defer func() {
 					if r := recover(); r != nil {
-						localErr = errorFromRecovery(r)
+						fail(errorFromRecovery(r))
 					}
 				}()
 
-				localErr = task(ctx, p)
-				if localErr != nil && err == nil {
-					p.cancel()
-					err = localErr
+				if err := task(ctx, p); err != nil {
+					fail(err)
 				}
-				wg.Done()
 			}(stage[i])
 		}
 		wg.Wait()
-		if err != nil {
-			return err
+		if stageErr != nil {
+			return stageErr
 		}
 
 		select {
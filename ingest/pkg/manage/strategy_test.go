@@ -0,0 +1,102 @@
+package manage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/repofuel/repofuel/ingest/pkg/invoke"
+	"gopkg.in/yaml.v2"
+)
+
+func TestBuildPipelines_Defaults(t *testing.T) {
+	pipelines, err := buildPipelines(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for kind, cfg := range DefaultPipelines {
+		if len(pipelines[kind]) != len(cfg) {
+			t.Errorf("%s: expected %d stages, got %d", kind, len(cfg), len(pipelines[kind]))
+		}
+	}
+
+	for _, a := range []invoke.Action{invoke.ActionRepositoryAdded, invoke.ActionPullRequestCheck, invoke.ActionRepositoryPush} {
+		if _, ok := pipelines[pipelineKind(a)]; !ok {
+			t.Errorf("missing the pipeline of %v", a)
+		}
+	}
+}
+
+func TestBuildPipelines_Configured(t *testing.T) {
+	content := `
+pipelines:
+  update_repository:
+    - [status_in_progress]
+    - [prepare_git_repository]
+    - [ingest_local_branches]
+    - [analyze]
+    - - task: predict
+        retry: {max_attempts: 2, initial_backoff: 1m}
+    - [update_releases]
+    - [status_is_ready]
+    - - task: webhook
+        params: {url: "https://example.com/hooks/repofuel", timeout: 10s}
+`
+	var opts Options
+	err := yaml.UnmarshalStrict([]byte(content), &opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	predict := opts.Pipelines[PipelineUpdateRepository][4][0]
+	if predict.Name != "predict" || predict.Retry == nil || predict.Retry.MaxAttempts != 2 {
+		t.Errorf("unexpected task config %+v", predict)
+	}
+
+	pipelines, err := buildPipelines(opts.Pipelines)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(pipelines[PipelineUpdateRepository]); n != 8 {
+		t.Errorf("expected the configured pipeline with 8 stages, got %d", n)
+	}
+	if n := len(pipelines[PipelinePullRequest]); n != len(DefaultPipelines[PipelinePullRequest]) {
+		t.Errorf("expected the default pull request pipeline, got %d stages", n)
+	}
+}
+
+func TestBuildPipelines_Invalid(t *testing.T) {
+	configured := map[string]PipelineConfig{
+		"nightly": {stage("analyze")},
+		PipelineUpdateRepository: {
+			stage("status_in_progress", "notify_slack"),
+			{},
+			{{Name: "webhook"}},
+			{{Name: "webhook", Params: map[string]string{"url": "https://example.com", "timeout": "-1s"}}},
+			{{Name: "analyze", Params: map[string]string{"depth": "1"}}},
+			{{Name: "predict", Retry: &RetryPolicy{Multiplier: .5}}},
+		},
+		PipelinePushCheck: {},
+	}
+
+	_, err := buildPipelines(configured)
+	if err == nil {
+		t.Fatal("expected the invalid pipelines to be rejected")
+	}
+
+	for _, problem := range []string{
+		`unknown pipeline "nightly"`,
+		`unknown task "notify_slack"`,
+		"stage 2 should have at least one task",
+		"missing url parameter",
+		`invalid timeout "-1s"`,
+		`task "analyze" does not have parameters`,
+		"multiplier should be at least 1",
+		"push_check: should have at least one stage",
+	} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("expected %q in the error, got %v", problem, err)
+		}
+	}
+}
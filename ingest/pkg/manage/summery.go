@@ -7,13 +7,18 @@ import (
 	"strings"
 
 	"github.com/repofuel/repofuel/ingest/internal/entity"
+	"github.com/repofuel/repofuel/ingest/pkg/repoconfig"
 )
 
 type FailureSummary struct{}
 
 type PushSummary struct {
-	maxRisk *float32
-	Commits []*entity.Commit
+	maxRisk    *float32
+	thresholds repoconfig.Thresholds
+	Commits    []*entity.Commit
+	// ConfigProblems are the problems of the repository config file, they are
+	// listed above the commits.
+	ConfigProblems []string
 }
 
 type PullRequestSummaryItem struct {
@@ -22,16 +27,20 @@ type PullRequestSummaryItem struct {
 }
 
 type PullRequestSummary struct {
-	maxRisk *float32
-	items   []*PullRequestSummaryItem
+	maxRisk    *float32
+	thresholds repoconfig.Thresholds
+	items      []*PullRequestSummaryItem
+	// ConfigProblems are the problems of the repository config file, they are
+	// listed above the pull requests.
+	ConfigProblems []string
 }
 
 func (s *PullRequestSummary) Title() string {
-	return title(s.MaxRisk())
+	return title(s.MaxRisk(), s.thresholds)
 }
 
 func (s *PullRequestSummary) Summary() string {
-	return summary(s.MaxRisk())
+	return summary(s.MaxRisk(), s.thresholds)
 }
 
 func (s *PullRequestSummary) DetailsText(providerName, providerUrl, ownerName, repoName string) string {
@@ -45,6 +54,7 @@ func (s *PullRequestSummary) DetailsText(providerName, providerUrl, ownerName, r
 	}
 
 	var sb strings.Builder
+	writeConfigProblems(&sb, s.ConfigProblems)
 	for _, item := range s.items {
 		d.writPullRequestHeader(&sb, item.Pull)
 		d.writeCommitsTable(&sb, item.Commits)
@@ -69,9 +79,10 @@ func (e FailureSummary) DetailsText(providerName, providerUrl, ownerName, repoNa
 	return e.Summary()
 }
 
-func NewPushSummery(commits []*entity.Commit) *PushSummary {
+func NewPushSummery(commits []*entity.Commit, thresholds repoconfig.Thresholds) *PushSummary {
 	return &PushSummary{
-		Commits: commits,
+		Commits:    commits,
+		thresholds: thresholds,
 	}
 }
 
@@ -93,14 +104,14 @@ func (c *PushSummary) MaxRisk() float32 {
 }
 
 func (c *PushSummary) Title() string {
-	return title(c.MaxRisk())
+	return title(c.MaxRisk(), c.thresholds)
 }
 
-func title(risk float32) string {
+func title(risk float32, t repoconfig.Thresholds) string {
 	switch {
-	case risk > .80:
+	case risk > t.High:
 		return "High bug potential"
-	case risk > .50:
+	case risk > t.Moderate:
 		return "Moderate bug potential"
 	default:
 		return "Low bug potential"
@@ -108,14 +119,14 @@ func title(risk float32) string {
 }
 
 func (c *PushSummary) Summary() string {
-	return summary(c.MaxRisk())
+	return summary(c.MaxRisk(), c.thresholds)
 }
 
-func summary(risk float32) string {
+func summary(risk float32, t repoconfig.Thresholds) string {
 	switch {
-	case risk > .80:
+	case risk > t.High:
 		return "The likelihood of this modification introducing a bug is high.\n>*For more details click on the score of the individual commits below.*"
-	case risk > .50:
+	case risk > t.Moderate:
 		return "The likelihood of this modification introducing a bug is moderate.\n>*For more details click on the score of the individual commits below.*"
 	default:
 		return "The likelihood of this modification introducing a bug is low.\n>*For more details click on the score of the individual commits below.*"
@@ -133,10 +144,24 @@ func (c *PushSummary) DetailsText(providerName, providerUrl, ownerName, repoName
 	}
 
 	var sb strings.Builder
+	writeConfigProblems(&sb, c.ConfigProblems)
 	data.writeCommitsTable(&sb, c.Commits)
 	return sb.String()
 }
 
+func writeConfigProblems(sb *strings.Builder, problems []string) {
+	if len(problems) == 0 {
+		return
+	}
+
+	sb.WriteString("## Configuration problems\n>The `" + repoconfig.FileName + "` file is ignored because it is invalid.\n\n")
+	for _, problem := range problems {
+		sb.WriteString("- ")
+		sb.WriteString(problem)
+		sb.WriteString("\n")
+	}
+}
+
 type templateData struct {
 	RepofuelDomain, OwnerName, RepoName, ProviderName, ProviderURL string
 }
@@ -192,9 +217,10 @@ func (d *templateData) writeCommitScore(w io.Writer, commit *entity.Commit) {
 	)
 }
 
-func NewPullRequestSummary(count int) *PullRequestSummary {
+func NewPullRequestSummary(count int, thresholds repoconfig.Thresholds) *PullRequestSummary {
 	return &PullRequestSummary{
-		items: make([]*PullRequestSummaryItem, 0, count),
+		items:      make([]*PullRequestSummaryItem, 0, count),
+		thresholds: thresholds,
 	}
 }
 
@@ -1,11 +1,19 @@
 package manage
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/repofuel/repofuel/ingest/pkg/invoke"
 	"github.com/repofuel/repofuel/ingest/pkg/jobinfo"
 	"github.com/repofuel/repofuel/pkg/common"
+	"github.com/repofuel/repofuel/pkg/repofuel"
 )
 
 //p.Action == invoke.ActionRepositoryAdded || p.Action == invoke.ActionPullRequestRefreshing
@@ -29,3 +37,85 @@ func AddPullRequests(ctx context.Context, p *process) error {
 		})
 	})
 }
+
+type webhookPayload struct {
+	Repository   string `json:"repository"`
+	Name         string `json:"name"`
+	URL          string `json:"url,omitempty"`
+	Job          string `json:"job"`
+	Action       string `json:"action"`
+	Status       string `json:"status"`
+	CommitsCount int    `json:"commits_count"`
+	BuggyCount   int    `json:"buggy_count"`
+}
+
+// defaultWebhookTimeout is the timeout of the webhook requests if the timeout
+// parameter is not set.
+const defaultWebhookTimeout = 30 * time.Second
+
+// newWebhookTask creates a task that posts the repository summary to the url
+// parameter, such as to notify or export it after the analysis. The optional
+// timeout parameter limits the time of the request, such as "10s".
+func newWebhookTask(params map[string]string) (Task, error) {
+	target, ok := params["url"]
+	if !ok {
+		return nil, errors.New("missing url parameter")
+	}
+
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid url %q", target)
+	}
+
+	timeout := defaultWebhookTimeout
+	for name, value := range params {
+		switch name {
+		case "url":
+		case "timeout":
+			timeout, err = time.ParseDuration(value)
+			if err != nil || timeout <= 0 {
+				return nil, fmt.Errorf("invalid timeout %q", value)
+			}
+		default:
+			return nil, fmt.Errorf("unknown parameter %q", name)
+		}
+	}
+
+	client := &http.Client{Timeout: timeout}
+
+	return func(ctx context.Context, p *process) error {
+		// not cached to have the counts and the status after the previous stages
+		repo, err := p.mgr.srv.Repo.FindByID(ctx, p.RepoID)
+		if err != nil {
+			return err
+		}
+
+		body, err := json.Marshal(&webhookPayload{
+			Repository:   repo.ID.Hex(),
+			Name:         repo.Source.RepoName,
+			URL:          repo.Source.HTMLURL,
+			Job:          p.JobID.Hex(),
+			Action:       p.Action.String(),
+			Status:       repo.Status.String(),
+			CommitsCount: repo.CommitsCount,
+			BuggyCount:   repo.BuggyCount,
+		})
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		res, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+
+		return repofuel.CheckResponse(res)
+	}, nil
+}
@@ -0,0 +1,149 @@
+// Package repoconfig parses the `.repofuel.yml` file, which keeps the settings
+// of a repository in its default branch. The settings of the file override the
+// stored settings of the repository, section by section.
+package repoconfig
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/repofuel/repofuel/ingest/pkg/branchfilter"
+	"github.com/repofuel/repofuel/ingest/pkg/classify"
+	"github.com/repofuel/repofuel/ingest/pkg/identity"
+	"github.com/repofuel/repofuel/ingest/pkg/subsystem"
+	"gopkg.in/yaml.v2"
+)
+
+// FileName is the path of the file in the repository.
+const FileName = ".repofuel.yml"
+
+// Version is the latest version of the file schema.
+const Version = 1
+
+// Config is the content of the file. The sections that are missing from the
+// file keep the stored settings of the repository.
+type Config struct {
+	// Version is the schema of the file, it is the latest if it is missing.
+	Version    int                  `json:"version"               bson:"version"               yaml:"version"`
+	Checks     *Checks              `json:"checks,omitempty"      bson:"checks,omitempty"      yaml:"checks"`
+	Paths      *classify.PathConfig `json:"paths,omitempty"       bson:"paths,omitempty"       yaml:"paths"`
+	Subsystems *subsystem.Config    `json:"subsystems,omitempty"  bson:"subsystems,omitempty"  yaml:"subsystems"`
+	Bots       *identity.BotConfig  `json:"bots,omitempty"        bson:"bots,omitempty"        yaml:"bots"`
+	Branches   *branchfilter.Config `json:"branches,omitempty"    bson:"branches,omitempty"    yaml:"branches"`
+}
+
+// Checks is the settings of the check runs.
+type Checks struct {
+	// Enable turns the check runs on or off, the stored setting is kept if it
+	// is missing.
+	Enable *bool `json:"enable,omitempty"  bson:"enable,omitempty"  yaml:"enable"`
+	// HighRisk and ModerateRisk are the bug potentials above which the check
+	// runs are titled as high or moderate risk, zeros keep the defaults.
+	HighRisk     float32 `json:"high_risk,omitempty"      bson:"high_risk,omitempty"      yaml:"high_risk"`
+	ModerateRisk float32 `json:"moderate_risk,omitempty"  bson:"moderate_risk,omitempty"  yaml:"moderate_risk"`
+}
+
+// Thresholds are the bug potentials above which a change is high or moderate
+// risk.
+type Thresholds struct {
+	High, Moderate float32
+}
+
+var DefaultThresholds = Thresholds{High: .80, Moderate: .50}
+
+// Thresholds returns the thresholds of the checks, the missing ones are the
+// defaults. It is safe to call on a nil checks.
+func (c *Checks) Thresholds() Thresholds {
+	t := DefaultThresholds
+	if c == nil {
+		return t
+	}
+
+	if c.HighRisk != 0 {
+		t.High = c.HighRisk
+	}
+	if c.ModerateRisk != 0 {
+		t.Moderate = c.ModerateRisk
+	}
+	return t
+}
+
+// Error lists all the problems of an invalid file.
+type Error struct {
+	Problems []string
+}
+
+func (e *Error) Error() string {
+	return "invalid " + FileName + ": " + strings.Join(e.Problems, "; ")
+}
+
+// Parse decodes and validates the content of the file. The unknown keys are
+// rejected, and the returned error is an *Error if the file is invalid.
+func Parse(content []byte) (*Config, error) {
+	var cfg Config
+	err := yaml.UnmarshalStrict(content, &cfg)
+	if err != nil {
+		var typeErr *yaml.TypeError
+		if errors.As(err, &typeErr) {
+			return nil, &Error{Problems: typeErr.Errors}
+		}
+		return nil, &Error{Problems: []string{err.Error()}}
+	}
+
+	if cfg.Version == 0 {
+		cfg.Version = Version
+	}
+
+	err = cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Validate checks the config against the schema of its version, it returns
+// an *Error that lists all the problems.
+func (cfg *Config) Validate() error {
+	var problems []string
+	addProblem := func(format string, a ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, a...))
+	}
+
+	if cfg.Version != Version {
+		addProblem("unsupported version %d", cfg.Version)
+	}
+
+	if cfg.Checks != nil {
+		if !isRisk(cfg.Checks.HighRisk) {
+			addProblem("checks.high_risk should be between 0 and 1")
+		}
+		if !isRisk(cfg.Checks.ModerateRisk) {
+			addProblem("checks.moderate_risk should be between 0 and 1")
+		}
+		if t := cfg.Checks.Thresholds(); t.Moderate >= t.High {
+			addProblem("checks.moderate_risk should be less than checks.high_risk")
+		}
+	}
+
+	if err := cfg.Paths.Validate(); err != nil {
+		addProblem("paths: %v", err)
+	}
+
+	if err := cfg.Subsystems.Validate(); err != nil {
+		addProblem("subsystems: %v", err)
+	}
+
+	if err := cfg.Branches.Validate(); err != nil {
+		addProblem("branches: %v", err)
+	}
+
+	if len(problems) > 0 {
+		return &Error{Problems: problems}
+	}
+	return nil
+}
+
+func isRisk(v float32) bool {
+	return v >= 0 && v <= 1
+}
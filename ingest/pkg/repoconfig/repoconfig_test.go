@@ -0,0 +1,100 @@
+package repoconfig
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/repofuel/repofuel/ingest/pkg/branchfilter"
+	"github.com/repofuel/repofuel/ingest/pkg/classify"
+	"github.com/repofuel/repofuel/ingest/pkg/identity"
+	"github.com/repofuel/repofuel/ingest/pkg/subsystem"
+)
+
+func TestParse(t *testing.T) {
+	content := `
+version: 1
+checks:
+  enable: true
+  high_risk: 0.9
+paths:
+  generated: [api/gen/]
+  ignored: ["*.pb.go"]
+subsystems:
+  source: codeowners
+  rules:
+    - pattern: services/*/**
+      name: services/$1
+bots:
+  emails: [ci@example.com]
+branches:
+  include: [main, release/*]
+`
+	cfg, err := Parse([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enable := true
+	want := &Config{
+		Version: 1,
+		Checks:  &Checks{Enable: &enable, HighRisk: .9},
+		Paths:   &classify.PathConfig{Generated: []string{"api/gen/"}, Ignored: []string{"*.pb.go"}},
+		Subsystems: &subsystem.Config{
+			Source: subsystem.CodeOwners,
+			Rules:  []*subsystem.Rule{{Pattern: "services/*/**", Name: "services/$1"}},
+		},
+		Bots:     &identity.BotConfig{Emails: []string{"ci@example.com"}},
+		Branches: &branchfilter.Config{Include: []string{"main", "release/*"}},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("got %+v, want %+v", cfg, want)
+	}
+
+	if got := cfg.Checks.Thresholds(); got != (Thresholds{High: .9, Moderate: .5}) {
+		t.Errorf("unexpected thresholds %+v", got)
+	}
+}
+
+func TestParse_Defaults(t *testing.T) {
+	cfg, err := Parse([]byte("checks:\n  enable: false\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Version != Version {
+		t.Errorf("expected the latest version, got %d", cfg.Version)
+	}
+	if cfg.Checks.Enable == nil || *cfg.Checks.Enable {
+		t.Error("expected the checks to be disabled")
+	}
+	if cfg.Paths != nil || cfg.Subsystems != nil || cfg.Bots != nil {
+		t.Errorf("expected the missing sections to be nil, got %+v", cfg)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	cases := map[string]int{
+		"version: 2":                                     1,
+		"check:\n  enable: true":                         1,
+		"checks:\n  enable: yes please":                  1,
+		"checks:\n  high_risk: 1.5\n  moderate_risk: -1": 2,
+		"checks:\n  high_risk: 0.4":                      1,
+		"paths:\n  tests: [/]":                           1,
+		"subsystems:\n  source: owners":                  1,
+		"branches:\n  exclude: [\"release/[\"]":          1,
+		"version: 0\npaths: {ignored: [/]}\nsubsystems: {rules: [{pattern: a/**}]}": 2,
+	}
+	for content, count := range cases {
+		_, err := Parse([]byte(content))
+
+		var cfgErr *Error
+		if !errors.As(err, &cfgErr) {
+			t.Errorf("%q: expected a config error, got %v", content, err)
+			continue
+		}
+		if len(cfgErr.Problems) != count {
+			t.Errorf("%q: expected %d problems, got %q", content, count, cfgErr.Problems)
+		}
+	}
+}
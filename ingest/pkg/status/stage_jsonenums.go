@@ -28,6 +28,8 @@ var (
 		"Recovered":   Recovered,
 		"Ignored":     Ignored,
 		"Progressing": Progressing,
+		"Retrying":    Retrying,
+		"Paused":      Paused,
 	}
 
 	_StageValueToName = map[Stage]string{
@@ -50,6 +52,8 @@ var (
 		Recovered:   "Recovered",
 		Ignored:     "Ignored",
 		Progressing: "Progressing",
+		Retrying:    "Retrying",
+		Paused:      "Paused",
 	}
 )
 
@@ -76,6 +80,8 @@ func init() {
 			interface{}(Recovered).(fmt.Stringer).String():   Recovered,
 			interface{}(Ignored).(fmt.Stringer).String():     Ignored,
 			interface{}(Progressing).(fmt.Stringer).String(): Progressing,
+			interface{}(Retrying).(fmt.Stringer).String():    Retrying,
+			interface{}(Paused).(fmt.Stringer).String():      Paused,
 		}
 	}
 }
@@ -27,11 +27,13 @@ func _() {
 	_ = x[Recovered-16]
 	_ = x[Ignored-17]
 	_ = x[Progressing-18]
+	_ = x[Retrying-19]
+	_ = x[Paused-20]
 }
 
-const _Stage_name = "QueuedAddedCloningClonedFetchingFetchedIngestingIngestedAnalyzingAnalyzedPredictingPredictedReadyFailedCanceledWatchedRecoveredIgnoredProgressing"
+const _Stage_name = "QueuedAddedCloningClonedFetchingFetchedIngestingIngestedAnalyzingAnalyzedPredictingPredictedReadyFailedCanceledWatchedRecoveredIgnoredProgressingRetryingPaused"
 
-var _Stage_index = [...]uint8{0, 6, 11, 18, 24, 32, 39, 48, 56, 65, 73, 83, 92, 97, 103, 111, 118, 127, 134, 145}
+var _Stage_index = [...]uint8{0, 6, 11, 18, 24, 32, 39, 48, 56, 65, 73, 83, 92, 97, 103, 111, 118, 127, 134, 145, 153, 159}
 
 func (i Stage) String() string {
 	if i >= Stage(len(_Stage_index)-1) {
@@ -31,6 +31,8 @@ const (
 	Recovered
 	Ignored
 	Progressing
+	Retrying
+	Paused
 )
 
 var _StageEnumToStageValue = make(map[string]Stage, len(_StageNameToValue))
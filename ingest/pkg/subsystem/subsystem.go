@@ -0,0 +1,301 @@
+// Package subsystem maps the paths of a repository to its subsystems, which are
+// counted by the NS and SEXP metrics. The paths are mapped by the rules of the
+// repository, then by the source of the subsystems, and the first directory of
+// the path is the fallback.
+package subsystem
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/repofuel/repofuel/ingest/pkg/classify"
+	"github.com/repofuel/repofuel/ingest/pkg/engine"
+)
+
+type Source string
+
+const (
+	// Directory takes the first directory of the path.
+	Directory Source = "directory"
+	// CodeOwners takes the owners of the path in the CODEOWNERS file.
+	CodeOwners Source = "codeowners"
+	// Manifest takes the closest directory of the path that has a package
+	// manifest, such as a Go module or a package.json.
+	Manifest Source = "manifest"
+)
+
+// Rule names the subsystem of the paths that match its pattern.
+type Rule struct {
+	// Pattern matches the whole path. It is a glob, where `*` matches inside a
+	// directory and `**` matches across directories, or a regular expression
+	// if Regexp is set.
+	Pattern string `json:"pattern"  bson:"pattern"`
+	Regexp  bool   `json:"regexp"   bson:"regexp,omitempty"`
+	// Name is the subsystem, it can refer to the wildcards of the glob or the
+	// groups of the regular expression as $1, $2, and so on.
+	Name string `json:"name"  bson:"name"`
+}
+
+// Config is the subsystem configurations of a repository, a nil config takes
+// the first directory of the paths.
+type Config struct {
+	// Rules are tried in order, the first matching rule names the subsystem.
+	Rules []*Rule `json:"rules,omitempty"  bson:"rules,omitempty"`
+	// Source maps the paths that do not match any rule, it is Directory if
+	// it is empty.
+	Source Source `json:"source,omitempty"  bson:"source,omitempty"`
+}
+
+// Validate checks the source and compiles the patterns of the rules.
+func (cfg *Config) Validate() error {
+	_, err := cfg.compile()
+	return err
+}
+
+func (cfg *Config) compile() ([]*rule, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+
+	switch cfg.Source {
+	case "", Directory, CodeOwners, Manifest:
+	default:
+		return nil, fmt.Errorf("unknown subsystem source %q", cfg.Source)
+	}
+
+	rules := make([]*rule, len(cfg.Rules))
+	for i, r := range cfg.Rules {
+		if r.Name == "" {
+			return nil, fmt.Errorf("subsystem rule %q has no name", r.Pattern)
+		}
+
+		expr := r.Pattern
+		if !r.Regexp {
+			expr = globExpr(r.Pattern)
+		}
+
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("subsystem rule %q: %w", r.Pattern, err)
+		}
+		rules[i] = &rule{re: re, name: r.Name}
+	}
+
+	return rules, nil
+}
+
+type rule struct {
+	re   *regexp.Regexp
+	name string
+}
+
+func (r *rule) match(p string) (string, bool) {
+	m := r.re.FindStringSubmatchIndex(p)
+	if m == nil {
+		return "", false
+	}
+	return string(r.re.ExpandString(nil, r.name, p, m)), true
+}
+
+// globExpr converts a glob to a regular expression, every wildcard is a group.
+func globExpr(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString("(?:(.*)/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			b.WriteString("(.*)")
+			i += 1
+		case glob[i] == '*':
+			b.WriteString("([^/]*)")
+		case glob[i] == '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	return b.String()
+}
+
+// FileReader reads a file from the revision that the subsystems are derived
+// from, such as the head of the default branch. It returns engine.ErrFileNotFound
+// if the file does not exist.
+type FileReader func(path string) ([]byte, error)
+
+// codeOwnersPaths are the locations of the CODEOWNERS file, in the order that
+// GitHub looks for it.
+var codeOwnersPaths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// manifestFiles mark the directories of the modules and packages.
+var manifestFiles = []string{
+	"go.mod",
+	"package.json",
+	"pom.xml",
+	"build.gradle",
+	"build.gradle.kts",
+	"Cargo.toml",
+	"pyproject.toml",
+	"setup.py",
+	"composer.json",
+}
+
+// Mapper maps the paths to the subsystems, a nil mapper takes the first
+// directory of the paths. It is safe for concurrent use.
+type Mapper struct {
+	rules  []*rule
+	source Source
+	owners []*ownersRule
+	read   FileReader
+
+	mu        sync.Mutex
+	manifests map[string]bool
+}
+
+// New creates a mapper for the config, the reader is used to find the
+// CODEOWNERS file and the manifests.
+func New(cfg *Config, read FileReader) (*Mapper, error) {
+	rules, err := cfg.compile()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Mapper{
+		rules:     rules,
+		source:    Directory,
+		read:      read,
+		manifests: make(map[string]bool),
+	}
+	if cfg != nil && cfg.Source != "" {
+		m.source = cfg.Source
+	}
+
+	if m.source == CodeOwners {
+		m.owners, err = readCodeOwners(read)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+// Subsystem returns the subsystem of the path.
+func (m *Mapper) Subsystem(p string) string {
+	if m == nil {
+		return engine.SubsystemFromPath(p)
+	}
+
+	for _, r := range m.rules {
+		if name, ok := r.match(p); ok {
+			return name
+		}
+	}
+
+	switch m.source {
+	case CodeOwners:
+		if owners, ok := m.ownersOf(p); ok {
+			return owners
+		}
+	case Manifest:
+		if dir, ok := m.moduleOf(p); ok {
+			return dir
+		}
+	}
+
+	return engine.SubsystemFromPath(p)
+}
+
+// ownersOf returns the owners of the last matching pattern, as GitHub does.
+func (m *Mapper) ownersOf(p string) (string, bool) {
+	for i := len(m.owners) - 1; i >= 0; i-- {
+		if m.owners[i].re.MatchString(p) {
+			return m.owners[i].owners, m.owners[i].owners != ""
+		}
+	}
+	return "", false
+}
+
+// moduleOf returns the closest directory of the path that has a manifest, the
+// manifests of the root directory are ignored because they cover every path.
+func (m *Mapper) moduleOf(p string) (string, bool) {
+	for dir := path.Dir(p); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if m.hasManifest(dir) {
+			return dir + "/", true
+		}
+	}
+	return "", false
+}
+
+// hasManifest caches the directories that are checked, the read errors are
+// taken as missing manifests.
+func (m *Mapper) hasManifest(dir string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	found, ok := m.manifests[dir]
+	if ok {
+		return found
+	}
+
+	for _, name := range manifestFiles {
+		if _, err := m.read(path.Join(dir, name)); err == nil {
+			found = true
+			break
+		}
+	}
+	m.manifests[dir] = found
+	return found
+}
+
+type ownersRule struct {
+	re     *regexp.Regexp
+	owners string
+}
+
+func readCodeOwners(read FileReader) ([]*ownersRule, error) {
+	for _, p := range codeOwnersPaths {
+		content, err := read(p)
+		if err == engine.ErrFileNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return parseCodeOwners(content)
+	}
+	return nil, nil
+}
+
+// parseCodeOwners parses the patterns of a CODEOWNERS file, the owners of a
+// pattern are joined by spaces to name the subsystem.
+func parseCodeOwners(content []byte) ([]*ownersRule, error) {
+	var rules []*ownersRule
+
+	s := bufio.NewScanner(bytes.NewReader(content))
+	for s.Scan() {
+		line := s.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		re, err := classify.CompilePathPattern(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("codeowners pattern %q: %w", fields[0], err)
+		}
+		rules = append(rules, &ownersRule{re: re, owners: strings.Join(fields[1:], " ")})
+	}
+
+	return rules, s.Err()
+}
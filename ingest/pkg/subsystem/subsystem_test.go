@@ -0,0 +1,113 @@
+package subsystem
+
+import (
+	"testing"
+
+	"github.com/repofuel/repofuel/ingest/pkg/engine"
+)
+
+func reader(files map[string]string) FileReader {
+	return func(p string) ([]byte, error) {
+		content, ok := files[p]
+		if !ok {
+			return nil, engine.ErrFileNotFound
+		}
+		return []byte(content), nil
+	}
+}
+
+func TestMapper_Rules(t *testing.T) {
+	m, err := New(&Config{Rules: []*Rule{
+		{Pattern: "services/*/**", Name: "services/$1"},
+		{Pattern: `src/main/java/com/example/(\w+)/.*`, Regexp: true, Name: "$1"},
+		{Pattern: "**/*_test.go", Name: "tests"},
+	}}, reader(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]string{
+		"services/auth/cmd/main.go":                  "services/auth",
+		"src/main/java/com/example/billing/Tax.java": "billing",
+		"pkg/x/x_test.go":                            "tests",
+		"x_test.go":                                  "tests",
+		// the paths that do not match fall back to the first directory
+		"pkg/x/x.go": "pkg/",
+		"main.go":    "",
+	}
+	for p, want := range cases {
+		if got := m.Subsystem(p); got != want {
+			t.Errorf("%s: got %q, want %q", p, got, want)
+		}
+	}
+}
+
+func TestMapper_CodeOwners(t *testing.T) {
+	m, err := New(&Config{Source: CodeOwners}, reader(map[string]string{
+		".github/CODEOWNERS": "# owners\n" +
+			"*.js       @web\n" +
+			"/docs/     @docs # the documentation\n" +
+			"apps/api   @backend @ops\n" +
+			"apps/api/generated\n",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]string{
+		"web/index.js":            "@web",
+		"docs/guide.go":           "@docs",
+		"apps/api/server.go":      "@backend @ops",
+		"apps/api/handler.js":     "@backend @ops",
+		"apps/api/generated/a.go": "apps/",
+		"cmd/main.go":             "cmd/",
+	}
+	for p, want := range cases {
+		if got := m.Subsystem(p); got != want {
+			t.Errorf("%s: got %q, want %q", p, got, want)
+		}
+	}
+}
+
+func TestMapper_Manifest(t *testing.T) {
+	m, err := New(&Config{Source: Manifest}, reader(map[string]string{
+		"go.mod":                   "module example.com/x",
+		"services/auth/go.mod":     "module example.com/x/auth",
+		"web/app/package.json":     "{}",
+		"web/app/lib/package.json": "{}",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]string{
+		"services/auth/internal/token.go": "services/auth/",
+		"web/app/src/index.js":            "web/app/",
+		"web/app/lib/util.js":             "web/app/lib/",
+		"services/mail/main.go":           "services/",
+		"main.go":                         "",
+	}
+	for p, want := range cases {
+		if got := m.Subsystem(p); got != want {
+			t.Errorf("%s: got %q, want %q", p, got, want)
+		}
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	invalid := []*Config{
+		{Source: "owners"},
+		{Rules: []*Rule{{Pattern: "a/**"}}},
+		{Rules: []*Rule{{Pattern: "(", Regexp: true, Name: "a"}}},
+	}
+	for _, cfg := range invalid {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected an error for %+v", cfg)
+		}
+	}
+
+	var cfg *Config
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error for a nil config: %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package szz
+
+import (
+	"bytes"
+
+	"github.com/repofuel/repofuel/ingest/pkg/engine"
+)
+
+// agStrategy follows the annotation graph SZZ (AG-SZZ) in ignoring the lines
+// that cannot introduce bugs: the blank lines, the comment-only lines, and
+// the cosmetic changes that only reformat the code.
+type agStrategy struct{}
+
+func (agStrategy) Variant() Variant {
+	return AG
+}
+
+func (agStrategy) NeedsContent() bool {
+	return true
+}
+
+func (agStrategy) DeletedChunks(files []*File) [][]engine.ChunkAddr {
+	chunks := make([][]engine.ChunkAddr, len(files))
+	for i, f := range files {
+		chunks[i] = agDeletedChunks(newFileSources(f), f.Hunks, nil)
+	}
+	return chunks
+}
+
+type fileSources struct {
+	old, new *source
+}
+
+func newFileSources(f *File) *fileSources {
+	return &fileSources{
+		old: newSource(f.Language, f.OldContent),
+		new: newSource(f.Language, f.NewContent),
+	}
+}
+
+// agDeletedChunks returns the deleted code lines of the hunks that are not
+// cosmetic, the hunks that are reported by skip are ignored as well.
+func agDeletedChunks(src *fileSources, hunks []Hunk, skip func(int) bool) []engine.ChunkAddr {
+	var chunks []engine.ChunkAddr
+	for i, h := range hunks {
+		if h.Deleted.Len() == 0 || isCosmetic(src, h) || (skip != nil && skip(i)) {
+			continue
+		}
+
+		for line := h.Deleted.Start; line <= h.Deleted.End; line++ {
+			if src.old.isCode(line) {
+				chunks = appendLine(chunks, line)
+			}
+		}
+	}
+	return chunks
+}
+
+// isCosmetic reports whether the hunk changes the format of the code only,
+// such as splitting or joining lines, by comparing its code without spaces.
+func isCosmetic(src *fileSources, h Hunk) bool {
+	deleted := removeSpaces(bytes.Join(src.old.codeLines(h.Deleted), nil))
+	added := removeSpaces(bytes.Join(src.new.codeLines(h.Added), nil))
+	return len(deleted) > 0 && bytes.Equal(deleted, added)
+}
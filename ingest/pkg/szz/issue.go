@@ -0,0 +1,61 @@
+package szz
+
+import (
+	"time"
+
+	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+	"github.com/repofuel/repofuel/pkg/common"
+)
+
+type DropReason string
+
+const (
+	// DroppedAfterIssue is the reason of dropping the candidates that are
+	// authored after the issue of the fix was reported.
+	DroppedAfterIssue DropReason = "after_issue"
+)
+
+// DroppedCandidate is a blamed commit that is not considered as bug-inducing.
+type DroppedCandidate struct {
+	Commit identifier.Hash `json:"commit"  bson:"commit"`
+	Reason DropReason      `json:"reason"  bson:"reason"`
+}
+
+// IssueReportDate returns the date of the latest reported issue, it is the
+// zero time if the creation dates of the issues are unknown. The latest date
+// is used when the fix references several issues to not drop any candidate
+// that could induce one of them.
+func IssueReportDate(issues []common.Issue) time.Time {
+	var reported time.Time
+	for _, issue := range issues {
+		if issue.Fetched && issue.CreatedAt.After(reported) {
+			reported = issue.CreatedAt
+		}
+	}
+	return reported
+}
+
+// FilterByIssueDate removes from the candidates the commits that are authored
+// after the issue was reported, such commits cannot introduce the reported bug.
+// The candidates with unknown author dates are kept.
+func FilterByIssueDate(candidates identifier.HashSet, reported time.Time, authorDate func(identifier.Hash) (time.Time, bool)) []*DroppedCandidate {
+	if reported.IsZero() {
+		return nil
+	}
+
+	var dropped []*DroppedCandidate
+	for h := range candidates {
+		date, ok := authorDate(h)
+		if !ok || !date.After(reported) {
+			continue
+		}
+
+		candidates.Delete(h)
+		dropped = append(dropped, &DroppedCandidate{
+			Commit: h,
+			Reason: DroppedAfterIssue,
+		})
+	}
+
+	return dropped
+}
@@ -0,0 +1,157 @@
+package szz
+
+import (
+	"unicode"
+
+	"github.com/repofuel/repofuel/ingest/pkg/engine"
+)
+
+// raStrategy is a refactoring-aware SZZ (RA-SZZ) that builds on AG-SZZ and
+// ignores the hunks that only refactor the code. It detects two refactoring
+// kinds without parsing the code: moving code to another place in the commit,
+// and renaming identifiers consistently within a hunk.
+type raStrategy struct{}
+
+func (raStrategy) Variant() Variant {
+	return RA
+}
+
+func (raStrategy) NeedsContent() bool {
+	return true
+}
+
+func (raStrategy) DeletedChunks(files []*File) [][]engine.ChunkAddr {
+	sources := make([]*fileSources, len(files))
+	added := make(map[string]int)
+	for i, f := range files {
+		sources[i] = newFileSources(f)
+		for _, h := range f.Hunks {
+			for _, line := range sources[i].new.codeLines(h.Added) {
+				added[string(removeSpaces(line))] += 1
+			}
+		}
+	}
+
+	chunks := make([][]engine.ChunkAddr, len(files))
+	for i, f := range files {
+		src := sources[i]
+		chunks[i] = agDeletedChunks(src, f.Hunks, func(j int) bool {
+			h := f.Hunks[j]
+			deleted := src.old.codeLines(h.Deleted)
+			return isMoved(deleted, added) || isRenamed(deleted, src.new.codeLines(h.Added))
+		})
+	}
+	return chunks
+}
+
+// isMoved reports whether all the deleted lines are added somewhere else in the
+// commit. The matched lines are consumed, so the same added line does not
+// justify more than one deleted line.
+func isMoved(deleted [][]byte, added map[string]int) bool {
+	if len(deleted) == 0 {
+		return false
+	}
+
+	needed := make(map[string]int, len(deleted))
+	for _, line := range deleted {
+		needed[string(removeSpaces(line))] += 1
+	}
+
+	for line, n := range needed {
+		if added[line] < n {
+			return false
+		}
+	}
+
+	for line, n := range needed {
+		added[line] -= n
+	}
+
+	return true
+}
+
+// isRenamed reports whether the added lines are the deleted lines after
+// renaming some identifiers, where every identifier is renamed consistently.
+func isRenamed(deleted, added [][]byte) bool {
+	oldTokens := tokenize(deleted)
+	newTokens := tokenize(added)
+	if len(oldTokens) == 0 || len(oldTokens) != len(newTokens) {
+		return false
+	}
+
+	renames := make(map[string]string)
+	reversed := make(map[string]string)
+	var renamed bool
+
+	for i := range oldTokens {
+		o, n := oldTokens[i], newTokens[i]
+		if o == n {
+			continue
+		}
+
+		if !isIdentifier(o) || !isIdentifier(n) || keywords[o] || keywords[n] {
+			return false
+		}
+
+		if v, ok := renames[o]; ok && v != n {
+			return false
+		}
+		if v, ok := reversed[n]; ok && v != o {
+			return false
+		}
+
+		renames[o] = n
+		reversed[n] = o
+		renamed = true
+	}
+
+	return renamed
+}
+
+func tokenize(lines [][]byte) []string {
+	var tokens []string
+	for _, line := range lines {
+		runes := []rune(string(line))
+		for i := 0; i < len(runes); {
+			if runes[i] == ' ' {
+				i++
+				continue
+			}
+
+			j := i + 1
+			if isIdentifierRune(runes[i]) {
+				for j < len(runes) && isIdentifierRune(runes[j]) {
+					j++
+				}
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		}
+	}
+	return tokens
+}
+
+func isIdentifierRune(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func isIdentifier(token string) bool {
+	for _, r := range token {
+		return r == '_' || r == '$' || unicode.IsLetter(r)
+	}
+	return false
+}
+
+// keywords are the common keywords between the languages, replacing them is
+// a change in the behaviour and not a renaming.
+var keywords = map[string]bool{
+	"if": true, "else": true, "elif": true, "for": true, "foreach": true, "while": true, "do": true,
+	"switch": true, "case": true, "default": true, "break": true, "continue": true, "return": true,
+	"goto": true, "try": true, "catch": true, "except": true, "finally": true, "throw": true,
+	"throws": true, "raise": true, "new": true, "delete": true, "true": true, "false": true,
+	"null": true, "nil": true, "None": true, "True": true, "False": true, "undefined": true,
+	"and": true, "or": true, "not": true, "in": true, "is": true, "instanceof": true,
+	"typeof": true, "this": true, "self": true, "super": true, "static": true, "final": true,
+	"const": true, "var": true, "let": true, "public": true, "private": true, "protected": true,
+	"async": true, "await": true, "yield": true, "defer": true, "go": true, "select": true,
+}
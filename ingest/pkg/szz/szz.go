@@ -0,0 +1,237 @@
+// Package szz implements the variants of the SZZ algorithm, which select the
+// lines deleted by a fix commit that should be blamed to identify the
+// bug-inducing commits.
+package szz
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/repofuel/repofuel/ingest/pkg/classify"
+	"github.com/repofuel/repofuel/ingest/pkg/engine"
+	"github.com/repofuel/repofuel/pkg/metrics"
+)
+
+type Variant string
+
+const (
+	// Plain blames every deleted line in the fix.
+	Plain Variant = "szz"
+	// AG ignores the blank, comment-only, and cosmetic lines.
+	AG Variant = "ag-szz"
+	// RA ignores the refactoring-only hunks in addition to the lines ignored by AG.
+	RA Variant = "ra-szz"
+	// PlainCode is the plain variant without the blank and comment-only lines,
+	// it is recorded on the links when the metrics exclude the non-code lines
+	// and it is not configured by itself.
+	PlainCode Variant = "szz-code"
+)
+
+const (
+	DefaultMaxHD = 100
+	DefaultMaxLD = 5000
+	DefaultMaxNF = 50
+)
+
+// Config is the SZZ configurations of a repository. The zero values are
+// replaced by the defaults, so a nil config is a valid configuration.
+type Config struct {
+	Variant Variant `json:"variant"  bson:"variant,omitempty"`
+	MaxHD   int     `json:"max_hd"   bson:"max_hd,omitempty"`
+	MaxLD   int     `json:"max_ld"   bson:"max_ld,omitempty"`
+	MaxNF   int     `json:"max_nf"   bson:"max_nf,omitempty"`
+	// IssueDateFilter drops the candidates that are authored after the issue
+	// of the fix was reported.
+	IssueDateFilter bool `json:"issue_date_filter"  bson:"issue_date_filter,omitempty"`
+}
+
+// Validate checks that the variant is supported and the caps are not negative.
+func (cfg *Config) Validate() error {
+	if cfg == nil {
+		return nil
+	}
+
+	if _, err := New(cfg, false); err != nil {
+		return err
+	}
+
+	if cfg.MaxHD < 0 || cfg.MaxLD < 0 || cfg.MaxNF < 0 {
+		return errors.New("the SZZ caps should not be negative")
+	}
+	return nil
+}
+
+func (cfg *Config) variant() Variant {
+	if cfg == nil || cfg.Variant == "" {
+		return Plain
+	}
+	return cfg.Variant
+}
+
+// IsTraceable returns true if the fix is not too big to be traced, the big
+// commits are usually not fixes and they make the SZZ results noisy.
+func (cfg *Config) IsTraceable(m *metrics.ChangeMeasures) bool {
+	maxHD, maxLD, maxNF := DefaultMaxHD, DefaultMaxLD, DefaultMaxNF
+	if cfg != nil {
+		if cfg.MaxHD > 0 {
+			maxHD = cfg.MaxHD
+		}
+		if cfg.MaxLD > 0 {
+			maxLD = cfg.MaxLD
+		}
+		if cfg.MaxNF > 0 {
+			maxNF = cfg.MaxNF
+		}
+	}
+
+	return m.LD < float64(maxLD) &&
+		m.HD < float64(maxHD) &&
+		m.NF < float64(maxNF)
+}
+
+// Hunk holds the addresses of the deleted and added lines in a diff hunk.
+type Hunk struct {
+	Deleted engine.ChunkAddr
+	Added   engine.ChunkAddr
+}
+
+// File is a source code file that is changed by a fix commit.
+type File struct {
+	Language   string
+	Hunks      []Hunk
+	OldContent []byte
+	NewContent []byte
+}
+
+type Strategy interface {
+	Variant() Variant
+	// NeedsContent reports whether the strategy reads the content of the files.
+	NeedsContent() bool
+	// DeletedChunks returns for every file the chunks of the deleted lines that
+	// should be blamed, the result has the same order of the files.
+	DeletedChunks(files []*File) [][]engine.ChunkAddr
+}
+
+// New returns the strategy of the configured variant, excludeNonCode ignores the
+// deleted blank and comment-only lines. It only affects the plain variant
+// because the others ignore them already.
+func New(cfg *Config, excludeNonCode bool) (Strategy, error) {
+	switch v := cfg.variant(); v {
+	case Plain:
+		if excludeNonCode {
+			return codeOnlyStrategy{plainStrategy{}}, nil
+		}
+		return plainStrategy{}, nil
+	case AG:
+		return agStrategy{}, nil
+	case RA:
+		return raStrategy{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported SZZ variant: %q", v)
+	}
+}
+
+type plainStrategy struct{}
+
+func (plainStrategy) Variant() Variant {
+	return Plain
+}
+
+func (plainStrategy) NeedsContent() bool {
+	return false
+}
+
+func (plainStrategy) DeletedChunks(files []*File) [][]engine.ChunkAddr {
+	chunks := make([][]engine.ChunkAddr, len(files))
+	for i, f := range files {
+		for _, h := range f.Hunks {
+			if h.Deleted.Len() > 0 {
+				chunks[i] = append(chunks[i], h.Deleted)
+			}
+		}
+	}
+	return chunks
+}
+
+// codeOnlyStrategy removes the non-code lines from the chunks of the wrapped
+// strategy.
+type codeOnlyStrategy struct {
+	Strategy
+}
+
+func (codeOnlyStrategy) Variant() Variant {
+	return PlainCode
+}
+
+func (codeOnlyStrategy) NeedsContent() bool {
+	return true
+}
+
+func (s codeOnlyStrategy) DeletedChunks(files []*File) [][]engine.ChunkAddr {
+	chunks := s.Strategy.DeletedChunks(files)
+	for i, f := range files {
+		src := newSource(f.Language, f.OldContent)
+
+		var code []engine.ChunkAddr
+		for _, chunk := range chunks[i] {
+			for line := chunk.Start; line <= chunk.End; line++ {
+				if src.isCode(line) {
+					code = appendLine(code, line)
+				}
+			}
+		}
+		chunks[i] = code
+	}
+	return chunks
+}
+
+// source is the content of a file version with its classified lines.
+type source struct {
+	lines [][]byte
+	types []classify.LineType
+}
+
+func newSource(lang string, content []byte) *source {
+	lines := bytes.Split(content, []byte{'\n'})
+	return &source{
+		lines: lines,
+		types: classify.ClassifyLines(lang, content),
+	}
+}
+
+// isCode reports whether the line has code, the line number is one-based. The
+// unknown lines are considered code to not miss them.
+func (s *source) isCode(line int) bool {
+	if line < 1 || line > len(s.types) {
+		return true
+	}
+	return s.types[line-1] == classify.LineCode
+}
+
+// codeLines returns the code lines in the chunk, the whitespaces between the
+// words are replaced by a single space.
+func (s *source) codeLines(chunk engine.ChunkAddr) [][]byte {
+	var lines [][]byte
+	for line := chunk.Start; line <= chunk.End; line++ {
+		if line < 1 || line > len(s.lines) || !s.isCode(line) {
+			continue
+		}
+		lines = append(lines, bytes.Join(bytes.Fields(s.lines[line-1]), []byte{' '}))
+	}
+	return lines
+}
+
+func removeSpaces(b []byte) []byte {
+	return bytes.ReplaceAll(b, []byte{' '}, nil)
+}
+
+// appendLine adds the line to the chunks, it extends the last chunk if the
+// line follows it directly.
+func appendLine(chunks []engine.ChunkAddr, line int) []engine.ChunkAddr {
+	if n := len(chunks); n > 0 && chunks[n-1].End == line-1 {
+		chunks[n-1].End = line
+		return chunks
+	}
+	return append(chunks, engine.ChunkAddr{Start: line, End: line})
+}
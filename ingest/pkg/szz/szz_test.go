@@ -0,0 +1,238 @@
+package szz
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/repofuel/repofuel/ingest/pkg/engine"
+	"github.com/repofuel/repofuel/ingest/pkg/identifier"
+	"github.com/repofuel/repofuel/pkg/common"
+	"github.com/repofuel/repofuel/pkg/metrics"
+)
+
+func chunk(start, end int) engine.ChunkAddr {
+	return engine.ChunkAddr{Start: start, End: end}
+}
+
+func TestNew(t *testing.T) {
+	for _, v := range []Variant{"", Plain, AG, RA} {
+		s, err := New(&Config{Variant: v}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != "" && s.Variant() != v {
+			t.Errorf("expected %s, got %s", v, s.Variant())
+		}
+	}
+
+	s, err := New(nil, false)
+	if err != nil || s.Variant() != Plain {
+		t.Errorf("expected the plain variant by default")
+	}
+
+	_, err = New(&Config{Variant: "unknown"}, false)
+	if err == nil {
+		t.Error("expected an error for unknown variants")
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	for _, cfg := range []*Config{nil, {}, {Variant: RA, MaxHD: 10}} {
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("unexpected error for %+v: %v", cfg, err)
+		}
+	}
+
+	for _, cfg := range []*Config{{Variant: "unknown"}, {MaxLD: -1}} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected an error for %+v", cfg)
+		}
+	}
+}
+
+func TestConfig_IsTraceable(t *testing.T) {
+	m := &metrics.ChangeMeasures{LD: 10, HD: 3, NF: 2}
+
+	var cfg *Config
+	if !cfg.IsTraceable(m) {
+		t.Error("expected to be traceable with the default caps")
+	}
+
+	cfg = &Config{MaxHD: 3}
+	if cfg.IsTraceable(m) {
+		t.Error("expected to exceed the hunks cap")
+	}
+}
+
+func TestPlainStrategy(t *testing.T) {
+	files := []*File{{
+		Hunks: []Hunk{
+			{Deleted: chunk(2, 3), Added: chunk(2, 2)},
+			{Deleted: chunk(6, 5), Added: chunk(6, 6)},
+		},
+	}}
+
+	got := plainStrategy{}.DeletedChunks(files)
+	want := [][]engine.ChunkAddr{{chunk(2, 3)}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestCodeOnlyStrategy(t *testing.T) {
+	s, err := New(nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.NeedsContent() || s.Variant() != PlainCode {
+		t.Fatal("expected the code-only plain variant that reads the content")
+	}
+
+	files := []*File{{
+		Language: "Go",
+		OldContent: []byte("package main\n" +
+			"// comment\n" +
+			"\n" +
+			"x := 1\n" +
+			"y := 2\n"),
+		NewContent: []byte("package main\n"),
+		Hunks: []Hunk{
+			{Deleted: chunk(2, 5), Added: chunk(2, 1)},
+		},
+	}}
+
+	got := s.DeletedChunks(files)
+	want := [][]engine.ChunkAddr{{chunk(4, 5)}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestAGStrategy(t *testing.T) {
+	files := []*File{{
+		Language: "Go",
+		OldContent: []byte("package main\n" +
+			"// comment\n" +
+			"\n" +
+			"x := 1\n" +
+			"y := f(a,\n" +
+			"\tb)\n"),
+		NewContent: []byte("package main\n" +
+			"x := 2\n" +
+			"y := f(a, b)\n"),
+		Hunks: []Hunk{
+			{Deleted: chunk(2, 4), Added: chunk(2, 2)},
+			{Deleted: chunk(5, 6), Added: chunk(3, 3)},
+		},
+	}}
+
+	got := agStrategy{}.DeletedChunks(files)
+	want := [][]engine.ChunkAddr{{chunk(4, 4)}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestRAStrategy(t *testing.T) {
+	files := []*File{
+		{
+			Language: "Go",
+			OldContent: []byte("func a() {\n" +
+				"\tcount := load()\n" +
+				"\treturn count + 1\n" +
+				"}\n" +
+				"func b() {\n" +
+				"\treturn moved()\n" +
+				"}\n" +
+				"func c() {\n" +
+				"\treturn 1\n" +
+				"}\n"),
+			NewContent: []byte("func a() {\n" +
+				"\tn := load()\n" +
+				"\treturn n + 1\n" +
+				"}\n" +
+				"func c() {\n" +
+				"\treturn 2\n" +
+				"}\n"),
+			Hunks: []Hunk{
+				{Deleted: chunk(2, 3), Added: chunk(2, 3)},
+				{Deleted: chunk(5, 7), Added: chunk(4, 3)},
+				{Deleted: chunk(9, 9), Added: chunk(6, 6)},
+			},
+		},
+		{
+			Language:   "Go",
+			NewContent: []byte("func b() {\n\treturn moved()\n}\n"),
+			Hunks: []Hunk{
+				{Deleted: chunk(0, -1), Added: chunk(1, 3)},
+			},
+		},
+	}
+
+	got := raStrategy{}.DeletedChunks(files)
+	want := [][]engine.ChunkAddr{{chunk(9, 9)}, nil}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestIsRenamed(t *testing.T) {
+	tests := []struct {
+		deleted, added string
+		want           bool
+	}{
+		{"x := a + a", "y := a + a", true},
+		{"return 1", "return 2", false},
+		{"x:=a+b", "y:=y+b", false},
+		{"if(x){", "while(x){", false},
+		{"x:=1", "x:=2", false},
+		{"x:=a", "x:=a", false},
+	}
+
+	for _, tt := range tests {
+		got := isRenamed([][]byte{[]byte(tt.deleted)}, [][]byte{[]byte(tt.added)})
+		if got != tt.want {
+			t.Errorf("isRenamed(%q, %q) = %v, want %v", tt.deleted, tt.added, got, tt.want)
+		}
+	}
+}
+
+func TestFilterByIssueDate(t *testing.T) {
+	reported := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	before, after, unknown := identifier.Hash{1}, identifier.Hash{2}, identifier.Hash{3}
+
+	dates := map[identifier.Hash]time.Time{
+		before: reported.Add(-time.Hour),
+		after:  reported.Add(time.Hour),
+	}
+
+	issues := []common.Issue{
+		{Id: "1", Fetched: true, CreatedAt: reported.Add(-24 * time.Hour)},
+		{Id: "2", Fetched: true, CreatedAt: reported},
+		{Id: "3", Fetched: false},
+	}
+
+	candidates := identifier.NewHashSet()
+	candidates.Add(before)
+	candidates.Add(after)
+	candidates.Add(unknown)
+
+	dropped := FilterByIssueDate(candidates, IssueReportDate(issues), func(h identifier.Hash) (time.Time, bool) {
+		date, ok := dates[h]
+		return date, ok
+	})
+
+	want := []*DroppedCandidate{{Commit: after, Reason: DroppedAfterIssue}}
+	if !reflect.DeepEqual(dropped, want) {
+		t.Errorf("dropped %v, want %v", dropped, want)
+	}
+
+	if candidates.Count() != 2 || !candidates.Has(before) || !candidates.Has(unknown) {
+		t.Errorf("unexpected candidates: %v", candidates.Slice())
+	}
+
+	if d := FilterByIssueDate(candidates, time.Time{}, nil); d != nil {
+		t.Errorf("expected no filtering without the issue dates, got %v", d)
+	}
+}
@@ -64,10 +64,12 @@ func (h *Handler) Prediction(w http.ResponseWriter, r *http.Request) {
 	repoId := chi.URLParam(r, "repo_id")
 	currentJob := chi.URLParam(r, "job")
 	oldestJob := r.FormValue("oldest_job")
+	// all the commits are predicted when there is no oldest job
+	all := r.FormValue("all") == "true"
 
 	log.Println("received prediction request for repo: ", repoId)
 
-	if oldestJob == "" {
+	if oldestJob == "" && !all {
 		oldestJob = currentJob
 	}
 
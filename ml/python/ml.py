@@ -12,8 +12,9 @@ experience = ['exp', 'rexp', 'sexp']
 history = ['ndev', 'nuc', 'age']
 size = ['la', 'ld', 'lt']
 diffusion = ['ns', 'nd', 'nf', 'entropy']
+complexity = ['cc', 'dcc', 'nest', 'nfn']
 
-features = experience + history + size + diffusion
+features = experience + history + size + diffusion + complexity
 
 dimensions = {
     "experience": experience,
@@ -29,14 +30,23 @@ id_column = 'commit_id'
 ignored_days = 90
 
 
+def available_features(df):
+    # the complexity is missing in the commits that are analyzed before
+    # measuring it, it is used only when all the commits have it
+    base = [f for f in features if f not in complexity]
+    df = df.dropna(subset=base, axis=0)
+    return base + [f for f in complexity if f in df and df[f].notna().all()]
+
+
 def prepare_data(df, oldest_ignored_date):
-    df = df.dropna(subset=features + [target], axis=0)
+    model_features = available_features(df)
+    df = df.dropna(subset=model_features + [target], axis=0)
     df = df.loc[df[date_column] < oldest_ignored_date]
 
     if len(df) > max_training_rows:
         df = df.sort_values(date_column, ascending=False)[:max_training_rows]
 
-    X = df.loc[:, features]
+    X = df.loc[:, model_features]
     y = df.loc[:, target].astype(str)
 
     # from sklearn.preprocessing import StandardScaler
@@ -132,7 +142,7 @@ def build_and_predict(collector, params, model_path):
     result["model_report"] = {
         "format": "onnx",
         "params": model.get_params(),
-        "feature_importance": match_ordered_lists(features, model.feature_importances_),
+        "feature_importance": match_ordered_lists(list(X.columns), model.feature_importances_),
         "accuracy": report.get("accuracy", 0),
         "medians_score": medians_score,
         "buggy": report.get("True", {}),
@@ -177,13 +187,20 @@ def load_and_predict(collector, model, medians, medians_score):
 
 
 def predict(onnx_model, df, medians, medians_score):
+    # the models that are built before adding a feature do not have its median
+    model_features = [f for f in features if f in medians]
+
+    # the missing measures are neutral, they are filled with the medians
+    missing = {f: np.ravel(medians[f])[0] for f in model_features}
+
     df = df.reset_index()
-    score = pd.DataFrame(onnx_model.predict(df.loc[:, features]))["True"]
+    df_features = df.loc[:, model_features].fillna(missing)
+    score = pd.DataFrame(onnx_model.predict(df_features))["True"]
 
     result = pd.DataFrame()
     for name, dimension_features in dimensions.items():
-        dimension_df = df.loc[:, features]
-        for feature in [f for f in features if f not in dimension_features]:
+        dimension_df = df_features.copy()
+        for feature in [f for f in model_features if f not in dimension_features]:
             dimension_df[feature] = medians[feature]
         result[name] = (pd.DataFrame(onnx_model.predict(dimension_df))["True"] - medians_score) / medians_score
 
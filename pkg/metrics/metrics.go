@@ -39,6 +39,16 @@ type ChangeMeasures struct {
 	REXP float64 `json:"rexp"`
 	// SEXP is the developer experience on modified subsystems
 	SEXP float64 `json:"sexp"`
+	// CC is the cyclomatic complexity of the modified files after the commit,
+	// the complexity measures are nil for the commits that are analyzed before
+	// measuring them.
+	CC *float64 `json:"cc,omitempty" bson:"cc,omitempty"`
+	// DCC is the change of the cyclomatic complexity of the modified files
+	DCC *float64 `json:"dcc,omitempty" bson:"dcc,omitempty"`
+	// NEST is the maximum nesting depth in the modified files after the commit
+	NEST *float64 `json:"nest,omitempty" bson:"nest,omitempty"`
+	// NFN is the number of functions in the modified files after the commit
+	NFN *float64 `json:"nfn,omitempty" bson:"nfn,omitempty"`
 }
 
 func (m ChangeMeasures) GetHeaders() []string {
@@ -62,7 +72,15 @@ func (m ChangeMeasures) ToSlice() []string {
 
 	r := make([]string, n)
 	for i := 0; i < n; i++ {
-		r[i] = fmt.Sprint(val.Field(i).Interface())
+		field := val.Field(i)
+		if field.Kind() == reflect.Ptr {
+			if field.IsNil() {
+				// the missing measures are empty
+				continue
+			}
+			field = field.Elem()
+		}
+		r[i] = fmt.Sprint(field.Interface())
 	}
 
 	return r
@@ -93,6 +111,54 @@ type FileMeasures struct {
 	REXP float64 `json:"rexp"`
 }
 
+// ComplexityMeasures are the static measures of the new version of a file, the
+// measures of a deleted file are zeros.
+type ComplexityMeasures struct {
+	// CC is the cyclomatic complexity of the file
+	CC float64 `json:"cc"`
+	// DCC is the change of the cyclomatic complexity from the parent
+	DCC float64 `json:"dcc"`
+	// NEST is the maximum nesting depth in the functions of the file
+	NEST float64 `json:"nest"`
+	// NFN is the number of functions in the file
+	NFN float64 `json:"nfn"`
+}
+
+// FunctionMeasures are the measures of a changed function, the history of the
+// function is found by its name in the history of the file.
+type FunctionMeasures struct {
+	// LA is lines of code added in the function
+	LA float64 `json:"la"`
+	// LD is lines of code deleted in the function
+	LD float64 `json:"ld"`
+	// NDEV is the number of developers that changed the function in the past
+	NDEV float64 `json:"ndev"`
+	// NUC is the number of unique changes that touched the function
+	NUC float64 `json:"nuc"`
+	// NFC is the number of fix changes that touched the function
+	NFC float64 `json:"nfc"`
+}
+
+// LineMeasures are the size measures of a change, they are calculated either
+// from all the changed lines or from the code lines only.
+type LineMeasures struct {
+	// LA is lines added
+	LA float64 `json:"la"`
+	// LD is lines deleted
+	LD float64 `json:"ld"`
+	// HA is hunks that add lines
+	HA float64 `json:"ha"`
+	// HD is hunks that delete lines
+	HD float64 `json:"hd"`
+}
+
+func (m *LineMeasures) Add(m2 *LineMeasures) {
+	m.LA += m2.LA
+	m.LD += m2.LD
+	m.HA += m2.HA
+	m.HD += m2.HD
+}
+
 type Quantiles struct {
 	Commit    map[string]*ChangeMeasures `json:"commit"`
 	Developer map[string]*ChangeMeasures `json:"developer"`
@@ -57,3 +57,18 @@ func (s *MLService) PredictByJob(ctx context.Context, repoID string, currentJob
 	resp, err := s.client.Do(req, &prediction)
 	return &prediction, resp, err
 }
+
+// PredictAllByJob predicts all the commits of the repository until the current
+// job, such as after recomputing their metrics.
+func (s *MLService) PredictAllByJob(ctx context.Context, repoID string, currentJob string) (*PredictionResult, *http.Response, error) {
+	u := fmt.Sprintf("repositories/%s/jobs/%s/prediction?all=true", repoID, currentJob)
+
+	req, err := (*service)(s).NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var prediction PredictionResult
+	resp, err := s.client.Do(req, &prediction)
+	return &prediction, resp, err
+}